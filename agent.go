@@ -0,0 +1,254 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - agent.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: f090033b-c7a4-11f1-9526-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// agentSnapshotPath is where an agent serves its whatjson.Snapshot.
+const agentSnapshotPath = "/v1/snapshot"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// loadCertPool reads a PEM bundle of CA certificates.
+func loadCertPool(name string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no certificates found",
+			name)
+	}
+
+	return pool, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// gobMediaType is the content type of a gob-encoded whatjson.Snapshot.
+const gobMediaType = "application/x-gob"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// snapshotHandler serves a fresh collection as a whatjson.Snapshot on every request, labeled with
+// the node if it is set, as JSON or (if the request accepts it) gob.  A query parameter selects
+// sessions, as --query does.
+func snapshotHandler(opts what.Options, node string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var filter sessionFilter
+
+		if text := r.URL.Query().Get("query"); text != "" {
+			q, err := parseQuery(text)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+
+				return
+			}
+
+			filter.Query = q
+		}
+
+		snap, err := what.Collect(r.Context(), opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+
+			return
+		}
+
+		filter.apply(snap)
+
+		doc := jsonSnapshot(snap, opts.Sample > 0)
+		doc.Node = node
+
+		// Aggregators polling many agents ask for gob, which is cheaper to produce and parse.
+		if strings.Contains(r.Header.Get("Accept"), gobMediaType) {
+			w.Header().Set("Content-Type", gobMediaType)
+			_ = gob.NewEncoder(w).Encode(doc)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(doc)
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// agentMain implements "go-what agent": the collection served over HTTPS, for aggregators
+// (go-what --agents) to query without logging in to every host.
+func agentMain(args []string) int {
+	flags := flag.NewFlagSet("go-what agent", flag.ExitOnError)
+
+	listen := flags.String("listen", ":7070",
+		"the address to serve on")
+	certFile := flags.String("cert", "",
+		"the server's TLS certificate (PEM), required")
+	keyFile := flags.String("key", "",
+		"the private key of --cert (PEM), required")
+	mtls := flags.Bool("mtls", false,
+		"require clients to present a certificate signed by --client-ca")
+	clientCA := flags.String("client-ca", "",
+		"the CA certificates (PEM) that client certificates are verified against")
+	origin := flags.Bool("origin", false,
+		"resolve the process holding each pty master, as go-what --origin does")
+	humansOnly := flags.Bool("humans-only", false,
+		"leave out sessions spawned by automation")
+	advertised := flags.Bool("advertise", false,
+		"answer mDNS/DNS-SD queries for "+mdnsService+", for go-what --discover")
+	sandboxed := flags.Bool("sandbox", true,
+		"once listening, keep only the capabilities collecting uses, and deny exec and other"+
+			" system calls (seccomp)")
+
+	_ = flags.Parse(args)
+
+	if *certFile == "" || *keyFile == "" {
+		fmt.Fprintf(os.Stderr, "go-what: agent: --cert and --key are required, to serve over TLS\n")
+
+		return 2
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if *mtls {
+		if *clientCA == "" {
+			fmt.Fprintf(os.Stderr, "go-what: agent: --mtls needs --client-ca\n")
+
+			return 2
+		}
+
+		pool, err := loadCertPool(*clientCA)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: agent: %v\n",
+				err)
+
+			return 1
+		}
+
+		config.ClientCAs, config.ClientAuth = pool, tls.RequireAndVerifyClientCert
+	}
+
+	opts := what.Options{
+		Origin:     *origin,
+		HumansOnly: *humansOnly,
+		Graphical:  true,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET "+agentSnapshotPath, snapshotHandler(opts, ""))
+	handleProbes(mux, opts)
+
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           mux,
+		TLSConfig:         config,
+		ReadHeaderTimeout: 10 * time.Second,
+		ErrorLog:          log.New(os.Stderr, "go-what: agent: ", 0),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = server.Shutdown(shutdown)
+	}()
+
+	if *advertised {
+		_, portText, _ := net.SplitHostPort(*listen)
+
+		port, err := strconv.Atoi(portText)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: agent: --advertise: bad port in --listen %q\n",
+				*listen)
+
+			return 2
+		}
+
+		// Advertise the name the certificate is for, so discovered agents verify by name.
+		var serverName string
+		if cert, err := tls.LoadX509KeyPair(*certFile, *keyFile); err == nil &&
+			len(cert.Leaf.DNSNames) > 0 {
+			serverName = cert.Leaf.DNSNames[0]
+		}
+
+		go func() {
+			if err := advertise(ctx, port, serverName); err != nil {
+				fmt.Fprintf(os.Stderr, "go-what: agent: --advertise: %v\n",
+					err)
+			}
+		}()
+	}
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-what: agent: %v\n",
+			err)
+
+		return 1
+	}
+
+	if *sandboxed {
+		if err := confineService(); err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: agent: --sandbox: %v\n",
+				err)
+		}
+	}
+
+	err = server.ServeTLS(listener, *certFile, *keyFile)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "go-what: agent: %v\n",
+			err)
+
+		return 1
+	}
+
+	return 0
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,107 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - alerts.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: a6ed3416-c7af-11f1-ba90-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// alert is raised by an alertRule for a session that calls for someone to look.
+type alert struct {
+	Time    time.Time
+	Rule    string
+	TTY     *what.TTY
+	Message string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// alertRule watches the events of --follow and raises alerts from them.
+type alertRule interface {
+	check(event what.SessionEvent) []alert
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// concurrentLogins is the --alert-concurrent rule: it raises a "concurrent-login" alert when
+// an account logs in remotely while it has a session open from another source address, as a
+// shared or stolen credential would.  Local sessions, with no source, are not counted.
+type concurrentLogins struct {
+	remote map[string]*what.TTY // by terminal name
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// newConcurrentLogins returns the rule with no sessions seen.
+func newConcurrentLogins() *concurrentLogins {
+	return &concurrentLogins{remote: make(map[string]*what.TTY)}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// check implements alertRule.  A session is checked when it starts, or when its terminal is
+// taken by another account or source.
+func (c *concurrentLogins) check(event what.SessionEvent) []alert {
+	tty := event.TTY
+
+	if event.Type == what.SessionRemoved || tty.Host == "" {
+		delete(c.remote, tty.Name)
+
+		return nil
+	}
+
+	seen := c.remote[tty.Name]
+	c.remote[tty.Name] = tty
+
+	if seen != nil && seen.Stat.Uid == tty.Stat.Uid && seen.Host == tty.Host {
+		return nil
+	}
+
+	var others []string
+
+	for name, other := range c.remote {
+		if name != tty.Name && other.Stat.Uid == tty.Stat.Uid && other.Host != tty.Host {
+			others = append(others, other.Name+" from "+other.Host)
+		}
+	}
+
+	if len(others) == 0 {
+		return nil
+	}
+
+	slices.Sort(others)
+
+	return []alert{{
+		Time: event.Time,
+		Rule: "concurrent-login",
+		TTY:  tty,
+		Message: fmt.Sprintf("%s logged in on %s from %s while also on %s",
+			username(tty.Stat.Uid), tty.Name, tty.Host, strings.Join(others, ", ")),
+	}}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,228 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - alerts_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 6ffec0d3-c7b1-11f1-bc5d-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+	"github.com/johnsonjh/go-what/whatjson"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// remoteTTY returns a pty session of uid logged in from host.
+func remoteTTY(name string, uid uint32, host string) *what.TTY {
+	return &what.TTY{Name: name, Host: host, Stat: syscall.Stat_t{Uid: uid}}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestConcurrentLogins(t *testing.T) {
+	type step struct {
+		typ  what.EventType
+		tty  *what.TTY
+		want string // the alert's message, or "" for none
+	}
+
+	for _, tc := range []struct {
+		name  string
+		steps []step
+	}{
+		{"one session", []step{
+			{what.SessionAdded, remoteTTY("pts/0", 1000, "192.0.2.1"), ""},
+		}},
+		{"two from one address", []step{
+			{what.SessionAdded, remoteTTY("pts/0", 1000, "192.0.2.1"), ""},
+			{what.SessionAdded, remoteTTY("pts/1", 1000, "192.0.2.1"), ""},
+		}},
+		{"two from two addresses", []step{
+			{what.SessionAdded, remoteTTY("pts/0", 1000, "192.0.2.1"), ""},
+			{
+				what.SessionAdded, remoteTTY("pts/1", 1000, "198.51.100.7"),
+				"alice logged in on pts/1 from 198.51.100.7 while also on pts/0 from 192.0.2.1",
+			},
+		}},
+		{"two users from two addresses", []step{
+			{what.SessionAdded, remoteTTY("pts/0", 1000, "192.0.2.1"), ""},
+			{what.SessionAdded, remoteTTY("pts/1", 1001, "198.51.100.7"), ""},
+		}},
+		{"after the first logged out", []step{
+			{what.SessionAdded, remoteTTY("pts/0", 1000, "192.0.2.1"), ""},
+			{what.SessionRemoved, remoteTTY("pts/0", 1000, "192.0.2.1"), ""},
+			{what.SessionAdded, remoteTTY("pts/1", 1000, "198.51.100.7"), ""},
+		}},
+		{"a local session does not count", []step{
+			{what.SessionAdded, remoteTTY("tty1", 1000, ""), ""},
+			{what.SessionAdded, remoteTTY("pts/1", 1000, "198.51.100.7"), ""},
+		}},
+		{"an update of the same login", []step{
+			{what.SessionAdded, remoteTTY("pts/0", 1000, "192.0.2.1"), ""},
+			{
+				what.SessionAdded, remoteTTY("pts/1", 1000, "198.51.100.7"),
+				"alice logged in on pts/1 from 198.51.100.7 while also on pts/0 from 192.0.2.1",
+			},
+			{what.SessionUpdated, remoteTTY("pts/1", 1000, "198.51.100.7"), ""},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rule := newConcurrentLogins()
+
+			for i, s := range tc.steps {
+				alerts := rule.check(what.SessionEvent{Type: s.typ, Time: time.Now(), TTY: s.tty})
+
+				var got string
+				if len(alerts) > 0 {
+					got = alerts[0].Message
+
+					if len(alerts) > 1 || alerts[0].Rule != "concurrent-login" ||
+						alerts[0].TTY != s.tty {
+						t.Errorf("step %d raised %+v",
+							i, alerts)
+					}
+				}
+
+				if got != s.want {
+					t.Errorf("step %d alerted %q, want %q",
+						i, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// testAlert is an alert of the sink tests.
+var testAlert = alert{
+	Time:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	Rule:    "concurrent-login",
+	TTY:     remoteTTY("pts/1", 1000, "198.51.100.7"),
+	Message: "alice logged in twice",
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestAlertFormats(t *testing.T) {
+	syslog, err := newSyslogSink("udp://127.0.0.1", "authpriv", "info", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		line string
+		want []string
+	}{
+		{"table", alertLine(testAlert), []string{
+			"alert", "alice", "pts/1", "concurrent-login: alice logged in twice",
+		}},
+		{"cef", cefAlertLine(testAlert), []string{
+			"|alert-concurrent-login|Alert concurrent-login|7|", "act=alert", "suser=alice",
+			"src=198.51.100.7", "msg=alice logged in twice",
+		}},
+		{"leef", leefAlertLine(testAlert), []string{
+			"|alert-concurrent-login|", "action=alert", "usrName=alice", "sev=7",
+			"\tmsg=alice logged in twice",
+		}},
+		// authpriv (10) at warning (4), raised from the configured info.
+		{"syslog", syslog.alertMessage(testAlert), []string{
+			"<84>1 2026-01-02T03:04:05.000000Z ", " alert [what@32473 alert=\"concurrent-login\"",
+			`user="alice" uid="1000" tty="pts/1" from="198.51.100.7"] alice logged in twice`,
+		}},
+		{"journal", string(alertEntry(testAlert)), []string{
+			"MESSAGE=alice logged in twice\n", "PRIORITY=4\n", "WHAT_ALERT=concurrent-login\n",
+			"WHAT_FROM=198.51.100.7\n",
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, want := range tc.want {
+				if !strings.Contains(tc.line, want) {
+					t.Errorf("%q does not contain %q",
+						tc.line, want)
+				}
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestWebhook(t *testing.T) {
+	var got whatjson.Alert
+
+	status := http.StatusNoContent
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" ||
+			json.Unmarshal(body, &got) != nil {
+			t.Errorf("the webhook was sent %s %q",
+				r.Method, body)
+		}
+
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink, err := newWebhookSink(server.URL + "/alerts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := what.SessionEvent{Type: what.SessionAdded, TTY: testAlert.TTY}
+	if err := sink.Send(event); err != nil {
+		t.Errorf("Send: %v",
+			err)
+	}
+
+	if err := sink.Alert(testAlert); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.SchemaVersion != whatjson.SchemaVersion || got.Rule != testAlert.Rule ||
+		got.Message != testAlert.Message || !got.Time.Equal(testAlert.Time) {
+		t.Errorf("the webhook was posted %+v",
+			got)
+	}
+
+	status = http.StatusBadGateway
+
+	if err := sink.Alert(testAlert); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Alert to a failing webhook returned %v, want its status",
+			err)
+	}
+
+	for _, target := range []string{"ftp://example.com/", "example.com", "http://"} {
+		if _, err := newWebhookSink(target); err == nil {
+			t.Errorf("newWebhookSink(%q) took it as a webhook URL",
+				target)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
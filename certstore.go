@@ -0,0 +1,118 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - certstore.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 505a414c-c7a6-11f1-81db-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// certStore serves TLS certificates from files that something else keeps up to date, such as
+// an ACME client: each is read again once it changes, so renewals take effect without a
+// restart.  A certificate for the name a client asks for is looked up in Dir, as
+// NAME/fullchain.pem with NAME/privkey.pem (the layout of certbot's live directory) or as
+// NAME.crt with NAME.key; CertFile and KeyFile are served otherwise.
+type certStore struct {
+	CertFile string
+	KeyFile  string
+	Dir      string
+
+	mu     sync.Mutex
+	loaded map[string]*loadedCert
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// loadedCert is a certificate as read from certFile when it was last modified at mtime.
+type loadedCert struct {
+	cert  *tls.Certificate
+	mtime time.Time
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// load returns the certificate of certFile and keyFile, reading them again if certFile has
+// changed since they were last read.
+func (s *certStore) load(certFile, keyFile string) (*tls.Certificate, error) {
+	fi, err := os.Stat(certFile)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c := s.loaded[certFile]; c != nil && c.mtime.Equal(fi.ModTime()) {
+		return c.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.loaded == nil {
+		s.loaded = make(map[string]*loadedCert)
+	}
+
+	s.loaded[certFile] = &loadedCert{cert: &cert, mtime: fi.ModTime()}
+
+	return &cert, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (s *certStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	// A name is used in a path, so only plain DNS names are looked up.
+	if name := strings.ToLower(hello.ServerName); s.Dir != "" && name != "" &&
+		!strings.ContainsAny(name, `/\`) && !strings.HasPrefix(name, ".") {
+		for _, pair := range [][2]string{
+			{filepath.Join(name, "fullchain.pem"), filepath.Join(name, "privkey.pem")},
+			{name + ".crt", name + ".key"},
+		} {
+			cert, err := s.load(filepath.Join(s.Dir, pair[0]), filepath.Join(s.Dir, pair[1]))
+			if err == nil {
+				return cert, nil
+			}
+
+			if !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+		}
+	}
+
+	if s.CertFile == "" {
+		return nil, fmt.Errorf("no certificate for %q in %s",
+			hello.ServerName, s.Dir)
+	}
+
+	return s.load(s.CertFile, s.KeyFile)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,204 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - collector.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 533f2c7d-c7ac-11f1-a934-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// defaultCollectorSocket is where go-what --collector listens, and go-what looks for one, unless
+// told otherwise with --socket.
+const defaultCollectorSocket = "/run/go-what.sock"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// collectorTimeout bounds how long go-what waits for a collector's snapshot before collecting
+// for itself.
+const collectorTimeout = time.Second
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// collectedOptions are the what.Options a snapshot depends on: go-what uses a collector's
+// snapshot only if it would have collected with the same.
+type collectedOptions struct {
+	Origin       bool
+	HumansOnly   bool
+	AllTTYs      bool
+	IdleShells   bool
+	ProcessGroup bool
+	Graphical    bool
+	NoTTYAll     bool
+	NoTTYRoot    what.NoTTYRoot
+	NoTTYZero    bool
+	Ancestry     bool
+	Sample       time.Duration
+	Output       bool
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// collectedWith returns the collectedOptions of opts.
+func collectedWith(opts what.Options) collectedOptions {
+	return collectedOptions{
+		Origin: opts.Origin, HumansOnly: opts.HumansOnly, AllTTYs: opts.AllTTYs,
+		IdleShells: opts.IdleShells, ProcessGroup: opts.ProcessGroup, Graphical: opts.Graphical,
+		NoTTYAll: opts.NoTTYAll, NoTTYRoot: opts.NoTTYRoot, NoTTYZero: opts.NoTTYZero,
+		Ancestry: opts.Ancestry, Sample: opts.Sample, Output: opts.Output,
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// runCollector implements go-what --collector: it collects with opts every interval, and
+// writes the latest snapshot, gob-encoded after the collectedOptions, to every connection to
+// listener, without collecting or encoding anything for it.  With opts.Output, each snapshot's
+// output rates are measured since the one before.  While collecting fails, connections are
+// closed with nothing written, so that clients collect for themselves rather than read a
+// snapshot that is ever older.  It returns when ctx is done.
+func runCollector(ctx context.Context, listener net.Listener, opts what.Options,
+	interval time.Duration,
+) error {
+	defer listener.Close()
+
+	var (
+		latest   atomic.Pointer[[]byte]
+		previous *what.Snapshot
+	)
+
+	collect := func() {
+		snap, err := what.Collect(ctx, opts)
+		if err != nil {
+			latest.Store(nil)
+
+			if ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "go-what: %v\n",
+					err)
+			}
+
+			return
+		}
+
+		snap.MeasureOutput(previous)
+		previous = snap
+
+		var buf bytes.Buffer
+
+		enc := gob.NewEncoder(&buf)
+		if err := errors.Join(enc.Encode(collectedWith(opts)), enc.Encode(snap)); err != nil {
+			latest.Store(nil)
+			fmt.Fprintf(os.Stderr, "go-what: encoding the snapshot: %v\n",
+				err)
+
+			return
+		}
+
+		data := buf.Bytes()
+		latest.Store(&data)
+	}
+
+	collect()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				collect()
+
+			case <-ctx.Done():
+				listener.Close()
+
+				return
+			}
+		}
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		if data := latest.Load(); data != nil {
+			_ = conn.SetWriteDeadline(time.Now().Add(collectorTimeout))
+			_, _ = conn.Write(*data)
+		}
+
+		conn.Close()
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// errOtherOptions is returned by fetchCollected when the collector collects with other options.
+var errOtherOptions = errors.New("the collector collects with other options")
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fetchCollected returns the latest snapshot of the collector listening on socket, provided it
+// collects with opts.
+func fetchCollected(socket string, opts what.Options) (*what.Snapshot, error) {
+	conn, err := net.DialTimeout("unix", socket, collectorTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(collectorTimeout))
+
+	dec := gob.NewDecoder(conn)
+
+	var collected collectedOptions
+	if err := dec.Decode(&collected); err != nil {
+		return nil, err
+	}
+
+	if collected != collectedWith(opts) {
+		return nil, errOtherOptions
+	}
+
+	var snap what.Snapshot
+	if err := dec.Decode(&snap); err != nil {
+		return nil, err
+	}
+
+	return &snap, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
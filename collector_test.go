@@ -0,0 +1,125 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - collector_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 131a779d-c7b1-11f1-8832-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// stallingFS is a host with one process whose /proc listing, once stall is set, takes longer
+// than the collections of the test may.
+type stallingFS struct {
+	fstest.MapFS
+
+	stall atomic.Bool
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// newStallingFS returns a stallingFS that does not stall yet.
+func newStallingFS() *stallingFS {
+	return &stallingFS{MapFS: fstest.MapFS{
+		"proc/1":                   {Mode: fs.ModeDir | 0o555, Sys: &syscall.Stat_t{}},
+		"proc/1/stat":              {Data: []byte("1 (init) S 0 1 1 0 -1 0 0 0 0 0 0 0 0 0 20\n")},
+		"proc/1/cmdline":           {Data: []byte("/sbin/init\x00")},
+		"proc/sys/kernel/hostname": {Data: []byte("fixture\n")},
+	}}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ReadDir implements fs.ReadDirFS.
+func (s *stallingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == "proc" && s.stall.Load() {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return s.MapFS.ReadDir(name)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestCollector(t *testing.T) {
+	fsys := newStallingFS()
+	opts := what.Options{FS: fsys, Timeout: 20 * time.Millisecond}
+
+	socket := filepath.Join(t.TempDir(), "what.sock")
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+
+	go func() { done <- runCollector(ctx, listener, opts, 5*time.Millisecond) }()
+
+	defer func() {
+		cancel()
+
+		if err := <-done; err != nil {
+			t.Errorf("runCollector: %v",
+				err)
+		}
+	}()
+
+	snap, err := fetchCollected(socket, opts)
+	if err != nil || snap.Hostname != "fixture" || snap.NoTTY[0] != 1 {
+		t.Fatalf("fetchCollected = %+v, %v; want the fixture's snapshot",
+			snap, err)
+	}
+
+	other := opts
+	other.Origin = true
+
+	if _, err := fetchCollected(socket, other); !errors.Is(err, errOtherOptions) {
+		t.Errorf("fetchCollected with other options returned %v, want errOtherOptions",
+			err)
+	}
+
+	// Once collecting fails, the last snapshot must not be served any longer.
+	fsys.stall.Store(true)
+
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := fetchCollected(socket, opts); err != nil {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("the collector still serves a snapshot after collecting has failed")
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
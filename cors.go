@@ -0,0 +1,80 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - cors.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 61d8ffc6-c7a6-11f1-9585-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// corsMaxAge is how long, in seconds, browsers may cache the answer to a preflight request.
+const corsMaxAge = "600"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// allowCORS wraps next so that pages from origins (scheme://host[:port], or "*" for any) may
+// query it from the browser with methods.  Preflight requests are answered here, without
+// credentials, as browsers send them; requests from other origins are served as usual, and
+// the browser withholds the answer from the page.
+func allowCORS(next http.Handler, origins, methods []string) http.Handler {
+	if len(origins) == 0 {
+		return next
+	}
+
+	anyOrigin := slices.Contains(origins, "*")
+	allowMethods := strings.Join(methods, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		h := w.Header()
+
+		h.Add("Vary", "Origin")
+
+		if origin == "" || !anyOrigin && !slices.Contains(origins, origin) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		if anyOrigin {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			// Listed origins may send credentials, such as basic auth the browser remembers.
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", allowMethods)
+			h.Set("Access-Control-Allow-Headers", "Authorization")
+			h.Set("Access-Control-Max-Age", corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
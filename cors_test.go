@@ -0,0 +1,142 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - cors_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: e04fc22b-c7b1-11f1-ae06-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestAllowCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	methods := []string{http.MethodGet, http.MethodHead}
+
+	for _, tc := range []struct {
+		name        string
+		origins     []string
+		method      string
+		origin      string
+		preflight   bool
+		status      int
+		allow       string
+		credentials string
+		allowMethod string
+	}{
+		{
+			name: "a listed origin", origins: []string{"https://ops.example"},
+			method: http.MethodGet, origin: "https://ops.example",
+			status: http.StatusOK, allow: "https://ops.example", credentials: "true",
+		},
+		{
+			name: "an unlisted origin", origins: []string{"https://ops.example"},
+			method: http.MethodGet, origin: "https://evil.example",
+			status: http.StatusOK,
+		},
+		{
+			name: "no origin", origins: []string{"https://ops.example"},
+			method: http.MethodGet,
+			status: http.StatusOK,
+		},
+		{
+			name: "any origin", origins: []string{"*"},
+			method: http.MethodGet, origin: "https://else.example",
+			status: http.StatusOK, allow: "*",
+		},
+		{
+			name: "a preflight", origins: []string{"https://ops.example"},
+			method: http.MethodOptions, origin: "https://ops.example", preflight: true,
+			status: http.StatusNoContent, allow: "https://ops.example", credentials: "true",
+			allowMethod: "GET, HEAD",
+		},
+		{
+			name: "a preflight from an unlisted origin", origins: []string{"https://ops.example"},
+			method: http.MethodOptions, origin: "https://evil.example", preflight: true,
+			status: http.StatusOK,
+		},
+		{
+			// An OPTIONS request that is not a preflight is the handler's.
+			name: "a plain OPTIONS", origins: []string{"*"},
+			method: http.MethodOptions, origin: "https://ops.example",
+			status: http.StatusOK, allow: "*",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, "/v1/snapshot", nil)
+			if tc.origin != "" {
+				r.Header.Set("Origin", tc.origin)
+			}
+
+			if tc.preflight {
+				r.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			}
+
+			w := httptest.NewRecorder()
+			allowCORS(next, tc.origins, methods).ServeHTTP(w, r)
+
+			h := w.Header()
+			for _, check := range []struct{ header, got, want string }{
+				{"Access-Control-Allow-Origin", h.Get("Access-Control-Allow-Origin"), tc.allow},
+				{
+					"Access-Control-Allow-Credentials", h.Get("Access-Control-Allow-Credentials"),
+					tc.credentials,
+				},
+				{
+					"Access-Control-Allow-Methods", h.Get("Access-Control-Allow-Methods"),
+					tc.allowMethod,
+				},
+				{"Vary", h.Get("Vary"), "Origin"},
+			} {
+				if check.got != check.want {
+					t.Errorf("%s: %q, want %q",
+						check.header, check.got, check.want)
+				}
+			}
+
+			if w.Code != tc.status {
+				t.Errorf("status %d, want %d",
+					w.Code, tc.status)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestAllowCORSDisabled(t *testing.T) {
+	next := http.NotFoundHandler()
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Origin", "https://ops.example")
+
+	w := httptest.NewRecorder()
+	allowCORS(next, nil, nil).ServeHTTP(w, r)
+
+	if len(w.Header().Values("Vary")) > 0 || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("with no origins, the headers were %v",
+			w.Header())
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
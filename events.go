@@ -0,0 +1,278 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - events.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 8a854f4c-c7a6-11f1-b3ee-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+	"github.com/johnsonjh/go-what/whatjson"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// eventsPath is where go-what serve streams session events, as server-sent events.
+const eventsPath = "/v1/events"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// The limits of an event stream: how many events a subscriber may fall behind by before it is
+// dropped (and its browser reconnects), and how often an idle stream is kept alive.
+const (
+	eventBacklog   = 64
+	eventKeepalive = 15 * time.Second
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// eventHub runs one what.Watch for every subscriber of the event stream, so that they cost no
+// collections of their own, and only while there are any; new subscribers are first sent the
+// sessions present as added.
+type eventHub struct {
+	node string
+	ctx  context.Context
+	opts what.WatchOptions
+
+	mu          sync.Mutex
+	sessions    map[string]*what.TTY
+	subscribers map[chan whatjson.Event]bool
+
+	// stop cancels the watch, which is nil while there is none.  Each watch has its own
+	// generation, so that the events of one stopped do not reach the subscribers of the next.
+	stop       context.CancelFunc
+	generation int
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// newEventHub returns a hub watching with opts, until ctx is done, whenever it has subscribers.
+func newEventHub(ctx context.Context, opts what.WatchOptions, node string) *eventHub {
+	return &eventHub{
+		node:        node,
+		ctx:         ctx,
+		opts:        opts,
+		sessions:    make(map[string]*what.TTY),
+		subscribers: make(map[chan whatjson.Event]bool),
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// watch publishes the events of a watch until ctx is done.  If a collection fails, the error is
+// logged and every subscriber is dropped, to reconnect and so start another.
+func (hub *eventHub) watch(ctx context.Context, generation int) {
+	events, err := what.Watch(ctx, hub.opts)
+	if err == nil {
+		for event := range events {
+			if event.Type == what.WatchFailed {
+				err = event.Err
+
+				break
+			}
+
+			hub.publish(generation, event)
+		}
+	}
+
+	if err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "go-what: serve: %s: %v\n",
+			eventsPath, err)
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if generation != hub.generation {
+		return
+	}
+
+	for ch := range hub.subscribers {
+		close(ch)
+	}
+
+	clear(hub.subscribers)
+	hub.stopWatching()
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// stopWatching cancels the watch and forgets its sessions; hub.mu is held.
+func (hub *eventHub) stopWatching() {
+	if hub.stop != nil {
+		hub.stop()
+		hub.stop = nil
+	}
+
+	hub.generation++
+
+	clear(hub.sessions)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// jsonEvent converts a session event for the stream.
+func (hub *eventHub) jsonEvent(event what.SessionEvent) whatjson.Event {
+	doc := whatjson.Event{
+		SchemaVersion: whatjson.SchemaVersion,
+		Type:          event.Type.String(),
+		Time:          event.Time,
+		TTY:           event.TTY.Name,
+		Node:          hub.node,
+		Sessions:      ttySessions(event.TTY, false),
+	}
+
+	if event.Previous != nil {
+		doc.Previous = ttySessions(event.Previous, false)
+	}
+
+	return doc
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// publish records an event of the watch of generation and sends it to every subscriber,
+// dropping those too far behind.
+func (hub *eventHub) publish(generation int, event what.SessionEvent) {
+	doc := hub.jsonEvent(event)
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if generation != hub.generation {
+		return
+	}
+
+	if event.Type == what.SessionRemoved {
+		delete(hub.sessions, event.TTY.Name)
+	} else {
+		hub.sessions[event.TTY.Name] = event.TTY
+	}
+
+	for ch := range hub.subscribers {
+		select {
+		case ch <- doc:
+		default:
+			delete(hub.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	if len(hub.subscribers) == 0 {
+		hub.stopWatching()
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// subscribe returns a channel of events, starting with the sessions present, that is closed when
+// the subscriber is dropped, and starts watching if no one else is subscribed.  unsubscribe must
+// be called when done with it, and the watch stops with the last subscriber.
+func (hub *eventHub) subscribe() (events <-chan whatjson.Event, unsubscribe func()) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if hub.stop == nil {
+		var ctx context.Context
+
+		ctx, hub.stop = context.WithCancel(hub.ctx)
+		hub.generation++
+
+		go hub.watch(ctx, hub.generation)
+	}
+
+	ch := make(chan whatjson.Event, eventBacklog+len(hub.sessions))
+
+	for _, name := range slices.Sorted(maps.Keys(hub.sessions)) {
+		ch <- hub.jsonEvent(what.SessionEvent{
+			Type: what.SessionAdded,
+			Time: time.Now(),
+			TTY:  hub.sessions[name],
+		})
+	}
+
+	hub.subscribers[ch] = true
+
+	return ch, func() {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+
+		if hub.subscribers[ch] {
+			delete(hub.subscribers, ch)
+			close(ch)
+		}
+
+		if len(hub.subscribers) == 0 {
+			hub.stopWatching()
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ServeHTTP streams the events as server-sent events, named by their type, each carrying a
+// whatjson.Event.
+func (hub *eventHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	events, unsubscribe := hub.subscribe()
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	keepalive := time.NewTicker(eventKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		var err error
+
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-keepalive.C:
+			_, err = fmt.Fprint(w, ": keepalive\n\n")
+
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			data, _ := json.Marshal(event)
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n",
+				event.Type, data)
+		}
+
+		if err != nil || rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,80 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - events_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 9f9876e6-c7b3-11f1-9d63-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestEventHubWatchesForSubscribers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fsys := &countingFS{MapFS: fixtureHost(fixtureSession{0, 1000, 1, "bash"})}
+	hub := newEventHub(ctx, what.WatchOptions{
+		Options:  what.Options{FS: fsys},
+		Interval: 5 * time.Millisecond,
+	}, "node1")
+
+	time.Sleep(50 * time.Millisecond)
+
+	if got := fsys.listings.Load(); got != 0 {
+		t.Fatalf("with no subscribers, the hub collected %d times",
+			got)
+	}
+
+	for round := 1; round <= 2; round++ {
+		events, unsubscribe := hub.subscribe()
+
+		select {
+		case event := <-events:
+			if event.Type != "added" || event.TTY != "pts/0" || event.Node != "node1" {
+				t.Errorf("round %d: the first event was %+v",
+					round, event)
+			}
+
+		case <-time.After(2 * time.Second):
+			t.Fatalf("round %d: no event was sent",
+				round)
+		}
+
+		unsubscribe()
+
+		// Let a collection under way finish, then see that no more are made.
+		time.Sleep(20 * time.Millisecond)
+
+		stopped := fsys.listings.Load()
+
+		time.Sleep(50 * time.Millisecond)
+
+		if got := fsys.listings.Load(); got != stopped {
+			t.Errorf("round %d: after the last subscriber left, the hub collected %d more times",
+				round, got-stopped)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,127 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - failed.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 70eb9c32-c7a2-11f1-bc7b-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+	"github.com/johnsonjh/go-what/whatjson"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// failedMain implements "go-what failed", a summary of recent failed logins from btmp, and
+// returns the exit status.
+func failedMain(args []string) int {
+	flags := flag.NewFlagSet("go-what failed", flag.ExitOnError)
+
+	since := flags.Duration("since", 24*time.Hour,
+		"summarize attempts made within this long")
+	file := flags.String("file", what.BtmpPath,
+		"the btmp file to read")
+	format := flags.String("format", "table",
+		"output format: table or json")
+	flags.BoolVar(&prettyJSON, "pretty", false,
+		"indent the JSON, for reading")
+
+	_ = flags.Parse(args)
+
+	if !slices.Contains([]string{"table", "json"}, *format) {
+		fmt.Fprintf(os.Stderr, "go-what: unknown --format %q\n",
+			*format)
+
+		return 2
+	}
+
+	entries, err := what.ReadUtmp(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-what: %v\n",
+			err)
+
+		if errors.Is(err, fs.ErrPermission) {
+			fmt.Fprintf(os.Stderr, "go-what: %s is normally readable only by root\n",
+				*file)
+		}
+
+		return 1
+	}
+
+	start := time.Now().Add(-*since)
+	failures := what.SummarizeFailures(entries, start)
+
+	if *format == "json" {
+		doc := whatjson.Failures{
+			SchemaVersion: whatjson.SchemaVersion,
+			Since:         start,
+			Failures:      []whatjson.FailedLogin{},
+		}
+
+		for _, f := range failures {
+			doc.Failures = append(doc.Failures, whatjson.FailedLogin(f))
+		}
+
+		err := newJSONEncoder(os.Stdout).Encode(doc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: %v\n",
+				err)
+
+			return 1
+		}
+
+		return 0
+	}
+
+	total := 0
+	for _, f := range failures {
+		total += f.Count
+	}
+
+	loginString := "logins"
+
+	if total == 1 {
+		loginString = "login"
+	}
+
+	fmt.Printf(" %d failed %s in the last %s\n",
+		total, loginString, *since)
+
+	if len(failures) == 0 {
+		return 0
+	}
+
+	fmt.Printf("%6s %-12s %-24s %6s %6s\n",
+		"COUNT", "USER", "SOURCE", "FIRST", "LAST")
+
+	for _, f := range failures {
+		fmt.Printf("%6d %-12.12s %-24.24s %6s %6s\n",
+			f.Count, f.User, f.Source, prettyTime(f.First.Unix()), prettyTime(f.Last.Unix()))
+	}
+
+	return 0
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
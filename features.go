@@ -0,0 +1,64 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - features.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 9dcb8c07-c7aa-11f1-98e5-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// builtFeatures lists the optional parts of go-what this binary has; building with -tags
+// minimal leaves them all out, for a small static binary to put in an initramfs.
+func builtFeatures() []string {
+	features := []string{}
+	if haveGeoIP {
+		features = append(features, "geoip")
+	}
+
+	if haveTop {
+		features = append(features, "top")
+	}
+
+	return features
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// printVersion writes the line --version shows: the version, the toolchain, whether cgo was
+// used, and the optional features.
+func printVersion(w io.Writer) {
+	info := readBuildInfo()
+
+	linking := "static"
+	if info.CGO {
+		linking = "cgo"
+	}
+
+	features := cmp.Or(strings.Join(info.Features, " "), "none")
+
+	fmt.Fprintf(w, "go-what %s %s, %s, features: %s\n",
+		info.Version, info.GoVersion, linking, features)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
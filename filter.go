@@ -0,0 +1,161 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - filter.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 9a181108-c7a2-11f1-848e-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sessionFilter selects the sessions to report; the zero value selects them all.
+type sessionFilter struct {
+	Users []string
+
+	// IdleOver, if set, selects the terminals idle at least this long; graphical sessions,
+	// whose idle time is not known, are dropped.
+	IdleOver time.Duration
+
+	// Groups, if set, selects the users in any of these groups, primary or supplementary.
+	Groups []string
+
+	// HideNoTTY drops the TTY-less process counts altogether.
+	HideNoTTY bool
+
+	// Query, if set, selects the foreground processes it matches; terminals left with none are
+	// dropped.  It does not apply to the TTY-less process counts.
+	Query *query
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// apply drops the terminals in snap that the filter does not select, and the TTY-less process
+// counts of users it does not select.
+func (f sessionFilter) apply(snap *what.Snapshot) {
+	if f.HideNoTTY {
+		snap.NoTTY = nil
+	}
+
+	if len(f.Users) > 0 {
+		maps.DeleteFunc(snap.NoTTY, func(uid uint32, _ int) bool {
+			return !slices.Contains(f.Users, username(uid))
+		})
+	}
+
+	if len(f.Groups) > 0 {
+		maps.DeleteFunc(snap.NoTTY, func(uid uint32, _ int) bool {
+			return !inGroups(uid, f.Groups)
+		})
+	}
+
+	snap.TTYs = slices.DeleteFunc(snap.TTYs, func(tty *what.TTY) bool {
+		if len(f.Users) > 0 && !slices.Contains(f.Users, username(tty.Stat.Uid)) {
+			return true
+		}
+
+		if len(f.Groups) > 0 && !inGroups(tty.Stat.Uid, f.Groups) {
+			return true
+		}
+
+		if f.IdleOver == 0 {
+			return false
+		}
+
+		idle, known := tty.Idle(snap.Time)
+
+		return !known || idle < f.IdleOver
+	})
+
+	if f.Query == nil {
+		return
+	}
+
+	snap.TTYs = slices.DeleteFunc(snap.TTYs, func(tty *what.TTY) bool {
+		tty.Processes = slices.DeleteFunc(tty.Processes, func(p *what.Process) bool {
+			return !f.Query.matches(tty, p, snap.Time)
+		})
+
+		return len(tty.Processes) == 0
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sortKeys are the orders sortSessions knows, the first being Collect's own.
+var sortKeys = []string{"input", "user", "tty", "cpu"}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sortSessions orders the terminals in snap by one of sortKeys: last input (oldest first, as
+// Collect returns them), user, terminal name, or the %CPU sampled for their foreground processes,
+// busiest first.  Terminals that compare equal keep the order of last input.
+func sortSessions(snap *what.Snapshot, by string) {
+	usage := func(tty *what.TTY) float64 {
+		var total float64
+		for _, p := range tty.Processes {
+			total += p.CPUPercent
+		}
+
+		return total
+	}
+
+	slices.SortStableFunc(snap.TTYs, func(a, b *what.TTY) int {
+		return cmp.Compare(a.Stat.Atim.Sec, b.Stat.Atim.Sec)
+	})
+
+	switch by {
+	case "user":
+		slices.SortStableFunc(snap.TTYs, func(a, b *what.TTY) int {
+			return cmp.Compare(username(a.Stat.Uid), username(b.Stat.Uid))
+		})
+
+	case "tty":
+		slices.SortStableFunc(snap.TTYs, func(a, b *what.TTY) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+
+	case "cpu":
+		slices.SortStableFunc(snap.TTYs, func(a, b *what.TTY) int {
+			return cmp.Compare(usage(b), usage(a))
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// selects reports whether the filter's users, groups, and query select the session of event.
+func (f sessionFilter) selects(event what.SessionEvent) bool {
+	uid := event.TTY.Stat.Uid
+
+	if len(f.Users) > 0 && !slices.Contains(f.Users, username(uid)) ||
+		len(f.Groups) > 0 && !inGroups(uid, f.Groups) {
+		return false
+	}
+
+	return f.Query == nil || slices.ContainsFunc(event.TTY.Processes,
+		func(p *what.Process) bool { return f.Query.matches(event.TTY, p, event.Time) })
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,69 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - filter_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: b60ee7b3-c7b1-11f1-b60b-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestFilterIdleOver(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	graphical := idleTTY("tty2", 1002, now, 3*time.Hour)
+	graphical.Graphical = true
+
+	for _, tc := range []struct {
+		idleOver time.Duration
+		want     []string
+	}{
+		{0, []string{"pts/0", "pts/1", "tty2"}},
+		{time.Minute, []string{"pts/0", "pts/1"}},
+		{time.Hour, []string{"pts/0"}},
+		{4 * time.Hour, nil},
+	} {
+		t.Run(tc.idleOver.String(), func(t *testing.T) {
+			snap := &what.Snapshot{Time: now, TTYs: []*what.TTY{
+				idleTTY("pts/0", 1000, now, 2*time.Hour),
+				idleTTY("pts/1", 1001, now, 5*time.Minute),
+				graphical,
+			}}
+
+			sessionFilter{IdleOver: tc.idleOver}.apply(snap)
+
+			var got []string
+			for _, tty := range snap.TTYs {
+				got = append(got, tty.Name)
+			}
+
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("--idle-over %v kept %q, want %q",
+					tc.idleOver, got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
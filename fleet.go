@@ -0,0 +1,514 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - fleet.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: fae8e36e-c7a4-11f1-9ea0-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/johnsonjh/go-what/whatjson"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// The limits of a multi-host round by default: how many hosts are collected from at once, how
+// long connecting to one may take, and how long collecting from one may take altogether.  A
+// host that runs out of time is reported as failed, and the others are reported as usual.
+const (
+	fleetParallel       = 32
+	fleetConnectTimeout = 10 * time.Second
+	fleetHostTimeout    = 30 * time.Second
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fleetHost is one host's part of a multi-host report.
+type fleetHost struct {
+	Name string
+	Doc  *whatjson.Snapshot
+	Err  error
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// agentClient returns an HTTPS client for querying agents.  Agent certificates are verified
+// against caFile, or the system roots if it is empty; certFile and keyFile, if set, are the
+// client certificate presented to agents running with --mtls.  Connections to the host:port
+// keys of resolved go to their values instead, as for agents found by --discover.  Connecting,
+// including the TLS handshake, may take up to connectTimeout each.
+func agentClient(certFile, keyFile, caFile string, resolved map[string]string,
+	connectTimeout time.Duration,
+) (*http.Client, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		config.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	dialer := net.Dialer{Timeout: connectTimeout}
+
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig:     config,
+		TLSHandshakeTimeout: connectTimeout,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, cmp.Or(resolved[addr], addr))
+		},
+	}}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fetchAgent asks the agent at addr (host:port) for its snapshot.
+func fetchAgent(ctx context.Context, client *http.Client, addr string) (*whatjson.Snapshot, error) {
+	return fetchSnapshot(ctx, client, "https://"+addr+agentSnapshotPath)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fetchSnapshot gets the snapshot an agent, or go-what serve, answers with at url.
+func fetchSnapshot(ctx context.Context, client *http.Client, url string) (*whatjson.Snapshot,
+	error,
+) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", gobMediaType+", application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+		return nil, fmt.Errorf("%s: %s",
+			resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var doc whatjson.Snapshot
+
+	// Agents from before gob was supported answer with JSON whatever is asked for.
+	if resp.Header.Get("Content-Type") == gobMediaType {
+		err = gob.NewDecoder(resp.Body).Decode(&doc)
+	} else {
+		err = json.NewDecoder(resp.Body).Decode(&doc)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if doc.SchemaVersion > whatjson.SchemaVersion {
+		return nil, fmt.Errorf("schema version %d is newer than this go-what's %d",
+			doc.SchemaVersion, whatjson.SchemaVersion)
+	}
+
+	return &doc, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fleetSource is a host to collect from, and how.
+type fleetSource struct {
+	Name  string
+	Fetch func(ctx context.Context) (*whatjson.Snapshot, error)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fetchAll collects from the sources, up to parallel at a time and each for up to timeout,
+// returning their snapshots in the order given.
+func fetchAll(ctx context.Context, sources []fleetSource, parallel int, timeout time.Duration,
+) []fleetHost {
+	hosts := make([]fleetHost, len(sources))
+	slots := make(chan struct{}, max(parallel, 1))
+
+	var wg sync.WaitGroup
+
+	for i, source := range sources {
+		wg.Go(func() {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			doc, err := source.Fetch(ctx)
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				err = fmt.Errorf("no snapshot within %v",
+					timeout)
+			}
+
+			hosts[i] = fleetHost{Name: source.Name, Doc: doc, Err: err}
+		})
+	}
+
+	wg.Wait()
+
+	return hosts
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// printFleet writes the sessions of every host as one table, with a HOST column in front or,
+// if grouped, under a summary of the fleet and a header line per host with its uptime and load.
+// Times are relative to now, as the hosts' own clocks reported them.
+func printFleet(w io.Writer, hosts []fleetHost, grouped bool) {
+	headers := []string{"HOST", "USER", "TTY", "LOGIN", "INPUT", "WHAT"}
+	right := []bool{false, false, false, true, true, false}
+
+	// Each host's lines, with the header first; the HOST cell is dropped when grouped.
+	lines := [][][]string{{headers}}
+
+	for _, h := range hosts {
+		var hostLines [][]string
+
+		if h.Doc != nil {
+			for _, s := range h.Doc.Sessions {
+				hostLines = append(hostLines, []string{
+					h.Name, s.User, s.TTY,
+					prettyTime(s.Login.Unix()), prettyTime(s.Input.Unix()), s.Command,
+				})
+			}
+		}
+
+		lines = append(lines, hostLines)
+	}
+
+	if grouped {
+		for _, hostLines := range lines {
+			for i := range hostLines {
+				hostLines[i] = hostLines[i][1:]
+			}
+		}
+
+		right = right[1:]
+	}
+
+	widths := make([]int, len(right))
+
+	for _, hostLines := range lines {
+		for _, line := range hostLines {
+			for i, cell := range line {
+				widths[i] = max(widths[i], utf8.RuneCountInString(strings.TrimSpace(cell)))
+			}
+		}
+	}
+
+	write := func(line []string) {
+		cells := make([]string, len(line))
+
+		for i, cell := range line {
+			cells[i] = strings.TrimSpace(cell)
+			if i < len(line)-1 {
+				cells[i] = pad(cells[i], widths[i], right[i])
+			}
+		}
+
+		fmt.Fprintln(w, strings.Join(cells, " "))
+	}
+
+	if !grouped {
+		for _, hostLines := range lines {
+			for _, line := range hostLines {
+				write(line)
+			}
+		}
+
+		return
+	}
+
+	fmt.Fprintln(w, fleetSummary(hosts))
+
+	for i, h := range hosts {
+		fmt.Fprintln(w)
+
+		if h.Doc == nil {
+			fmt.Fprintf(w, "%s  error: %v\n",
+				h.Name, h.Err)
+
+			continue
+		}
+
+		fmt.Fprintln(w, hostHeader(h))
+
+		if len(lines[i+1]) > 0 {
+			write(lines[0][0])
+		}
+
+		for _, line := range lines[i+1] {
+			write(line)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fleetSummary is the first line of the grouped report: how many hosts answered, and the
+// sessions and distinct users across them.
+func fleetSummary(hosts []fleetHost) string {
+	var down, sessions int
+
+	users := make(map[string]bool)
+
+	for _, h := range hosts {
+		if h.Doc == nil {
+			down++
+
+			continue
+		}
+
+		sessions += len(h.Doc.Sessions)
+
+		for _, s := range h.Doc.Sessions {
+			users[s.User] = true
+		}
+	}
+
+	summary := fmt.Sprintf(" %d hosts",
+		len(hosts))
+	if down > 0 {
+		summary += fmt.Sprintf(" (%d unreachable)",
+			down)
+	}
+
+	return summary + fmt.Sprintf("  %d sessions  %d users",
+		sessions, len(users))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// hostHeader is the line above a host's sessions in the grouped report, modeled on the first
+// line of the single-host table.
+func hostHeader(h fleetHost) string {
+	header := h.Name
+
+	if h.Doc.Uptime > 0 {
+		header += "  up " + strings.TrimSpace(prettyTime(time.Now().Unix()-int64(h.Doc.Uptime)))
+	}
+
+	header += fmt.Sprintf("  %d users",
+		h.Doc.Users)
+
+	if len(h.Doc.Load) == 3 {
+		header += fmt.Sprintf("  load %.2f %.2f %.2f",
+			h.Doc.Load[0], h.Doc.Load[1], h.Doc.Load[2])
+	}
+
+	return header
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fleetConfig selects the hosts of a multi-host report, how to reach them, and how to report.
+type fleetConfig struct {
+	// Agents are the host:port addresses of agents to query, and Discover adds those that
+	// answer on the local network.  CertFile, KeyFile, and CAFile are as for agentClient.
+	Agents   []string
+	Discover bool
+	CertFile string
+	KeyFile  string
+	CAFile   string
+
+	// Hosts are reached with ssh, after expanding patterns against the aliases of SSHConfig,
+	// by running RemoteCommand there.  With an Inventory (as for loadInventory), Hosts are
+	// instead Ansible host patterns selecting from it, and all its hosts if there are none.
+	Hosts         []string
+	SSHConfig     string
+	Inventory     string
+	RemoteCommand string
+
+	// Parallel, ConnectTimeout, and Timeout limit the round, as fleetParallel and the rest do
+	// by default.
+	Parallel       int
+	ConnectTimeout time.Duration
+	Timeout        time.Duration
+
+	Format  string
+	Grouped bool
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fleetMain collects from every host of config and reports on them all, returning the exit
+// status: 1 if any host could not be collected from.
+func fleetMain(ctx context.Context, config fleetConfig) int {
+	agents := slices.Clone(config.Agents)
+	resolved := make(map[string]string)
+
+	if config.Discover {
+		found, err := discover(ctx, discoverWait)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: --discover: %v\n",
+				err)
+		}
+
+		for _, agent := range found {
+			if !slices.Contains(agents, agent.Name) {
+				agents = append(agents, agent.Name)
+			}
+
+			resolved[agent.Name] = agent.Addr
+		}
+	}
+
+	var sources []fleetSource
+
+	if len(agents) > 0 {
+		client, err := agentClient(config.CertFile, config.KeyFile, config.CAFile, resolved,
+			config.ConnectTimeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: %v\n",
+				err)
+
+			return 1
+		}
+
+		for _, addr := range agents {
+			sources = append(sources, fleetSource{
+				Name: addr,
+				Fetch: func(ctx context.Context) (*whatjson.Snapshot, error) {
+					return fetchAgent(ctx, client, addr)
+				},
+			})
+		}
+	}
+
+	switch {
+	case config.Inventory != "":
+		inv, err := loadInventory(config.Inventory)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: --inventory: %v\n",
+				err)
+
+			return 1
+		}
+
+		for _, h := range inv.Select(config.Hosts) {
+			options, host := h.SSHArgs()
+
+			sources = append(sources, fleetSource{
+				Name: h.Name,
+				Fetch: func(ctx context.Context) (*whatjson.Snapshot, error) {
+					return fetchSSH(ctx, options, host, config.RemoteCommand,
+						config.ConnectTimeout)
+				},
+			})
+		}
+
+	case len(config.Hosts) > 0:
+		for _, host := range expandHosts(config.Hosts, sshConfigAliases(config.SSHConfig)) {
+			sources = append(sources, fleetSource{
+				Name: host,
+				Fetch: func(ctx context.Context) (*whatjson.Snapshot, error) {
+					return fetchSSH(ctx, nil, host, config.RemoteCommand,
+						config.ConnectTimeout)
+				},
+			})
+		}
+	}
+
+	if len(sources) == 0 {
+		fmt.Fprintf(os.Stderr, "go-what: no hosts to collect from\n")
+
+		return 1
+	}
+
+	hosts := fetchAll(ctx, sources, config.Parallel, config.Timeout)
+
+	status := 0
+
+	for _, h := range hosts {
+		if h.Err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: %s: %v\n",
+				h.Name, h.Err)
+
+			status = 1
+		}
+	}
+
+	if config.Format == "table" {
+		printFleet(os.Stdout, hosts, config.Grouped)
+
+		return status
+	}
+
+	if err := printFleetJSON(os.Stdout, hosts); err != nil {
+		fmt.Fprintf(os.Stderr, "go-what: %v\n",
+			err)
+
+		return 1
+	}
+
+	return status
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// printFleetJSON writes every host's snapshot, or its error, as a whatjson.Fleet.
+func printFleetJSON(w io.Writer, hosts []fleetHost) error {
+	fleet := whatjson.Fleet{SchemaVersion: whatjson.SchemaVersion, Hosts: []whatjson.Host{}}
+
+	for _, h := range hosts {
+		host := whatjson.Host{Host: h.Name, Snapshot: h.Doc}
+		if h.Err != nil {
+			host.Error = h.Err.Error()
+		}
+
+		fleet.Hosts = append(fleet.Hosts, host)
+	}
+
+	return newJSONEncoder(w).Encode(fleet)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
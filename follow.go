@@ -0,0 +1,177 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - follow.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 551cf470-c7a2-11f1-8dfe-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sessionCommand renders what a session is running, as the WHAT column would with --pgrp
+// collapse.
+func sessionCommand(tty *what.TTY) string {
+	commands := make([]string, 0, len(tty.Processes))
+
+	for _, p := range tty.Processes {
+		commands = append(commands, p.Command())
+	}
+
+	command := strings.Join(commands, " | ")
+	if tty.Label != "" {
+		command = "[" + tty.Label + "] " + command
+	}
+
+	return command
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// followLine formats one session event as a line of the --follow log.
+func followLine(event what.SessionEvent) string {
+	line := fmt.Sprintf("%s %-7s %-8s %-7s %s",
+		event.Time.Format(time.RFC3339), event.Type, username(event.TTY.Stat.Uid),
+		event.TTY.Name, sessionCommand(event.TTY))
+
+	if event.Previous != nil {
+		line += fmt.Sprintf(" (was: %s)",
+			sessionCommand(event.Previous))
+	}
+
+	return line
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// alertLine formats an alert as a line of the --follow log, with "alert" as its event type.
+func alertLine(a alert) string {
+	return fmt.Sprintf("%s %-7s %-8s %-7s %s: %s",
+		a.Time.Format(time.RFC3339), "alert", username(a.TTY.Stat.Uid), a.TTY.Name, a.Rule,
+		a.Message)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// eventSink is somewhere the session events and alerts of --follow are sent.
+type eventSink interface {
+	Send(event what.SessionEvent) error
+	Alert(a alert) error
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// stdoutSink prints every event as a line in the --format: the --follow log for "table", or
+// CEF or LEEF for SIEM pipelines.
+type stdoutSink struct {
+	Format string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Send implements eventSink.
+func (s stdoutSink) Send(event what.SessionEvent) error {
+	line := followLine
+
+	switch s.Format {
+	case "cef":
+		line = cefLine
+
+	case "leef":
+		line = leefLine
+	}
+
+	_, err := fmt.Println(line(event))
+
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Alert implements eventSink.
+func (s stdoutSink) Alert(a alert) error {
+	line := alertLine
+
+	switch s.Format {
+	case "cef":
+		line = cefAlertLine
+
+	case "leef":
+		line = leefAlertLine
+	}
+
+	_, err := fmt.Println(line(a))
+
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// follow sends an event to every sink for every session that starts, ends, or changes its
+// foreground command, until ctx is done.  The sessions already present are sent first, as
+// started.  The filter's users, groups, and query select the events sent, the query those whose
+// session has a process it matches, but not its IdleOver: a session's idle time changes without
+// an event.  The rules check every event, selected or not, so that they see each session end;
+// their alerts go to every sink.  A sink that fails is reported, and the others are still sent
+// the event.
+func follow(ctx context.Context, opts what.WatchOptions, filter sessionFilter,
+	sinks []eventSink, rules []alertRule,
+) error {
+	events, err := what.Watch(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	for event := range events {
+		if event.Type == what.WatchFailed {
+			return fmt.Errorf("collection failed; stopped following: %w",
+				event.Err)
+		}
+
+		if filter.selects(event) {
+			for _, sink := range sinks {
+				if err := sink.Send(event); err != nil {
+					fmt.Fprintf(os.Stderr, "go-what: %v\n",
+						err)
+				}
+			}
+		}
+
+		for _, rule := range rules {
+			for _, a := range rule.check(event) {
+				for _, sink := range sinks {
+					if err := sink.Alert(a); err != nil {
+						fmt.Fprintf(os.Stderr, "go-what: %v\n",
+							err)
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,199 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - follow_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 5540c1e4-c7b1-11f1-b718-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// recordingSink is an eventSink that keeps what it is sent, as the lines of the --follow log.
+type recordingSink struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Send implements eventSink.
+func (s *recordingSink) Send(event what.SessionEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lines = append(s.lines, event.Type.String()+" "+username(event.TTY.Stat.Uid)+" "+
+		event.TTY.Name)
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Alert implements eventSink.
+func (s *recordingSink) Alert(a alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lines = append(s.lines, "alert "+a.Rule+" "+a.TTY.Name)
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// followFixture follows a fixture host for long enough to send the sessions present at the
+// start, returning what the sink was sent, sorted.
+func followFixture(t *testing.T, opts what.Options, filter sessionFilter,
+	rules ...alertRule,
+) []string {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	sink := &recordingSink{}
+
+	err := follow(ctx, what.WatchOptions{Options: opts, Interval: time.Hour}, filter,
+		[]eventSink{sink}, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(sink.lines)
+
+	return sink.lines
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestFollowFilter(t *testing.T) {
+	opts := what.Options{FS: fixtureHost(
+		fixtureSession{pts: 0, uid: 1000, login: 1, command: "vim"},
+		fixtureSession{pts: 1, uid: 1001, login: 1, command: "top"},
+		fixtureSession{pts: 2, uid: 1002, login: 1, command: "make"},
+	)}
+
+	for _, tc := range []struct {
+		name   string
+		filter sessionFilter
+		want   string
+	}{
+		{
+			"everyone",
+			sessionFilter{},
+			"added alice pts/0, added bob pts/1, added carol pts/2",
+		},
+		{
+			"users",
+			sessionFilter{Users: []string{"alice", "carol"}},
+			"added alice pts/0, added carol pts/2",
+		},
+		{
+			"groups",
+			sessionFilter{Groups: []string{"staff"}},
+			"added bob pts/1, added carol pts/2",
+		},
+		{
+			"users and groups",
+			sessionFilter{Users: []string{"bob"}, Groups: []string{"staff"}},
+			"added bob pts/1",
+		},
+		{"no one", sessionFilter{Groups: []string{"wheel"}, Users: []string{"bob"}}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := strings.Join(followFixture(t, opts, tc.filter), ", "); got != tc.want {
+				t.Errorf("follow sent %q, want %q",
+					got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// everyLogin is an alert rule raising an alert for every session that starts.
+type everyLogin struct{}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// check implements alertRule.
+func (everyLogin) check(event what.SessionEvent) []alert {
+	if event.Type != what.SessionAdded {
+		return nil
+	}
+
+	return []alert{{Time: event.Time, Rule: "login", TTY: event.TTY}}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestFollowFilterRules(t *testing.T) {
+	opts := what.Options{FS: fixtureHost(
+		fixtureSession{pts: 0, uid: 1000, login: 1, command: "vim"},
+		fixtureSession{pts: 1, uid: 1001, login: 1, command: "top"},
+	)}
+
+	q, err := parseQuery(`comm == "vim"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The filter selects the events sent; the rules see every session, selected or not.
+	for _, filter := range []sessionFilter{
+		{Users: []string{"alice"}},
+		{Groups: []string{"wheel"}},
+		{Query: q},
+	} {
+		got := strings.Join(followFixture(t, opts, filter, everyLogin{}), ", ")
+		if want := "added alice pts/0, alert login pts/0, alert login pts/1"; got != want {
+			t.Errorf("follow with %+v sent %q, want %q",
+				filter, got, want)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestFollowQuery(t *testing.T) {
+	opts := what.Options{FS: fixtureHost(
+		fixtureSession{pts: 0, uid: 1000, login: 1, command: "vim"},
+		fixtureSession{pts: 1, uid: 1001, login: 1, command: "top"},
+	)}
+
+	q, err := parseQuery(`comm == "top" || uid == 0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Join(followFixture(t, opts, sessionFilter{Query: q}), ", ")
+	if want := "added bob pts/1"; got != want {
+		t.Errorf("follow sent %q, want %q",
+			got, want)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,84 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - fullname.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 4a8e3593-c7a9-11f1-986c-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fullNameCommand, if set, is run with a user name as its last argument to find that user's
+// display name, as from LDAP's displayName; it prints the name on its first line.  GECOS is
+// used for users it gives no name for.
+var fullNameCommand string
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fullNames caches the names fullName finds.
+var fullNames userCache
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fullName returns the display name of uid, or "" if it has none (or did not answer in time).
+func fullName(uid uint32) string {
+	return fullNames.name(uid, lookupFullName)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// lookupFullName returns uid's display name for fullName: what fullNameCommand says, or the
+// first field of its GECOS.
+func lookupFullName(uid uint32) string {
+	name := username(uid)
+
+	if args := strings.Fields(fullNameCommand); len(args) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+		defer cancel()
+
+		out, err := exec.CommandContext(ctx, args[0], append(args[1:], name)...).Output()
+
+		line, _, _ := strings.Cut(string(out), "\n")
+		if line = strings.TrimSpace(line); err == nil && line != "" {
+			return line
+		}
+	}
+
+	var gecos string
+
+	if passwdFile != "" {
+		if fields := passwdFields(uid); len(fields) > 4 {
+			gecos = fields[4]
+		}
+	} else if u, err := user.LookupId(strconv.Itoa(int(uid))); err == nil {
+		gecos = u.Name
+	}
+
+	// The rest of GECOS is the office, phone numbers, and so on.
+	gecos, _, _ = strings.Cut(gecos, ",")
+
+	return strings.TrimSpace(gecos)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,531 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - geoip.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: ea77c532-c7a7-11f1-89a6-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+//go:build !minimal
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// haveGeoIP reports that --geo is built in; see builtFeatures.
+const haveGeoIP = true
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// mmdbMetadataMarker starts the metadata section at the end of a MaxMind DB file.
+var mmdbMetadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// mmdbMaxDepth bounds the nesting of maps and arrays in a MaxMind DB record, against loops in
+// damaged files.
+const mmdbMaxDepth = 32
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// mmdb is an open MaxMind DB file (format 2, as GeoLite2 and GeoIP2 databases and their
+// look-alikes use): a binary search tree over address bits, whose leaves point into a section
+// of records.
+type mmdb struct {
+	data       []byte
+	nodeCount  uint64
+	recordSize uint64
+	ipVersion  uint64
+	dataStart  uint64
+	ipv4Start  uint64
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// openMMDB reads the MaxMind DB file at name.
+func openMMDB(name string) (*mmdb, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	i := bytes.LastIndex(data, mmdbMetadataMarker)
+	if i < 0 {
+		return nil, fmt.Errorf("%s: not a MaxMind DB file",
+			name)
+	}
+
+	db := &mmdb{data: data}
+
+	start := uint64(i + len(mmdbMetadataMarker))
+
+	meta, _, err := db.decode(start, start, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%s: metadata: %w",
+			name, err)
+	}
+
+	fields, _ := meta.(map[string]any)
+	db.nodeCount, _ = fields["node_count"].(uint64)
+	db.recordSize, _ = fields["record_size"].(uint64)
+	db.ipVersion, _ = fields["ip_version"].(uint64)
+
+	treeSize := db.recordSize * 2 / 8 * db.nodeCount
+	if db.recordSize != 24 && db.recordSize != 28 && db.recordSize != 32 ||
+		treeSize+16 > uint64(i) {
+		return nil, fmt.Errorf("%s: unsupported record size %d or damaged tree",
+			name, db.recordSize)
+	}
+
+	db.dataStart = treeSize + 16
+
+	// IPv4 addresses live under ::/96 in an IPv6 tree.
+	if db.ipVersion == 6 {
+		for range 96 {
+			if db.ipv4Start >= db.nodeCount {
+				break
+			}
+
+			db.ipv4Start = db.record(db.ipv4Start, 0)
+		}
+	}
+
+	return db, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// record returns the left (bit 0) or right (bit 1) record of a search tree node.
+func (db *mmdb) record(node uint64, bit byte) uint64 {
+	size := db.recordSize * 2 / 8
+	b := db.data[node*size : node*size+size]
+
+	switch db.recordSize {
+	case 24:
+		if bit == 0 {
+			return uint64(b[0])<<16 | uint64(b[1])<<8 | uint64(b[2])
+		}
+
+		return uint64(b[3])<<16 | uint64(b[4])<<8 | uint64(b[5])
+
+	case 28:
+		if bit == 0 {
+			return uint64(b[3]&0xf0)<<20 | uint64(b[0])<<16 | uint64(b[1])<<8 | uint64(b[2])
+		}
+
+		return uint64(b[3]&0x0f)<<24 | uint64(b[4])<<16 | uint64(b[5])<<8 | uint64(b[6])
+	}
+
+	if bit == 0 {
+		return uint64(binary.BigEndian.Uint32(b[0:4]))
+	}
+
+	return uint64(binary.BigEndian.Uint32(b[4:8]))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// lookup returns the record for addr, or nil if the database has none.
+func (db *mmdb) lookup(addr netip.Addr) (map[string]any, error) {
+	addr = addr.Unmap()
+
+	var (
+		bits []byte
+		node uint64
+	)
+
+	switch {
+	case addr.Is4() && db.ipVersion == 6:
+		b := addr.As4()
+		bits, node = b[:], db.ipv4Start
+
+	case addr.Is4():
+		b := addr.As4()
+		bits = b[:]
+
+	case db.ipVersion == 4:
+		return nil, nil
+
+	default:
+		b := addr.As16()
+		bits = b[:]
+	}
+
+	for i := 0; i < len(bits)*8 && node < db.nodeCount; i++ {
+		if (node+1)*db.recordSize/4 > uint64(len(db.data)) {
+			return nil, errors.New("search tree out of bounds")
+		}
+
+		node = db.record(node, bits[i/8]>>(7-i%8)&1)
+	}
+
+	if node <= db.nodeCount {
+		return nil, nil
+	}
+
+	offset := node - db.nodeCount - 16 + db.dataStart
+
+	value, _, err := db.decode(offset, db.dataStart, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, _ := value.(map[string]any)
+
+	return fields, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// decode decodes the value at offset in the data section that starts at base (or the metadata
+// section), returning it (as a map[string]any, []any, string, []byte, uint64, int64, float64,
+// or bool) and the offset just past it.
+func (db *mmdb) decode(offset, base uint64, depth int) (any, uint64, error) {
+	end := uint64(len(db.data))
+
+	take := func(n uint64) ([]byte, error) {
+		if offset+n > end {
+			return nil, errors.New("record out of bounds")
+		}
+
+		b := db.data[offset : offset+n]
+		offset += n
+
+		return b, nil
+	}
+
+	uintOf := func(b []byte) uint64 {
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+
+		return v
+	}
+
+	if depth > mmdbMaxDepth {
+		return nil, 0, errors.New("records nested too deeply")
+	}
+
+	ctrl, err := take(1)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	kind := uint64(ctrl[0] >> 5)
+
+	// A pointer's size bits say how long the pointer is; what it points at is decoded in place.
+	if kind == 1 {
+		length := uint64(ctrl[0]>>3&3) + 1
+
+		b, err := take(length)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		target := uintOf(b)
+
+		switch length {
+		case 1:
+			target |= uint64(ctrl[0]&7) << 8
+		case 2:
+			target = target | uint64(ctrl[0]&7)<<16 + 2048
+		case 3:
+			target = target | uint64(ctrl[0]&7)<<24 + 526336
+		}
+
+		value, _, err := db.decode(base+target, base, depth+1)
+
+		return value, offset, err
+	}
+
+	if kind == 0 {
+		b, err := take(1)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		kind = 7 + uint64(b[0])
+	}
+
+	size := uint64(ctrl[0] & 0x1f)
+	if size >= 29 {
+		b, err := take(size - 28)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		size = []uint64{29, 285, 65821}[size-29] + uintOf(b)
+	}
+
+	switch kind {
+	case 2, 4: // UTF-8 string, bytes
+		b, err := take(size)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if kind == 2 {
+			return string(b), offset, nil
+		}
+
+		return bytes.Clone(b), offset, nil
+
+	case 3, 15: // double, float
+		b, err := take(size)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if kind == 3 && size == 8 {
+			return math.Float64frombits(binary.BigEndian.Uint64(b)), offset, nil
+		}
+
+		if kind == 15 && size == 4 {
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), offset, nil
+		}
+
+		return nil, 0, errors.New("bad floating-point size")
+
+	case 5, 6, 9, 10: // unsigned integers; uint128 is truncated, being unused for locations
+		b, err := take(size)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return uintOf(b), offset, nil
+
+	case 8: // int32
+		b, err := take(size)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return int64(int32(uintOf(b))), offset, nil //nolint:gosec
+
+	case 7: // map
+		// A damaged file can claim some 16M entries, here and for arrays; space is made for
+		// no more than a few before they are read.
+		fields := make(map[string]any, min(size, 1024))
+
+		for range size {
+			key, next, err := db.decode(offset, base, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			value, next, err := db.decode(next, base, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			name, _ := key.(string)
+			fields[name], offset = value, next
+		}
+
+		return fields, offset, nil
+
+	case 11: // array
+		values := make([]any, 0, min(size, 1024))
+
+		for range size {
+			value, next, err := db.decode(offset, base, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			values, offset = append(values, value), next
+		}
+
+		return values, offset, nil
+
+	case 14: // boolean, held in the size
+		return size != 0, offset, nil
+	}
+
+	return nil, 0, fmt.Errorf("unsupported data type %d",
+		kind)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// geoReloadCheck is how often a geoDB looks for changed database files.
+const geoReloadCheck = 10 * time.Second
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// geoDB looks addresses up in a set of MaxMind DB files (a city or country database, and
+// optionally an ASN one), reopening each after it changes on disk, as when a geoipupdate cron
+// job replaces it.  Files that are missing or unreadable are skipped, so that lookups come up
+// empty rather than failing.
+type geoDB struct {
+	Paths []string
+
+	mu      sync.Mutex
+	checked time.Time
+	dbs     map[string]*mmdb
+	mtimes  map[string]time.Time
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// geoConfigPath is the file listing the default MaxMind DB paths, one per line.
+func geoConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "go-what", "geoip")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// geoConfigPaths returns the MaxMind DB paths listed in the file at name, leaving out blank
+// lines and # comments, or nil if it cannot be read.
+func geoConfigPaths(name string) []string {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil
+	}
+
+	defer f.Close()
+
+	var paths []string
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && line[0] != '#' {
+			paths = append(paths, line)
+		}
+	}
+
+	return paths
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// newGeoDB returns a geoDB over the comma-separated paths, or those in the configuration file if
+// there are none; the files are opened at the first lookup.
+func newGeoDB(paths string) *geoDB {
+	if paths == "" {
+		return &geoDB{Paths: geoConfigPaths(geoConfigPath())}
+	}
+
+	return &geoDB{Paths: strings.Split(paths, ",")}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// refresh reopens the files that have changed since they were last opened; g.mu is held.
+func (g *geoDB) refresh(now time.Time) {
+	if now.Sub(g.checked) < geoReloadCheck && g.dbs != nil {
+		return
+	}
+
+	g.checked = now
+
+	if g.dbs == nil {
+		g.dbs, g.mtimes = make(map[string]*mmdb), make(map[string]time.Time)
+	}
+
+	for _, path := range g.Paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			delete(g.dbs, path)
+
+			continue
+		}
+
+		if g.dbs[path] != nil && g.mtimes[path].Equal(fi.ModTime()) {
+			continue
+		}
+
+		db, err := openMMDB(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: geoip: %v\n",
+				err)
+			delete(g.dbs, path)
+
+			continue
+		}
+
+		g.dbs[path], g.mtimes[path] = db, fi.ModTime()
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Describe returns where host (an address; names are not resolved) is, such as "NZ Wellington"
+// or "US AS15169 Google LLC", or "" if it is not an address or no database knows it.
+func (g *geoDB) Describe(host string) string {
+	addr, err := netip.ParseAddr(host)
+	if g == nil || err != nil || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return ""
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.refresh(time.Now())
+
+	var country, city, asn string
+
+	for _, path := range g.Paths {
+		db := g.dbs[path]
+		if db == nil {
+			continue
+		}
+
+		fields, err := db.lookup(addr)
+		if err != nil || fields == nil {
+			continue
+		}
+
+		if c, ok := fields["country"].(map[string]any); ok && country == "" {
+			country, _ = c["iso_code"].(string)
+		}
+
+		if c, ok := fields["city"].(map[string]any); ok && city == "" {
+			names, _ := c["names"].(map[string]any)
+			city, _ = names["en"].(string)
+		}
+
+		if n, ok := fields["autonomous_system_number"].(uint64); ok && asn == "" {
+			org, _ := fields["autonomous_system_organization"].(string)
+			asn = strings.TrimSpace(fmt.Sprintf("AS%d %s",
+				n, org))
+		}
+	}
+
+	return strings.Join(strings.Fields(strings.Join([]string{country, city, asn}, " ")), " ")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,48 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - geoip_minimal.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 9db1a1f6-c7aa-11f1-b940-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+//go:build minimal
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// haveGeoIP is false in minimal builds, which leave out the MaxMind DB reader behind --geo.
+const haveGeoIP = false
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// geoDB stands in for the GeoIP lookups; newGeoDB never returns one.
+type geoDB struct{}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func geoConfigPath() string { return "" }
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func geoConfigPaths(string) []string { return nil }
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func newGeoDB(string) *geoDB { return nil }
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (*geoDB) Describe(string) string { return "" }
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,496 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - geoip_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: f3d86bf5-c7b3-11f1-8044-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+//go:build !minimal
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"encoding/binary"
+	"maps"
+	"math"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// mmdbCtrl returns the control bytes of a MaxMind DB value of the kind and size.
+func mmdbCtrl(kind, size int) []byte {
+	var first byte
+	if kind <= 7 {
+		first = byte(kind << 5)
+	}
+
+	var extra []byte
+
+	switch {
+	case size < 29:
+		first |= byte(size)
+	case size < 285:
+		first, extra = first|29, []byte{byte(size - 29)}
+	case size < 65821:
+		first, extra = first|30, binary.BigEndian.AppendUint16(nil, uint16(size-285))
+	default:
+		n := size - 65821
+		first, extra = first|31, []byte{byte(n >> 16), byte(n >> 8), byte(n)}
+	}
+
+	b := []byte{first}
+	if kind > 7 {
+		b = append(b, byte(kind-7))
+	}
+
+	return append(b, extra...)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// mmdbRaw is bytes that mmdbEncode passes through as they are, such as a pointer.
+type mmdbRaw []byte
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// mmdbEncode encodes v, a map[string]any, []any, string, uint64, int32, float64, bool, or
+// mmdbRaw, as a MaxMind DB value.
+func mmdbEncode(v any) []byte {
+	switch v := v.(type) {
+	case mmdbRaw:
+		return v
+
+	case string:
+		return append(mmdbCtrl(2, len(v)), v...)
+
+	case uint64:
+		b := binary.BigEndian.AppendUint64(nil, v)
+		for len(b) > 0 && b[0] == 0 {
+			b = b[1:]
+		}
+
+		kind := 6 // uint32
+		if len(b) > 4 {
+			kind = 9 // uint64
+		}
+
+		return append(mmdbCtrl(kind, len(b)), b...)
+
+	case int32:
+		return binary.BigEndian.AppendUint32(mmdbCtrl(8, 4), uint32(v))
+
+	case float64:
+		return binary.BigEndian.AppendUint64(mmdbCtrl(3, 8), math.Float64bits(v))
+
+	case bool:
+		size := 0
+		if v {
+			size = 1
+		}
+
+		return mmdbCtrl(14, size)
+
+	case []any:
+		b := mmdbCtrl(11, len(v))
+		for _, e := range v {
+			b = append(b, mmdbEncode(e)...)
+		}
+
+		return b
+
+	case map[string]any:
+		b := mmdbCtrl(7, len(v))
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			b = append(append(b, mmdbEncode(key)...), mmdbEncode(v[key])...)
+		}
+
+		return b
+	}
+
+	panic("mmdbEncode: unsupported type")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// buildMMDB returns a MaxMind DB file with records of recordSize bits, for addresses of
+// ipVersion, holding the values of records by prefix.  In an IPv6 tree, IPv4 prefixes go under
+// ::/96, as MaxMind's own databases have them.
+func buildMMDB(recordSize, ipVersion int, records map[string]any) []byte {
+	// A record is a node's index, -1 for none, or -2-offset for the value at offset.
+	nodes := [][2]int{{-1, -1}}
+
+	var data []byte
+
+	for _, prefix := range slices.Sorted(maps.Keys(records)) {
+		p := netip.MustParsePrefix(prefix)
+		offset := len(data)
+		data = append(data, mmdbEncode(records[prefix])...)
+
+		var bits []byte
+
+		length := p.Bits()
+
+		switch {
+		case p.Addr().Is4() && ipVersion == 6:
+			b := p.Addr().As4()
+			bits, length = append(make([]byte, 12), b[:]...), length+96
+		case p.Addr().Is4():
+			b := p.Addr().As4()
+			bits = b[:]
+		default:
+			b := p.Addr().As16()
+			bits = b[:]
+		}
+
+		node := 0
+
+		for i := range length {
+			bit := bits[i/8] >> (7 - i%8) & 1
+			if i == length-1 {
+				nodes[node][bit] = -2 - offset
+
+				break
+			}
+
+			if nodes[node][bit] < 0 {
+				nodes = append(nodes, [2]int{-1, -1})
+				nodes[node][bit] = len(nodes) - 1
+			}
+
+			node = nodes[node][bit]
+		}
+	}
+
+	count := len(nodes)
+	value := func(r int) uint32 {
+		switch {
+		case r >= 0:
+			return uint32(r)
+		case r == -1:
+			return uint32(count)
+		}
+
+		return uint32(count + 16 - 2 - r)
+	}
+
+	var tree []byte
+
+	for _, n := range nodes {
+		left, right := value(n[0]), value(n[1])
+
+		switch recordSize {
+		case 24:
+			tree = append(tree, byte(left>>16), byte(left>>8), byte(left),
+				byte(right>>16), byte(right>>8), byte(right))
+		case 28:
+			tree = append(tree, byte(left>>16), byte(left>>8), byte(left),
+				byte(left>>24&0xf<<4|right>>24&0xf), byte(right>>16), byte(right>>8), byte(right))
+		case 32:
+			tree = binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(tree, left), right)
+		}
+	}
+
+	b := append(append(append(tree, make([]byte, 16)...), data...), mmdbMetadataMarker...)
+
+	return append(b, mmdbEncode(map[string]any{
+		"node_count":    uint64(count),
+		"record_size":   uint64(recordSize),
+		"ip_version":    uint64(ipVersion),
+		"database_type": "go-what-test",
+	})...)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// writeMMDB writes a MaxMind DB file for the test, returning its name.
+func writeMMDB(t *testing.T, data []byte) string {
+	t.Helper()
+
+	name := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return name
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestMMDBDecode(t *testing.T) {
+	long := strings.Repeat("x", 300)
+
+	// A pointer's target is offset by the space the shorter pointers cover.
+	at := func(target int, pointer ...byte) []byte {
+		b := make([]byte, target+1)
+		copy(b, pointer)
+
+		return append(b[:target], mmdbEncode("found")...)
+	}
+
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want any
+	}{
+		{"a string", mmdbEncode("Wellington"), "Wellington"},
+		{"a long string", mmdbEncode(long), long},
+		{"bytes", append(mmdbCtrl(4, 2), 0xca, 0xfe), []byte{0xca, 0xfe}},
+		{"a uint16", []byte{5<<5 | 2, 0x01, 0x00}, uint64(256)},
+		{"a uint32", mmdbEncode(uint64(15169)), uint64(15169)},
+		{"a uint64", mmdbEncode(uint64(1) << 40), uint64(1) << 40},
+		{"an empty uint32", mmdbCtrl(6, 0), uint64(0)},
+		{"an int32", mmdbEncode(int32(-41)), int64(-41)},
+		{"a double", mmdbEncode(-41.2865), -41.2865},
+		{"a float", binary.BigEndian.AppendUint32(mmdbCtrl(15, 4), math.Float32bits(0.5)), 0.5},
+		{"true", mmdbEncode(true), true},
+		{"false", mmdbEncode(false), false},
+		{"an array", mmdbEncode([]any{"a", uint64(2)}), []any{"a", uint64(2)}},
+		{
+			"a map", mmdbEncode(map[string]any{"iso_code": "NZ", "names": map[string]any{}}),
+			map[string]any{"iso_code": "NZ", "names": map[string]any{}},
+		},
+		{"a 1-byte pointer", at(0x105, 1<<5|0<<3|1, 0x05), "found"},
+		{"a 2-byte pointer", at(2048+0x10003, 1<<5|1<<3|1, 0x00, 0x03), "found"},
+		{"a 3-byte pointer", at(526336+5, 1<<5|2<<3|0, 0x00, 0x00, 0x05), "found"},
+		{"a 4-byte pointer", at(9, 1<<5|3<<3|7, 0x00, 0x00, 0x00, 0x09), "found"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := &mmdb{data: tc.data}
+
+			got, _, err := db.decode(0, 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("decode = %#v, want %#v",
+					got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// TestMMDBDecodeOffset checks that decode returns the offset just past the value, and past a
+// pointer rather than what it points at.
+func TestMMDBDecodeOffset(t *testing.T) {
+	data := append(mmdbEncode(map[string]any{"a": "b"}), 1<<5, 0)
+
+	db := &mmdb{data: data}
+
+	for _, tc := range []struct {
+		offset, want uint64
+	}{
+		{0, uint64(len(data) - 2)},
+		{uint64(len(data) - 2), uint64(len(data))},
+	} {
+		if _, next, err := db.decode(tc.offset, 0, 0); err != nil || next != tc.want {
+			t.Errorf("decode at %d ended at %d, %v, want %d",
+				tc.offset, next, err, tc.want)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestMMDBDecodeDamaged(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"no data", nil, "record out of bounds"},
+		{"a truncated string", append(mmdbCtrl(2, 5), "abc"...), "record out of bounds"},
+		{"a truncated size", []byte{2<<5 | 30, 0x01}, "record out of bounds"},
+		{"a truncated pointer", []byte{1<<5 | 1<<3, 0x00}, "record out of bounds"},
+		{"a pointer out of bounds", []byte{1<<5 | 7, 0xff}, "record out of bounds"},
+		{"a pointer to itself", []byte{1 << 5, 0x00}, "records nested too deeply"},
+		{"a missing extended type", []byte{0}, "record out of bounds"},
+		{"an unsupported type", []byte{0, 12 - 7}, "unsupported data type 12"},
+		{"a short double", append(mmdbCtrl(3, 4), 0, 0, 0, 0), "bad floating-point size"},
+		{"a truncated map", mmdbCtrl(7, 2), "record out of bounds"},
+		{"a map without its value", append(mmdbCtrl(7, 1), mmdbEncode("key")...), "out of bounds"},
+		// A size of some 16M: decode must fail for want of entries, not allocate them all.
+		{"a huge map", mmdbCtrl(7, 16843035), "record out of bounds"},
+		{"a huge array", mmdbCtrl(11, 16843035), "record out of bounds"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := &mmdb{data: tc.data}
+
+			value, _, err := db.decode(0, 0, 0)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("decode = %#v, %v, want an error with %q",
+					value, err, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestMMDBRecord(t *testing.T) {
+	node := []byte{0x12, 0x34, 0x56, 0xab, 0x78, 0x9a, 0xbc, 0xde}
+
+	for _, tc := range []struct {
+		size        uint64
+		left, right uint64
+	}{
+		{24, 0x123456, 0xab789a},
+		{28, 0xa123456, 0xb789abc},
+		{32, 0x123456ab, 0x789abcde},
+	} {
+		// The second node, so that the node size is used too.
+		db := &mmdb{data: append(make([]byte, tc.size/4), node...), recordSize: tc.size}
+
+		if left, right := db.record(1, 0), db.record(1, 1); left != tc.left || right != tc.right {
+			t.Errorf("%d-bit records %#x and %#x, want %#x and %#x",
+				tc.size, left, right, tc.left, tc.right)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestMMDBLookup(t *testing.T) {
+	nz := map[string]any{
+		"country": map[string]any{"iso_code": "NZ"},
+		"city":    map[string]any{"names": map[string]any{"en": "Wellington"}},
+	}
+	asn := map[string]any{
+		"autonomous_system_number":       uint64(64496),
+		"autonomous_system_organization": "Example Net",
+	}
+
+	for _, recordSize := range []int{24, 28, 32} {
+		for _, ipVersion := range []int{4, 6} {
+			records := map[string]any{"192.0.2.0/24": nz, "198.51.100.128/25": asn}
+			if ipVersion == 6 {
+				records["2001:db8::/32"] = asn
+			}
+
+			db, err := openMMDB(writeMMDB(t, buildMMDB(recordSize, ipVersion, records)))
+			if err != nil {
+				t.Fatalf("%d-bit IPv%d: %v",
+					recordSize, ipVersion, err)
+			}
+
+			for _, tc := range []struct {
+				addr string
+				want map[string]any
+			}{
+				{"192.0.2.7", nz},
+				{"::ffff:192.0.2.7", nz},
+				{"192.0.3.1", nil},
+				{"198.51.100.200", asn},
+				{"198.51.100.100", nil},
+				{"2001:db8::1", map[int]map[string]any{4: nil, 6: asn}[ipVersion]},
+				{"2001:db9::1", nil},
+			} {
+				got, err := db.lookup(netip.MustParseAddr(tc.addr))
+				if err != nil || !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("%d-bit IPv%d: lookup(%s) = %v, %v, want %v",
+						recordSize, ipVersion, tc.addr, got, err, tc.want)
+				}
+			}
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestGeoDBDescribe(t *testing.T) {
+	city := writeMMDB(t, buildMMDB(24, 6, map[string]any{"192.0.2.0/24": map[string]any{
+		"country": map[string]any{"iso_code": "NZ"},
+		"city":    map[string]any{"names": map[string]any{"en": "Wellington"}},
+	}}))
+	asn := writeMMDB(t, buildMMDB(28, 4, map[string]any{"192.0.2.0/24": map[string]any{
+		"autonomous_system_number":       uint64(64496),
+		"autonomous_system_organization": "Example Net",
+	}}))
+	geo := newGeoDB(city + "," + filepath.Join(t.TempDir(), "missing.mmdb") + "," + asn)
+
+	for host, want := range map[string]string{
+		"192.0.2.7":   "NZ Wellington AS64496 Example Net",
+		"192.0.3.7":   "",
+		"10.0.0.1":    "",
+		"example.com": "",
+	} {
+		if got := geo.Describe(host); got != want {
+			t.Errorf("Describe(%q) = %q, want %q",
+				host, got, want)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestOpenMMDBDamaged(t *testing.T) {
+	valid := buildMMDB(24, 4, map[string]any{"192.0.2.0/24": map[string]any{"a": "b"}})
+	meta := func(fields map[string]any) []byte {
+		return append(slices.Clone(mmdbMetadataMarker), mmdbEncode(fields)...)
+	}
+
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"not a database", []byte("hello"), "not a MaxMind DB file"},
+		{
+			"truncated metadata", append(slices.Clone(mmdbMetadataMarker), mmdbCtrl(7, 3)...),
+			"metadata",
+		},
+		{
+			"an unsupported record size",
+			meta(map[string]any{"node_count": uint64(0), "record_size": uint64(16)}),
+			"unsupported record size 16",
+		},
+		{
+			"a tree longer than the file",
+			meta(map[string]any{"node_count": uint64(1000), "record_size": uint64(24)}),
+			"damaged tree",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := openMMDB(writeMMDB(t, tc.data)); err == nil ||
+				!strings.Contains(err.Error(), tc.want) {
+				t.Errorf("openMMDB = %v, want an error with %q",
+					err, tc.want)
+			}
+		})
+	}
+
+	// A record pointing past the end of the data section.
+	db, err := openMMDB(writeMMDB(t, valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db.data = db.data[:db.dataStart+2]
+
+	if _, err := db.lookup(netip.MustParseAddr("192.0.2.1")); err == nil {
+		t.Error("lookup in a truncated data section succeeded")
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
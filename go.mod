@@ -15,11 +15,10 @@ go 1.26.3
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////
 
-require golang.org/x/term v0.42.0
-
-///////////////////////////////////////////////////////////////////////////////////////////////////
-
-require golang.org/x/sys v0.44.0 // indirect
+require (
+	golang.org/x/sys v0.44.0
+	golang.org/x/term v0.42.0
+)
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////
 // Local Variables:
@@ -0,0 +1,124 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - groups.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 3a6967e6-c7a9-11f1-a151-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// userGroups caches the groups groupsOf finds for each user, comma-separated.
+var userGroups userCache
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// groupsOf returns uid's groups, by name, its primary group first, or nil if that cannot be
+// found out.  Groups without a name (and, with numericIDs, all of them) are given by GID.
+func groupsOf(uid uint32) []string {
+	groups := userGroups.name(uid, lookupGroups)
+	if groups == "" {
+		return nil
+	}
+
+	return strings.Split(groups, ",")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// inGroups reports whether uid is in any of groups.
+func inGroups(uid uint32, groups []string) bool {
+	return slices.ContainsFunc(groupsOf(uid), func(group string) bool {
+		return slices.Contains(groups, group)
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// lookupGroups returns uid's groups for groupsOf, from the group file beside passwdFile if that
+// is set, or the system's user database.
+func lookupGroups(uid uint32) string {
+	if passwdFile != "" {
+		return fileGroups(uid)
+	}
+
+	u, err := user.LookupId(strconv.Itoa(int(uid)))
+	if err != nil || u == nil {
+		return ""
+	}
+
+	gids, _ := u.GroupIds()
+	gids = append([]string{u.Gid}, slices.DeleteFunc(gids, func(gid string) bool {
+		return gid == u.Gid
+	})...)
+
+	for i, gid := range gids {
+		if g, err := user.LookupGroupId(gid); err == nil && !numericIDs {
+			gids[i] = g.Name
+		}
+	}
+
+	return strings.Join(gids, ",")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fileGroups is lookupGroups for an alternate passwd file, whose group file is beside it.
+func fileGroups(uid uint32) string {
+	fields := passwdFields(uid)
+	if len(fields) < 4 {
+		return ""
+	}
+
+	name, primary := fields[0], fields[3]
+	groups := []string{primary}
+
+	data, _ := os.ReadFile(filepath.Join(filepath.Dir(passwdFile), "group"))
+
+	// name:password:GID:members
+	for line := range strings.Lines(string(data)) {
+		group := strings.Split(strings.TrimSpace(line), ":")
+		if len(group) < 4 {
+			continue
+		}
+
+		label := group[0]
+		if numericIDs || label == "" {
+			label = group[2]
+		}
+
+		switch {
+		case group[2] == primary:
+			groups[0] = label
+
+		case slices.Contains(strings.Split(group[3], ","), name):
+			groups = append(groups, label)
+		}
+	}
+
+	return strings.Join(groups, ",")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,291 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - hours.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: cb479729-c7af-11f1-b2d7-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// hoursWindow is a window of a business-hours rule: the weekdays it opens on, and its opening
+// and closing times as minutes after midnight.  A window that closes before it opens runs past
+// midnight into the next day.
+type hoursWindow struct {
+	Days     [7]bool // by time.Weekday
+	Holidays bool
+	From, To int
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// businessHours is the --hours rule: it raises an "out-of-hours" alert when an interactive
+// session logs in outside the allowed windows of its account.  A user's own windows apply if
+// the file has any, then those of the groups the user is in, then those for "*"; a user none
+// apply to may log in at any time.
+type businessHours struct {
+	Users    map[string][]hoursWindow
+	Groups   map[string][]hoursWindow
+	Anyone   []hoursWindow
+	Holidays map[string]bool // by YYYY-MM-DD
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// hoursDays are the day names of an hours file, in the order of time.Weekday.
+var hoursDays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// loadBusinessHours reads an hours file.  Each line but blanks and # comments is either a rule,
+//
+//	WHO DAYS HH:MM-HH:MM
+//
+// where WHO is a user, @group, or * for anyone, and DAYS a comma-separated list of days and
+// ranges of days (mon-fri,sat) that may name "holiday" for a window open on holidays too, or a
+// holiday, "holiday YYYY-MM-DD", on which only such windows open.  A user or group can have
+// several windows, on as many lines.
+func loadBusinessHours(name string) (*businessHours, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	hours := &businessHours{
+		Users:    make(map[string][]hoursWindow),
+		Groups:   make(map[string][]hoursWindow),
+		Holidays: make(map[string]bool),
+	}
+
+	scanner := bufio.NewScanner(f)
+
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "holiday" {
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("%s:%d: want holiday YYYY-MM-DD",
+					name, line)
+			}
+
+			day, err := time.Parse(time.DateOnly, fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %q is not a YYYY-MM-DD date",
+					name, line, fields[1])
+			}
+
+			hours.Holidays[day.Format(time.DateOnly)] = true
+
+			continue
+		}
+
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: want WHO DAYS HH:MM-HH:MM",
+				name, line)
+		}
+
+		window, err := parseHoursWindow(fields[1], fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w",
+				name, line, err)
+		}
+
+		switch who := fields[0]; {
+		case who == "*":
+			hours.Anyone = append(hours.Anyone, window)
+
+		case strings.HasPrefix(who, "@"):
+			hours.Groups[who[1:]] = append(hours.Groups[who[1:]], window)
+
+		default:
+			hours.Users[who] = append(hours.Users[who], window)
+		}
+	}
+
+	return hours, scanner.Err()
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// parseHoursWindow parses the DAYS and HH:MM-HH:MM of a rule.
+func parseHoursWindow(days, span string) (hoursWindow, error) {
+	var window hoursWindow
+
+	for item := range strings.SplitSeq(days, ",") {
+		if item == "holiday" {
+			window.Holidays = true
+
+			continue
+		}
+
+		first, last, isRange := strings.Cut(item, "-")
+		if !isRange {
+			last = first
+		}
+
+		from, to := slices.Index(hoursDays, first), slices.Index(hoursDays, last)
+		if from < 0 || to < 0 {
+			return window, fmt.Errorf("%q is not a day or range of days, such as mon-fri",
+				item)
+		}
+
+		for d := from; ; d = (d + 1) % 7 {
+			window.Days[d] = true
+
+			if d == to {
+				break
+			}
+		}
+	}
+
+	opens, closes, ok := strings.Cut(span, "-")
+
+	var err error
+
+	if window.From, err = parseClock(opens); err == nil && ok {
+		window.To, err = parseClock(closes)
+	}
+
+	if err != nil || !ok {
+		return window, fmt.Errorf("%q is not a span of times, such as 09:00-17:30",
+			span)
+	}
+
+	return window, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// parseClock parses HH:MM as minutes after midnight, allowing 24:00 to close a window at the end
+// of the day.
+func parseClock(s string) (int, error) {
+	var hour, minute int
+
+	if _, err := fmt.Sscanf(s, "%2d:%2d", &hour, &minute); err != nil || len(s) != 5 {
+		return 0, fmt.Errorf("%q is not HH:MM",
+			s)
+	}
+
+	if hour > 24 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("%q is not a time of day",
+			s)
+	}
+
+	return hour*60 + minute, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// windows returns the windows that apply to uid, and false if none do.
+func (h *businessHours) windows(uid uint32) ([]hoursWindow, bool) {
+	if windows, ok := h.Users[username(uid)]; ok {
+		return windows, true
+	}
+
+	var windows []hoursWindow
+
+	for _, group := range groupsOf(uid) {
+		windows = append(windows, h.Groups[group]...)
+	}
+
+	if len(windows) > 0 {
+		return windows, true
+	}
+
+	return h.Anyone, len(h.Anyone) > 0
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// open reports whether any of the windows is open at t, in t's location.  The part of a window
+// that runs past midnight belongs to the day it opened.
+func (h *businessHours) open(windows []hoursWindow, t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	yesterday := t.AddDate(0, 0, -1)
+
+	for _, w := range windows {
+		switch {
+		case w.From <= w.To:
+			if minute >= w.From && minute < w.To && h.opensOn(w, t) {
+				return true
+			}
+
+		case minute >= w.From && h.opensOn(w, t), minute < w.To && h.opensOn(w, yesterday):
+			return true
+		}
+	}
+
+	return false
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// opensOn reports whether a window opens on t's day: on its weekdays, unless that is a holiday
+// the window does not open on.
+func (h *businessHours) opensOn(w hoursWindow, t time.Time) bool {
+	if h.Holidays[t.Format(time.DateOnly)] {
+		return w.Holidays
+	}
+
+	return w.Days[t.Weekday()]
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// check implements alertRule, checking the login time of each interactive session as it
+// starts: sessions a labelling rule marks automated are left out.
+func (h *businessHours) check(event what.SessionEvent) []alert {
+	tty := event.TTY
+
+	if event.Type != what.SessionAdded || tty.Automated {
+		return nil
+	}
+
+	windows, ok := h.windows(tty.Stat.Uid)
+	login := time.Unix(tty.Stat.Ctim.Unix())
+
+	if !ok || h.open(windows, login) {
+		return nil
+	}
+
+	return []alert{{
+		Time: event.Time,
+		Rule: "out-of-hours",
+		TTY:  tty,
+		Message: fmt.Sprintf("%s logged in on %s at %s, outside the allowed hours",
+			username(tty.Stat.Uid), tty.Name, login.Format("Mon 2006-01-02 15:04")),
+	}}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
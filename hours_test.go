@@ -0,0 +1,159 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - hours_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 86969e43-c7b1-11f1-afb0-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// writeHours writes an hours file for the test, returning its name.
+func writeHours(t *testing.T, content string) string {
+	t.Helper()
+
+	name := filepath.Join(t.TempDir(), "hours")
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return name
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestBusinessHours(t *testing.T) {
+	hours, err := loadBusinessHours(writeHours(t, `
+# Anyone on weekdays; staff also on weekend nights; alice at any time but holidays.
+*      mon-fri       09:00-17:30
+@staff sat,sun       22:00-06:00   # into the next morning
+alice  mon-sun       00:00-24:00
+carol  mon-fri,holiday 08:00-12:00
+holiday 2026-12-25
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		uid  uint32
+		at   string
+		want bool // raises an alert
+	}{
+		{0, "2026-10-14 10:00", false},   // Wednesday, inside *
+		{0, "2026-10-14 17:30", true},    // the window closes at 17:30
+		{0, "2026-10-14 08:59", true},    // and opens at 09:00
+		{0, "2026-10-17 10:00", true},    // Saturday
+		{1001, "2026-10-14 10:00", true}, // staff's windows replace *
+		{1001, "2026-10-17 23:00", false},
+		{1001, "2026-10-19 05:59", false}, // Sunday night's window, on Monday morning
+		{1001, "2026-10-20 05:00", true},  // but not Tuesday's
+		{1000, "2026-10-18 03:00", false},
+		{1000, "2026-12-25 12:00", true}, // a holiday
+		{1002, "2026-12-25 09:00", false},
+		{1002, "2026-12-25 13:00", true},
+	} {
+		t.Run(username(tc.uid)+" "+tc.at, func(t *testing.T) {
+			login, err := time.ParseInLocation("2006-01-02 15:04", tc.at, time.Local)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tty := &what.TTY{Name: "pts/0", Stat: syscall.Stat_t{Uid: tc.uid}}
+			tty.Stat.Ctim.Sec = login.Unix()
+
+			alerts := hours.check(what.SessionEvent{Type: what.SessionAdded, TTY: tty})
+			if (len(alerts) > 0) != tc.want {
+				t.Errorf("check alerted %+v, want an alert: %v",
+					alerts, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestBusinessHoursSkips(t *testing.T) {
+	hours, err := loadBusinessHours(writeHours(t, "* mon 09:00-10:00\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A Sunday login, out of hours for anyone.
+	sunday := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+
+	for _, tc := range []struct {
+		name   string
+		event  what.EventType
+		adjust func(*what.TTY)
+	}{
+		{"updates", what.SessionUpdated, func(*what.TTY) {}},
+		{"removals", what.SessionRemoved, func(*what.TTY) {}},
+		{"automated sessions", what.SessionAdded, func(tty *what.TTY) { tty.Automated = true }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tty := &what.TTY{Name: "pts/0"}
+			tty.Stat.Ctim.Sec = sunday.Unix()
+			tc.adjust(tty)
+
+			if alerts := hours.check(what.SessionEvent{Type: tc.event, TTY: tty}); alerts != nil {
+				t.Errorf("check alerted %+v",
+					alerts)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestLoadBusinessHoursErrors(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		want string
+	}{
+		{"alice mon-fri", "want WHO DAYS HH:MM-HH:MM"},
+		{"alice someday 09:00-17:00", `"someday" is not a day`},
+		{"alice mon-fri 9-17", `"9-17" is not a span of times`},
+		{"alice mon-fri 09:00-25:00", "is not a span of times"},
+		{"alice mon-fri 09:00", "is not a span of times"},
+		{"holiday", "want holiday YYYY-MM-DD"},
+		{"holiday 2026-13-01", `"2026-13-01" is not a YYYY-MM-DD date`},
+	} {
+		t.Run(tc.line, func(t *testing.T) {
+			name := writeHours(t, "# rules\n"+tc.line+"\n")
+
+			_, err := loadBusinessHours(name)
+			if err == nil || !strings.Contains(err.Error(), tc.want) ||
+				!strings.HasPrefix(err.Error(), name+":2: ") {
+				t.Errorf("loadBusinessHours = %v, want an error at line 2 with %q",
+					err, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
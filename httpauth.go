@@ -0,0 +1,272 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - httpauth.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 3e44b684-c7a6-11f1-a934-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// credentials are who may query a server: bearer tokens, and users with basic-auth passwords.
+type credentials struct {
+	Tokens []string
+	Users  map[string]string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readSecrets returns the lines of name, leaving out blank lines and # comments.
+func readSecrets(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var lines []string
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && line[0] != '#' {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%s: no credentials found",
+			name)
+	}
+
+	return lines, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// loadCredentials reads the bearer tokens of tokenFile, one per line, and the users of
+// basicFile, as user:password lines.  Either may be "", and nil is returned if both are: then
+// anyone may query.
+func loadCredentials(tokenFile, basicFile string) (*credentials, error) {
+	if tokenFile == "" && basicFile == "" {
+		return nil, nil
+	}
+
+	creds := &credentials{Users: make(map[string]string)}
+
+	if tokenFile != "" {
+		tokens, err := readSecrets(tokenFile)
+		if err != nil {
+			return nil, err
+		}
+
+		creds.Tokens = tokens
+	}
+
+	if basicFile != "" {
+		lines, err := readSecrets(basicFile)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, line := range lines {
+			user, password, ok := strings.Cut(line, ":")
+			if !ok || user == "" {
+				return nil, fmt.Errorf("%s: entry %d is not user:password",
+					basicFile, i+1)
+			}
+
+			creds.Users[user] = password
+		}
+	}
+
+	return creds, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// allows reports whether r carries one of the credentials.  Comparisons take the same time
+// whether or not they match.
+func (c *credentials) allows(r *http.Request) bool {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return slices.ContainsFunc(c.Tokens, func(t string) bool {
+			return subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1
+		})
+	}
+
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	want, known := c.Users[user]
+
+	return subtle.ConstantTimeCompare([]byte(want), []byte(password)) == 1 && known
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// challenge is the WWW-Authenticate header of a refused request, offering the schemes that
+// the credentials have.
+func (c *credentials) challenge() string {
+	var schemes []string
+
+	if len(c.Tokens) > 0 {
+		schemes = append(schemes, `Bearer realm="go-what"`)
+	}
+
+	if len(c.Users) > 0 {
+		schemes = append(schemes, `Basic realm="go-what", charset="UTF-8"`)
+	}
+
+	return strings.Join(schemes, ", ")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// limiter is a token bucket per client address: each may make burst requests at once, and
+// then one every 1/rate seconds.
+type limiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// bucket is a client's tokens as of last.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// maxBuckets is how many clients a limiter tracks before forgetting those whose buckets have
+// refilled, which limit nothing.
+const maxBuckets = 10000
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// newLimiter returns a limiter allowing perMinute requests a minute to each client, or nil
+// (which allows everything) if perMinute is not positive.
+func newLimiter(perMinute int) *limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+
+	return &limiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(perMinute),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// allow takes a token from client's bucket, returning 0 if there was one and otherwise how
+// long until there will be.
+func (l *limiter) allow(client string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.buckets) >= maxBuckets {
+		for key, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+				delete(l.buckets, key)
+			}
+		}
+	}
+
+	b := l.buckets[client]
+	if b == nil {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*l.rate, l.burst)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+
+	b.tokens--
+
+	return 0
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// protect wraps next so that each client is held to the limiter and must present one of the
+// credentials; either may be nil to skip it.  The paths in open are exempt from both, for
+// health probes that cannot authenticate.
+func protect(next http.Handler, creds *credentials, lim *limiter, open ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slices.Contains(open, r.URL.Path) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		if lim != nil {
+			client, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				client = r.RemoteAddr
+			}
+
+			if wait := lim.allow(client, time.Now()); wait > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+
+				return
+			}
+		}
+
+		if creds != nil && !creds.allows(r) {
+			w.Header().Set("WWW-Authenticate", creds.challenge())
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,233 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - httpauth_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: cfeffb55-c7b1-11f1-95a7-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// writeSecrets writes a secrets file for the test, returning its name.
+func writeSecrets(t *testing.T, content string) string {
+	t.Helper()
+
+	name := filepath.Join(t.TempDir(), "secrets")
+	if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return name
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestCredentials(t *testing.T) {
+	creds, err := loadCredentials(
+		writeSecrets(t, "# tokens\n\ns3cret-token\nother-token\n"),
+		writeSecrets(t, "alice:wonderland\nbob:pass:with:colons\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"no credentials", "", false},
+		{"a token", "Bearer s3cret-token", true},
+		{"another token", "Bearer other-token", true},
+		{"a wrong token", "Bearer s3cret", false},
+		{"a comment as a token", "Bearer # tokens", false},
+		{"a password", basic("alice", "wonderland"), true},
+		{"a password with colons", basic("bob", "pass:with:colons"), true},
+		{"a wrong password", basic("alice", "looking-glass"), false},
+		{"an unknown user", basic("carol", ""), false},
+		{"another scheme", "Digest username=alice", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/snapshot", nil)
+			if tc.header != "" {
+				r.Header.Set("Authorization", tc.header)
+			}
+
+			if got := creds.allows(r); got != tc.want {
+				t.Errorf("allows(%q) = %v, want %v",
+					tc.header, got, tc.want)
+			}
+		})
+	}
+
+	if got, want := creds.challenge(),
+		`Bearer realm="go-what", Basic realm="go-what", charset="UTF-8"`; got != want {
+		t.Errorf("challenge = %q, want %q",
+			got, want)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// basic returns the Authorization header of a basic-auth request.
+func basic(user, password string) string {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetBasicAuth(user, password)
+
+	return r.Header.Get("Authorization")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestLoadCredentialsErrors(t *testing.T) {
+	if creds, err := loadCredentials("", ""); creds != nil || err != nil {
+		t.Errorf("loadCredentials with no files = %v, %v, want nil, nil",
+			creds, err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		tokens    string
+		basicAuth string
+		want      string
+	}{
+		{"no tokens", "# none yet\n", "", "no credentials found"},
+		{"no users", "", "\n\n", "no credentials found"},
+		{"no password", "", "alice:secret\nbob\n", "entry 2 is not user:password"},
+		{"no user", "", ":secret\n", "entry 1 is not user:password"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var tokenFile, basicFile string
+			if tc.tokens != "" {
+				tokenFile = writeSecrets(t, tc.tokens)
+			}
+
+			if tc.basicAuth != "" {
+				basicFile = writeSecrets(t, tc.basicAuth)
+			}
+
+			_, err := loadCredentials(tokenFile, basicFile)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("loadCredentials = %v, want an error with %q",
+					err, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestLimiter(t *testing.T) {
+	if newLimiter(0) != nil {
+		t.Error("newLimiter(0) limits requests")
+	}
+
+	lim := newLimiter(60)
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	// The burst is a minute's worth of requests.
+	for i := range 60 {
+		if wait := lim.allow("192.0.2.1", now); wait != 0 {
+			t.Fatalf("request %d was held for %v",
+				i+1, wait)
+		}
+	}
+
+	if wait := lim.allow("192.0.2.1", now); wait != time.Second {
+		t.Errorf("request 61 was held for %v, want 1s",
+			wait)
+	}
+
+	if wait := lim.allow("192.0.2.2", now); wait != 0 {
+		t.Errorf("another client was held for %v",
+			wait)
+	}
+
+	// After half a second, half a token has come back.
+	if wait := lim.allow("192.0.2.1", now.Add(500*time.Millisecond)); wait != 500*time.Millisecond {
+		t.Errorf("request 62 was held for %v, want 500ms",
+			wait)
+	}
+
+	if wait := lim.allow("192.0.2.1", now.Add(time.Second)); wait != 0 {
+		t.Errorf("request 63, a second later, was held for %v",
+			wait)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestProtect(t *testing.T) {
+	creds := &credentials{Tokens: []string{"s3cret"}}
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	handler := protect(next, creds, newLimiter(2), healthzPath)
+
+	for i, tc := range []struct {
+		path   string
+		token  string
+		remote string
+		want   int
+	}{
+		{"/v1/snapshot", "s3cret", "192.0.2.1:1000", http.StatusOK},
+		{"/v1/snapshot", "", "192.0.2.1:1001", http.StatusUnauthorized},
+		{"/v1/snapshot", "s3cret", "192.0.2.1:1002", http.StatusTooManyRequests},
+		{"/v1/snapshot", "s3cret", "192.0.2.2:1000", http.StatusOK},
+		{healthzPath, "", "192.0.2.1:1003", http.StatusOK},
+		{healthzPath, "", "192.0.2.1:1004", http.StatusOK},
+	} {
+		r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		r.RemoteAddr = tc.remote
+
+		if tc.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tc.want {
+			t.Errorf("request %d, for %s from %s: status %d, want %d",
+				i+1, tc.path, tc.remote, w.Code, tc.want)
+		}
+
+		switch w.Code {
+		case http.StatusUnauthorized:
+			if got := w.Header().Get("WWW-Authenticate"); got != `Bearer realm="go-what"` {
+				t.Errorf("request %d: WWW-Authenticate %q",
+					i+1, got)
+			}
+
+		case http.StatusTooManyRequests:
+			if got := w.Header().Get("Retry-After"); got != "30" {
+				t.Errorf("request %d: Retry-After %q, want 30",
+					i+1, got)
+			}
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
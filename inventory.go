@@ -0,0 +1,399 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - inventory.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: d6ac1ae0-c7a5-11f1-821b-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// inventoryHost is a host of an Ansible inventory, with the connection variables that ssh
+// understands.  Addr, User, and Port are "" unless the inventory sets ansible_host,
+// ansible_user, or ansible_port.
+type inventoryHost struct {
+	Name string
+	Addr string
+	User string
+	Port string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// SSHArgs returns the ssh options that connect to h as Ansible would, and the host to connect to.
+func (h inventoryHost) SSHArgs() ([]string, string) {
+	var args []string
+
+	if h.User != "" {
+		args = append(args, "-l", h.User)
+	}
+
+	if h.Port != "" {
+		args = append(args, "-p", h.Port)
+	}
+
+	if h.Addr != "" {
+		return args, h.Addr
+	}
+
+	return args, h.Name
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// inventory is an Ansible inventory: its hosts in order of appearance, and its groups by name,
+// each with its own hosts and child groups.
+type inventory struct {
+	Hosts  []inventoryHost
+	Groups map[string]*inventoryGroup
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// inventoryGroup is a group of an inventory, by the names of its hosts and child groups.
+type inventoryGroup struct {
+	Hosts    []string
+	Children []string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// loadInventory reads the Ansible inventory at name: dynamic inventory JSON on the standard input
+// for "-", the output of name --list for an executable (a dynamic inventory script), and
+// otherwise a JSON or INI file, whichever the contents are.  YAML inventories are not read.
+func loadInventory(name string) (*inventory, error) {
+	var data []byte
+
+	var err error
+
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else if fi, statErr := os.Stat(name); statErr == nil && fi.Mode().IsRegular() &&
+		fi.Mode()&0o111 != 0 {
+		data, err = exec.Command(name, "--list").Output()
+	} else {
+		data, err = os.ReadFile(name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if trimmed := bytes.TrimSpace(data); name == "-" || bytes.HasPrefix(trimmed, []byte("{")) {
+		return parseJSONInventory(trimmed)
+	}
+
+	return parseINIInventory(bytes.NewReader(data))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// group returns the group called name, making it if need be.
+func (inv *inventory) group(name string) *inventoryGroup {
+	if inv.Groups[name] == nil {
+		inv.Groups[name] = &inventoryGroup{}
+	}
+
+	return inv.Groups[name]
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// addHost records h as a host of group, and its variables if it has any new ones.
+func (inv *inventory) addHost(group string, h inventoryHost) {
+	i := slices.IndexFunc(inv.Hosts, func(e inventoryHost) bool { return e.Name == h.Name })
+	if i < 0 {
+		inv.Hosts = append(inv.Hosts, h)
+	} else {
+		e := &inv.Hosts[i]
+		e.Addr, e.User, e.Port = cmp.Or(h.Addr, e.Addr), cmp.Or(h.User, e.User),
+			cmp.Or(h.Port, e.Port)
+	}
+
+	if g := inv.group(group); !slices.Contains(g.Hosts, h.Name) {
+		g.Hosts = append(g.Hosts, h.Name)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// setVar applies an inventory variable to h, ignoring all but the connection variables.
+func (h *inventoryHost) setVar(key, value string) {
+	switch key {
+	case "ansible_host", "ansible_ssh_host":
+		h.Addr = value
+	case "ansible_user", "ansible_ssh_user":
+		h.User = value
+	case "ansible_port", "ansible_ssh_port":
+		h.Port = value
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// parseINIInventory reads an inventory in Ansible's INI format: hosts under [group] sections,
+// with key=value variables after them, and child groups under [group:children].  Hosts before
+// the first section are ungrouped; [group:vars] sections are skipped.  A numeric range such as
+// web[01:20] stands for each of the hosts in it.
+func parseINIInventory(r io.Reader) (*inventory, error) {
+	inv := &inventory{Groups: make(map[string]*inventoryGroup)}
+	section, kind := "ungrouped", ""
+
+	scanner := bufio.NewScanner(r)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			name, ok := strings.CutSuffix(line, "]")
+			if !ok {
+				return nil, fmt.Errorf("line %d: unterminated section %q",
+					n, line)
+			}
+
+			section, kind, _ = strings.Cut(name[1:], ":")
+			inv.group(section)
+
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		switch kind {
+		case "":
+			names, err := expandRange(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w",
+					n, err)
+			}
+
+			for _, name := range names {
+				h := inventoryHost{Name: name}
+
+				for _, field := range fields[1:] {
+					if key, value, ok := strings.Cut(field, "="); ok {
+						h.setVar(key, strings.Trim(value, `"'`))
+					}
+				}
+
+				inv.addHost(section, h)
+			}
+
+		case "children":
+			if g := inv.group(section); !slices.Contains(g.Children, fields[0]) {
+				g.Children = append(g.Children, fields[0])
+			}
+
+			inv.group(fields[0])
+		}
+	}
+
+	return inv, scanner.Err()
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// expandRange returns the host names of an inventory host pattern such as db[1:3] or
+// web[01:10].example.com, or just name if it has no range.
+func expandRange(name string) ([]string, error) {
+	open, end := strings.Index(name, "["), strings.Index(name, "]")
+	if open < 0 || end < open {
+		return []string{name}, nil
+	}
+
+	from, to, ok := strings.Cut(name[open+1:end], ":")
+	first, err1 := strconv.Atoi(from)
+	last, err2 := strconv.Atoi(to)
+
+	if !ok || err1 != nil || err2 != nil || first > last {
+		return nil, fmt.Errorf("unsupported host range in %q",
+			name)
+	}
+
+	var names []string
+
+	for i := first; i <= last; i++ {
+		names = append(names, fmt.Sprintf("%s%0*d%s",
+			name[:open], len(from), i, name[end+1:]))
+	}
+
+	return names, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// parseJSONInventory reads the output of a dynamic inventory's --list: groups, each either a
+// list of hosts or an object with hosts and children, and host variables under _meta.hostvars.
+func parseJSONInventory(data []byte) (*inventory, error) {
+	var doc map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("dynamic inventory: %w",
+			err)
+	}
+
+	var meta struct {
+		Hostvars map[string]map[string]any `json:"hostvars"`
+	}
+
+	if raw, ok := doc["_meta"]; ok {
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return nil, fmt.Errorf("dynamic inventory: _meta: %w",
+				err)
+		}
+	}
+
+	inv := &inventory{Groups: make(map[string]*inventoryGroup)}
+
+	// Groups in a stable order, since the JSON object has none.
+	names := make([]string, 0, len(doc))
+	for name := range doc {
+		if name != "_meta" {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		var group struct {
+			Hosts    []string `json:"hosts"`
+			Children []string `json:"children"`
+		}
+
+		if err := json.Unmarshal(doc[name], &group.Hosts); err != nil {
+			if err := json.Unmarshal(doc[name], &group); err != nil {
+				return nil, fmt.Errorf("dynamic inventory: group %s: %w",
+					name, err)
+			}
+		}
+
+		g := inv.group(name)
+		g.Children = append(g.Children, group.Children...)
+
+		for _, host := range group.Hosts {
+			h := inventoryHost{Name: host}
+
+			for key, value := range meta.Hostvars[host] {
+				h.setVar(key, fmt.Sprint(value))
+			}
+
+			inv.addHost(name, h)
+		}
+	}
+
+	return inv, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// groupHosts returns the hosts of the group called name and of its child groups, in order;
+// "all" is every host.
+func (inv *inventory) groupHosts(name string) []string {
+	if name == "all" {
+		names := make([]string, 0, len(inv.Hosts))
+		for _, h := range inv.Hosts {
+			names = append(names, h.Name)
+		}
+
+		return names
+	}
+
+	var names []string
+
+	seen := make(map[string]bool)
+
+	var walk func(name string)
+
+	walk = func(name string) {
+		g := inv.Groups[name]
+		if g == nil || seen[name] {
+			return
+		}
+
+		seen[name] = true
+		names = append(names, g.Hosts...)
+
+		for _, child := range g.Children {
+			walk(child)
+		}
+	}
+
+	walk(name)
+
+	return names
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Select returns the hosts named by patterns, as with ansible's host patterns: each names a
+// group, or matches host names with * and ?; a pattern starting with ! removes the hosts it
+// names instead.  No patterns select every host.
+func (inv *inventory) Select(patterns []string) []inventoryHost {
+	if len(patterns) == 0 {
+		patterns = []string{"all"}
+	}
+
+	picked := make(map[string]bool)
+
+	for _, pattern := range patterns {
+		pattern, exclude := strings.CutPrefix(pattern, "!")
+
+		names := inv.groupHosts(pattern)
+
+		if _, isGroup := inv.Groups[pattern]; !isGroup && pattern != "all" {
+			for _, h := range inv.Hosts {
+				if ok, _ := path.Match(pattern, h.Name); ok {
+					names = append(names, h.Name)
+				}
+			}
+		}
+
+		for _, name := range names {
+			picked[name] = !exclude
+		}
+	}
+
+	var hosts []inventoryHost
+
+	for _, h := range inv.Hosts {
+		if picked[h.Name] {
+			hosts = append(hosts, h)
+		}
+	}
+
+	return hosts
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
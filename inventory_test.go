@@ -0,0 +1,194 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - inventory_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: ed9c24c1-c7b1-11f1-a934-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// iniInventory is an INI inventory using each of the features parseINIInventory reads.
+const iniInventory = `
+bastion.example ansible_user=ops
+
+[web]
+web[01:03].example ansible_port=2222
+; the canary
+canary.example ansible_host=192.0.2.10 ansible_ssh_user="deploy"
+
+[db]
+db[1:2]
+
+[db:vars]
+ansible_user=postgres
+
+[prod:children]
+web
+db
+`
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// hostNames returns the names of hosts.
+func hostNames(hosts []inventoryHost) []string {
+	names := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		names = append(names, h.Name)
+	}
+
+	return names
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestParseINIInventory(t *testing.T) {
+	inv, err := parseINIInventory(strings.NewReader(iniInventory))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []inventoryHost{
+		{Name: "bastion.example", User: "ops"},
+		{Name: "web01.example", Port: "2222"},
+		{Name: "web02.example", Port: "2222"},
+		{Name: "web03.example", Port: "2222"},
+		{Name: "canary.example", Addr: "192.0.2.10", User: "deploy"},
+		{Name: "db1"},
+		{Name: "db2"},
+	}
+	if !slices.Equal(inv.Hosts, want) {
+		t.Errorf("hosts %+v, want %+v",
+			inv.Hosts, want)
+	}
+
+	testSelect(t, inv, []selectTest{
+		{nil, hostNames(want)},
+		{[]string{"ungrouped"}, []string{"bastion.example"}},
+		{[]string{"db"}, []string{"db1", "db2"}},
+		{[]string{"prod", "!canary.example"}, []string{
+			"web01.example", "web02.example", "web03.example", "db1", "db2",
+		}},
+		{[]string{"web0?.example", "db2"}, []string{
+			"web01.example", "web02.example", "web03.example", "db2",
+		}},
+		{[]string{"all", "!prod"}, []string{"bastion.example"}},
+		{[]string{"staging"}, nil},
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// selectTest is a case of Select: the hosts that patterns should select.
+type selectTest struct {
+	patterns []string
+	want     []string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// testSelect checks the hosts that inv selects in each of the tests.
+func testSelect(t *testing.T, inv *inventory, tests []selectTest) {
+	t.Helper()
+
+	for _, tc := range tests {
+		if got := hostNames(inv.Select(tc.patterns)); !slices.Equal(got, tc.want) {
+			t.Errorf("Select(%q) = %q, want %q",
+				tc.patterns, got, tc.want)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestParseJSONInventory(t *testing.T) {
+	inv, err := parseJSONInventory([]byte(`{
+		"web": {"hosts": ["web1", "web2"], "children": ["canaries"]},
+		"canaries": ["web3"],
+		"db": ["db1"],
+		"_meta": {"hostvars": {
+			"web1": {"ansible_host": "192.0.2.1", "ansible_port": 2222, "role": "frontend"},
+			"db1": {"ansible_user": "postgres"}
+		}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The groups are read in the order of their names.
+	want := []inventoryHost{
+		{Name: "web3"},
+		{Name: "db1", User: "postgres"},
+		{Name: "web1", Addr: "192.0.2.1", Port: "2222"},
+		{Name: "web2"},
+	}
+	if !slices.Equal(inv.Hosts, want) {
+		t.Errorf("hosts %+v, want %+v",
+			inv.Hosts, want)
+	}
+
+	testSelect(t, inv, []selectTest{
+		{[]string{"web"}, []string{"web3", "web1", "web2"}},
+		{[]string{"web", "!canaries"}, []string{"web1", "web2"}},
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestInventoryErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		ini  string
+		want string
+	}{
+		{"an unterminated section", "[web\nweb1\n", `line 1: unterminated section "[web"`},
+		{"a reversed range", "[web]\nweb[3:1]\n", `line 2: unsupported host range in "web[3:1]"`},
+		{"a letter range", "web[a:c]\n", `line 1: unsupported host range in "web[a:c]"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := parseINIInventory(strings.NewReader(tc.ini)); err == nil ||
+				err.Error() != tc.want {
+				t.Errorf("parseINIInventory = %v, want %q",
+					err, tc.want)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		json string
+		want string
+	}{
+		{`[`, "dynamic inventory: "},
+		{`{"web": 1}`, "dynamic inventory: group web: "},
+		{`{"_meta": []}`, "dynamic inventory: _meta: "},
+	} {
+		t.Run(tc.json, func(t *testing.T) {
+			if _, err := parseJSONInventory([]byte(tc.json)); err == nil ||
+				!strings.HasPrefix(err.Error(), tc.want) {
+				t.Errorf("parseJSONInventory = %v, want an error starting %q",
+					err, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
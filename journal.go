@@ -0,0 +1,211 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - journal.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 0143b175-c7a7-11f1-a142-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/johnsonjh/go-what/what"
+	"golang.org/x/sys/unix"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// journalSocket is where journald takes entries in its native protocol.
+const journalSocket = "/run/systemd/journal/socket"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// journalSink sends every event to journald as an entry of structured WHAT_* fields, so that
+// journalctl can select them (journalctl WHAT_USER=alice WHAT_EVENT=added, say).
+type journalSink struct {
+	conn *net.UnixConn
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// newJournalSink connects to journald.
+func newJournalSink() (*journalSink, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("journald: %w",
+			err)
+	}
+
+	return &journalSink{conn: conn}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// appendJournalField appends a field in the native protocol: KEY=value on a line, or, for a
+// value with newlines, KEY on a line followed by the value's length and the value itself.
+func appendJournalField(buf *bytes.Buffer, key, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(buf, "%s=%s\n",
+			key, value)
+
+		return
+	}
+
+	buf.WriteString(key + "\n")
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value + "\n")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// journalEntry encodes an event as a journal entry.  Fields that are unknown for the session
+// are left out.
+func journalEntry(event what.SessionEvent) []byte {
+	var buf bytes.Buffer
+
+	tty := event.TTY
+	user := username(tty.Stat.Uid)
+	command := sessionCommand(tty)
+
+	appendJournalField(&buf, "MESSAGE", fmt.Sprintf("%s %s %s: %s",
+		user, tty.Name, event.Type, command))
+	appendJournalField(&buf, "PRIORITY", "6")
+	appendJournalField(&buf, "SYSLOG_IDENTIFIER", "go-what")
+
+	fields := [][2]string{
+		{"WHAT_EVENT", event.Type.String()},
+		{"WHAT_USER", user},
+		{"WHAT_UID", strconv.Itoa(int(tty.Stat.Uid))},
+		{"WHAT_TTY", tty.Name},
+		{"WHAT_TTY_TYPE", tty.Type},
+		{"WHAT_COMMAND", command},
+		{"WHAT_ORIGIN", tty.Origin},
+		{"WHAT_FROM", tty.Host},
+		{"WHAT_LABEL", tty.Label},
+	}
+
+	if len(tty.Processes) > 0 && tty.Processes[0].PID != 0 {
+		fields = append(fields, [2]string{"WHAT_PID", strconv.Itoa(tty.Processes[0].PID)})
+	}
+
+	if event.Previous != nil {
+		fields = append(fields, [2]string{"WHAT_PREVIOUS_COMMAND", sessionCommand(event.Previous)})
+	}
+
+	for _, field := range fields {
+		if field[1] != "" {
+			appendJournalField(&buf, field[0], field[1])
+		}
+	}
+
+	return buf.Bytes()
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// alertEntry encodes an alert as a journal entry, at warning.
+func alertEntry(a alert) []byte {
+	var buf bytes.Buffer
+
+	appendJournalField(&buf, "MESSAGE", a.Message)
+	appendJournalField(&buf, "PRIORITY", "4")
+	appendJournalField(&buf, "SYSLOG_IDENTIFIER", "go-what")
+
+	fields := [][2]string{
+		{"WHAT_ALERT", a.Rule},
+		{"WHAT_USER", username(a.TTY.Stat.Uid)},
+		{"WHAT_UID", strconv.Itoa(int(a.TTY.Stat.Uid))},
+		{"WHAT_TTY", a.TTY.Name},
+		{"WHAT_FROM", a.TTY.Host},
+	}
+
+	for _, field := range fields {
+		if field[1] != "" {
+			appendJournalField(&buf, field[0], field[1])
+		}
+	}
+
+	return buf.Bytes()
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Send implements eventSink.
+func (j *journalSink) Send(event what.SessionEvent) error {
+	return j.write(journalEntry(event))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Alert implements eventSink.
+func (j *journalSink) Alert(a alert) error {
+	return j.write(alertEntry(a))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// write sends an entry.  One too large for a datagram is passed in a sealed memfd, as journald
+// allows.
+func (j *journalSink) write(entry []byte) error {
+	_, err := j.conn.Write(entry)
+	if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+		err = j.sendMemfd(entry)
+	}
+
+	if err != nil {
+		return fmt.Errorf("journald: %w",
+			err)
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sendMemfd passes entry to journald as the contents of a sealed memfd.
+func (j *journalSink) sendMemfd(entry []byte) error {
+	fd, err := unix.MemfdCreate("go-what-journal", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return err
+	}
+
+	f := os.NewFile(uintptr(fd), "memfd")
+	defer f.Close()
+
+	if _, err := f.Write(entry); err != nil {
+		return err
+	}
+
+	_, err = unix.FcntlInt(f.Fd(), unix.F_ADD_SEALS,
+		unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = j.conn.WriteMsgUnix(nil, unix.UnixRights(int(f.Fd())), nil)
+
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,145 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - journal_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 972ba547-c7b4-11f1-88e5-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestAppendJournalField(t *testing.T) {
+	for _, tc := range []struct {
+		key, value string
+		want       string
+	}{
+		{"PRIORITY", "6", "PRIORITY=6\n"},
+		{"WHAT_TTY", "", "WHAT_TTY=\n"},
+		{"WHAT_COMMAND", "a=b c", "WHAT_COMMAND=a=b c\n"},
+		// The length is a little-endian uint64, and the value is still followed by a newline.
+		{"MESSAGE", "one\ntwo", "MESSAGE\n\x07\x00\x00\x00\x00\x00\x00\x00one\ntwo\n"},
+		{"MESSAGE", "\n", "MESSAGE\n\x01\x00\x00\x00\x00\x00\x00\x00\n\n"},
+		{
+			"MESSAGE", strings.Repeat("x\n", 150),
+			"MESSAGE\n\x2c\x01\x00\x00\x00\x00\x00\x00" + strings.Repeat("x\n", 150) + "\n",
+		},
+	} {
+		var buf bytes.Buffer
+
+		appendJournalField(&buf, tc.key, tc.value)
+
+		if got := buf.String(); got != tc.want {
+			t.Errorf("appendJournalField(%q, %q) = %q, want %q",
+				tc.key, tc.value, got, tc.want)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestJournalEntry(t *testing.T) {
+	tty := remoteTTY("pts/1", 1000, "198.51.100.7")
+	tty.Type = "pty"
+	tty.Processes = []*what.Process{{PID: 4242, Cmdline: "sh\x00-c\x00make\nmake install\x00"}}
+
+	for _, tc := range []struct {
+		name  string
+		event what.SessionEvent
+		want  string
+	}{
+		{
+			"a multi-line command",
+			what.SessionEvent{Type: what.SessionAdded, TTY: tty},
+			"MESSAGE\n\x2a\x00\x00\x00\x00\x00\x00\x00" +
+				"alice pts/1 added: sh -c make\nmake install\n" +
+				"PRIORITY=6\nSYSLOG_IDENTIFIER=go-what\n" +
+				"WHAT_EVENT=added\nWHAT_USER=alice\nWHAT_UID=1000\nWHAT_TTY=pts/1\n" +
+				"WHAT_TTY_TYPE=pty\n" +
+				"WHAT_COMMAND\n\x17\x00\x00\x00\x00\x00\x00\x00sh -c make\nmake install\n" +
+				"WHAT_FROM=198.51.100.7\nWHAT_PID=4242\n",
+		},
+		{
+			// Unknown fields are left out, and the pid of a session without processes.
+			"an update",
+			what.SessionEvent{
+				Type: what.SessionUpdated, TTY: remoteTTY("tty1", 0, ""),
+				Previous: &what.TTY{Processes: []*what.Process{{Comm: "kworker"}}},
+			},
+			"MESSAGE=root tty1 updated: \nPRIORITY=6\nSYSLOG_IDENTIFIER=go-what\n" +
+				"WHAT_EVENT=updated\nWHAT_USER=root\nWHAT_UID=0\nWHAT_TTY=tty1\n" +
+				"WHAT_PREVIOUS_COMMAND=[kworker]\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(journalEntry(tc.event)); got != tc.want {
+				t.Errorf("got  %q\nwant %q",
+					got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestJournalSinkWrite(t *testing.T) {
+	addr := &net.UnixAddr{Name: filepath.Join(t.TempDir(), "socket"), Net: "unixgram"}
+
+	journald, err := net.ListenUnixgram("unixgram", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer journald.Close()
+
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sink := &journalSink{conn: conn}
+	defer conn.Close()
+
+	a := alert{Rule: "root-login", Message: "root logged in", TTY: remoteTTY("pts/2", 0, "")}
+	if err := sink.Alert(a); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 4096)
+
+	n, err := journald.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "MESSAGE=root logged in\nPRIORITY=4\nSYSLOG_IDENTIFIER=go-what\n" +
+		"WHAT_ALERT=root-login\nWHAT_USER=root\nWHAT_UID=0\nWHAT_TTY=pts/2\n"
+	if got := string(buf[:n]); got != want {
+		t.Errorf("got  %q\nwant %q",
+			got, want)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,291 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - json.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: f01b8683-c7a0-11f1-b46e-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+	"github.com/johnsonjh/go-what/whatjson"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// jsonSessions converts the collected terminals into whatjson sessions, one per foreground
+// process, in the same order as the table.  If sampled, every real process carries its
+// CPUPercent, even when it is zero; if the snapshot's output was measured, every session carries
+// its terminal's OutputRate.
+func jsonSessions(snap *what.Snapshot, sampled bool) []whatjson.Session {
+	sessions := []whatjson.Session{}
+
+	for _, tty := range snap.TTYs {
+		ttySessions := ttySessions(tty, sampled)
+
+		if snap.OutputInterval > 0 && !tty.Graphical {
+			for i := range ttySessions {
+				ttySessions[i].OutputRate = &tty.OutputRate
+			}
+		}
+
+		sessions = append(sessions, ttySessions...)
+	}
+
+	return sessions
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ttySessions converts one terminal's foreground processes into whatjson sessions.
+func ttySessions(tty *what.TTY, sampled bool) []whatjson.Session {
+	sessions := []whatjson.Session{}
+
+	name := ""
+	if len(tty.Processes) > 0 {
+		name = username(tty.Stat.Uid)
+	}
+
+	for _, p := range tty.Processes {
+		s := whatjson.Session{
+			User:         name,
+			UID:          tty.Stat.Uid,
+			TTY:          tty.Name,
+			Type:         tty.Type,
+			Login:        time.Unix(tty.Stat.Ctim.Unix()),
+			Input:        time.Unix(tty.Stat.Atim.Unix()),
+			Output:       time.Unix(tty.Stat.Mtim.Unix()),
+			Origin:       tty.Origin,
+			Host:         tty.Host,
+			Label:        tty.Label,
+			Automated:    tty.Automated,
+			Graphical:    tty.Graphical,
+			AtPrompt:     tty.AtPrompt,
+			LoginPrompt:  tty.LoginPrompt,
+			PID:          p.PID,
+			PPID:         p.PPID,
+			Command:      p.Command(),
+			CPUTime:      (p.CPU + p.ChildCPU).Seconds(),
+			Ancestry:     p.Ancestry,
+			Chroot:       p.Root,
+			Namespaces:   p.Namespaces,
+			AuditSession: p.AuditSession,
+			AuditUnknown: p.AuditUnknown,
+		}
+
+		if sampled && p.PID != 0 {
+			s.CPUPercent = &p.CPUPercent
+		}
+
+		if p.RealUID != p.EffectiveUID {
+			s.RealUID = &p.RealUID
+		}
+
+		if uid, ok := p.InsideUID(tty.Stat.Uid); ok {
+			s.UsernsUID = &uid
+		}
+
+		if a := tty.SSHAuth; a != nil {
+			s.SSHKey, s.SSHCertID, s.SSHCertSerial = a.Fingerprint, a.CertID, a.CertSerial
+			s.SSHAuthMethod = a.Method
+		}
+
+		if c := tty.Container; c != nil {
+			s.Container, s.Runtime = cmp.Or(c.ID, c.Name), c.Runtime
+			if c.ExecBy != nil {
+				s.ExecBy = username(c.ExecBy.UID)
+			}
+		}
+
+		if p.Cmdline != "" && !p.Partial {
+			s.Argv = p.Argv
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	return sessions
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// prettyJSON indents the JSON written to standard output, for people to read; otherwise each
+// document is on one line.
+var prettyJSON bool
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// newJSONEncoder returns an encoder onto w that indents as prettyJSON says.
+func newJSONEncoder(w io.Writer) *json.Encoder {
+	enc := json.NewEncoder(w)
+	if prettyJSON {
+		enc.SetIndent("", "  ")
+	}
+
+	return enc
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// jsonNoTTY converts the TTY-less process summary, using the same selection as the table.
+func jsonNoTTY(snap *what.Snapshot) []whatjson.NoTTY {
+	notty := []whatjson.NoTTY{}
+
+	for _, uid := range snap.NoTTYUIDs() {
+		notty = append(notty, whatjson.NoTTY{
+			User:      username(uid),
+			UID:       uid,
+			Processes: snap.NoTTY[uid],
+		})
+	}
+
+	return notty
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// jsonWarnings explains everything that makes the snapshot incomplete.
+func jsonWarnings(snap *what.Snapshot) []whatjson.Warning {
+	warnings := []whatjson.Warning{}
+
+	if snap.Hidepid != "" {
+		warnings = append(warnings, whatjson.Warning{
+			Kind:    whatjson.WarningHidepid,
+			Path:    "/proc",
+			Message: "/proc is mounted with hidepid=" + snap.Hidepid,
+		})
+	}
+
+	for _, err := range snap.Skipped {
+		w := whatjson.Warning{Kind: whatjson.WarningRead, Message: err.Error()}
+
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			w.Path = "/" + pathErr.Path
+		}
+
+		switch {
+		case errors.Is(err, fs.ErrPermission):
+			w.Kind = whatjson.WarningPermission
+
+		case errors.Is(err, what.ErrTruncated):
+			w.Kind = whatjson.WarningTruncated
+
+		case errors.Is(err, what.ErrMalformed):
+			w.Kind = whatjson.WarningParse
+		}
+
+		warnings = append(warnings, w)
+	}
+
+	return warnings
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// jsonSnapshot converts the whole snapshot into the document written by --format json.
+func jsonSnapshot(snap *what.Snapshot, sampled bool) whatjson.Snapshot {
+	doc := whatjson.Snapshot{
+		SchemaVersion: whatjson.SchemaVersion,
+		Sessions:      jsonSessions(snap, sampled),
+		NoTTY:         jsonNoTTY(snap),
+		Warnings:      jsonWarnings(snap),
+		Uptime:        snap.Uptime,
+		Users:         snap.Users,
+		Hostname:      snap.Hostname,
+		Time:          snap.Time,
+		Duration:      snap.Duration.Seconds(),
+	}
+
+	// The fourth field of loadavg is running/total processes.
+	if running, total, ok := strings.Cut(snap.Loadavg[3], "/"); ok {
+		doc.Running, _ = strconv.Atoi(running)
+		doc.Processes, _ = strconv.Atoi(total)
+	}
+
+	// Loadavg holds "?" for averages that could not be read.
+	for _, avg := range snap.Loadavg[:3] {
+		load, err := strconv.ParseFloat(avg, 64)
+		if err != nil {
+			doc.Load = nil
+
+			break
+		}
+
+		doc.Load = append(doc.Load, load)
+	}
+
+	return doc
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// printJSON writes the snapshot as a single whatjson.Snapshot document or, for NDJSON, as one
+// whatjson.Record per line.
+func printJSON(snap *what.Snapshot, ndjson, sampled bool) error {
+	enc := newJSONEncoder(os.Stdout)
+
+	if !ndjson {
+		return enc.Encode(jsonSnapshot(snap, sampled))
+	}
+
+	for _, s := range jsonSessions(snap, sampled) {
+		err := enc.Encode(whatjson.Record{
+			SchemaVersion: whatjson.SchemaVersion,
+			Kind:          whatjson.KindSession,
+			Session:       &s,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, n := range jsonNoTTY(snap) {
+		err := enc.Encode(whatjson.Record{
+			SchemaVersion: whatjson.SchemaVersion,
+			Kind:          whatjson.KindNoTTY,
+			NoTTY:         &n,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, w := range jsonWarnings(snap) {
+		err := enc.Encode(whatjson.Record{
+			SchemaVersion: whatjson.SchemaVersion,
+			Kind:          whatjson.KindWarning,
+			Warning:       &w,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -18,30 +18,28 @@ package main
 ///////////////////////////////////////////////////////////////////////////////////////////////////
 
 import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"encoding/gob"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
 	"os"
-	"os/user"
+	"os/signal"
 	"path/filepath"
 	"slices"
-	"sort"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
-	"golang.org/x/term"
+	"github.com/johnsonjh/go-what/what"
 )
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////
 
-type TTY struct {
-	Name      string
-	Stat      syscall.Stat_t
-	Processes []string
-}
-
-///////////////////////////////////////////////////////////////////////////////////////////////////
-
 func prettyTime(ts int64) string {
 	diff := time.Now().Unix() - ts
 	days := diff / (24 * 60 * 60)
@@ -77,211 +75,882 @@ func prettyTime(ts int64) string {
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////
 
-func getTermSize() (int, int) {
-	w, h, err := term.GetSize(int(os.Stdout.Fd()))
+// passwdFile, if set, is the passwd(5) file users are looked up in instead of the system's user
+// database, as for a host filesystem mounted elsewhere.
+var passwdFile string
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// numericIDs shows users by UID, never looking them up, for hosts where the lookups are slow.
+var numericIDs bool
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// hostPasswdFile is where the host's passwd file is found in a container that mounts the host's
+// root at /host, as monitoring agents' deployments commonly do.
+const hostPasswdFile = "/host/etc/passwd"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// defaultPasswdFile returns hostPasswdFile when running in a container that has it, or "" to use
+// the system's user database.
+func defaultPasswdFile() string {
+	_, docker := os.Stat("/.dockerenv")
+	_, podman := os.Stat("/run/.containerenv")
+
+	if docker != nil && podman != nil && os.Getenv("container") == "" {
+		return ""
+	}
+
+	if _, err := os.Stat(hostPasswdFile); err != nil {
+		return ""
+	}
+
+	return hostPasswdFile
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// passwdFields returns the entry for uid in passwdFile, split into its fields, or nil if it is
+// not there.
+func passwdFields(uid uint32) []string {
+	data, err := os.ReadFile(passwdFile)
 	if err != nil {
-		return 80, 24
+		return nil
 	}
 
-	return w, h
+	id := strconv.Itoa(int(uid))
+
+	for line := range strings.Lines(string(data)) {
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		if len(fields) > 2 && fields[2] == id {
+			return fields
+		}
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// passwdName returns the name of uid in passwdFile, or "" if it is not there.
+func passwdName(uid uint32) string {
+	if fields := passwdFields(uid); fields != nil {
+		return fields[0]
+	}
+
+	return ""
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// username resolves a UID, through userNames, falling back to the number itself.
+func username(uid uint32) string {
+	if numericIDs {
+		return strconv.Itoa(int(uid))
+	}
+
+	return cmp.Or(userNames.name(uid, lookupUser), strconv.Itoa(int(uid)))
 }
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////
 
 func main() {
-	ttys := make(map[uint64]*TTY)
-	ttyGlobs := []string{"/dev/tty*", "/dev/pts/*"}
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "failed":
+			os.Exit(failedMain(os.Args[2:]))
 
-	for _, glob := range ttyGlobs {
-		files, _ := filepath.Glob(glob)
-		for _, file := range files {
-			var stat syscall.Stat_t
+		case "wait":
+			os.Exit(waitMain(os.Args[2:]))
 
-			err := syscall.Stat(file, &stat)
-			if err != nil {
-				continue
-			}
+		case "motd":
+			os.Exit(motdMain(os.Args[2:]))
+
+		case "prompt":
+			os.Exit(promptMain(os.Args[2:]))
+
+		case "top":
+			os.Exit(topMain(os.Args[2:]))
 
-			ttys[stat.Rdev] = &TTY{Name: file[5:], Stat: stat}
+		case "agent":
+			os.Exit(agentMain(os.Args[2:]))
+
+		case "serve":
+			os.Exit(serveMain(os.Args[2:]))
 		}
 	}
 
-	notty := make(map[uint32]int)
-	uids := make(map[uint32]bool)
+	showVersion := flag.Bool("version", false,
+		"print the version, and the optional features built in, and exit")
+	format := flag.String("format", "table",
+		"output format: table, json, ndjson, or gob (binary); or, with --follow, cef or leef")
+	flag.BoolVar(&prettyJSON, "pretty", false,
+		"indent the JSON, for reading (with ndjson, each record then spans several lines)")
+	colorMode := flag.String("color", "auto",
+		"color and underline the table: auto (if standard output is a terminal), always, or never")
+	showOrigin := flag.Bool("origin", false,
+		"show the process holding each pty master (sshd, tmux, terminal emulator, ...)")
+	showContainer := flag.Bool("container", false,
+		"show the container (docker, podman, LXC, nspawn, ...) each session runs in")
+	showNamespaces := flag.Bool("namespaces", false,
+		"show the namespaces (m)nt, (n)et, (p)id, and (u)ser each session does not share with init")
+	showPID := flag.Bool("pid", false,
+		"show the PID of the foreground process (the lowest, with --pgrp collapse)")
+	showPPID := flag.Bool("ppid", false,
+		"show the parent PID of the foreground process")
+	showTime := flag.Bool("cpu-time", false,
+		"show the CPU time used by the foreground process, including its waited-for children")
+	sample := flag.Duration("sample", 0,
+		"read CPU times twice this far apart (e.g. 1s) and show each foreground process's %CPU")
+	sortBy := flag.String("sort", "input",
+		"order sessions by input (last input), user, tty, or cpu (busiest first, needs --sample)")
+	audit := flag.Bool("audit", false,
+		"show each session's audit session ID, with ! if the audit log has no record of it")
+	auditLog := flag.String("audit-log", what.AuditLogPath,
+		"the audit log --audit reads")
+	sshKey := flag.Bool("ssh-key", false,
+		"show the key fingerprint or certificate ID each ssh login authenticated with")
+	authMethod := flag.Bool("auth-method", false,
+		"show how each ssh login authenticated (publickey, password, ...), from sshd's log")
+	authLog := flag.String("auth-log", "",
+		"the log of sshd's messages to read (default auth.log or secure, or the journal)")
+	showGeo := flag.Bool("geo", false,
+		"show where each session's remote address is, from MaxMind DB files (see --geoip)")
+	geoPaths := flag.String("geoip", "",
+		"the .mmdb files --geo reads (comma-separated; default those in ~/.config/go-what/geoip)")
+	setUID := flag.Bool("setuid", false,
+		"flag sessions whose foreground process's effective UID is not its real UID, as real→eff")
+	fullNames := flag.Bool("full-names", false,
+		"follow each user name with the user's full name, from GECOS or --full-name-command")
+	flag.StringVar(&fullNameCommand, "full-name-command", "",
+		"a command printing the display name of the user named by its last argument (e.g. LDAP)")
+	idleRatio := flag.Bool("idle-ratio", false,
+		"show how much of each session's life it has been idle for (IDLE% of LOGIN)")
+	showRate := flag.Bool("rate", false,
+		"show how many bytes per second are written to each terminal (RATE), over --interval")
+	showType := flag.Bool("type", false,
+		"show what kind of terminal each session is on (TYPE): console, vt, pty, or serial")
+	showGroup := flag.Bool("primary-group", false,
+		"show the primary group of each session's user")
+	showProcs := flag.Bool("procs", false,
+		"show how many processes each session's user has on the host, with a terminal or not")
+	passwdPath := flag.String("passwd-file", "",
+		"look users up in this passwd file (default "+hostPasswdFile+" in a container with it)")
+	flag.BoolVar(&numericIDs, "n", false,
+		"show UIDs rather than user names, skipping the lookups (which -u then also takes)")
+	flag.BoolVar(&numericIDs, "numeric", false,
+		"the same as -n")
+	lastLogin := flag.Bool("last-login", false,
+		"show each user's previous login (time and origin), from wtmp")
+	commOnly := flag.Bool("comm", false,
+		"show only each process's executable name (as ps's comm), not its command line")
+	fullCommand := flag.Bool("full", false,
+		"show each command line whole, not cut off at the width of the terminal (as ps's args)")
+	stripPaths := flag.Bool("strip-paths", os.Getenv("GO_WHAT_STRIP_PATHS") != "",
+		"show commands without their directories (vim, not /usr/bin/vim); $GO_WHAT_STRIP_PATHS,"+
+			" if set, makes this the default")
+	fullPaths := flag.Bool("full-paths", false,
+		"keep commands' directories, overriding $GO_WHAT_STRIP_PATHS")
+	linkTemplate := flag.String("link", "",
+		"make each session a hyperlink to this URL, filling in {user}, {uid}, {tty}, {host}, and"+
+			" {hostname}; only to a terminal, unless --color=always")
+	showScripts := flag.Bool("scripts", false,
+		"for python, node, ruby, perl, and java, show the script (or jar) run, not the options")
+	showAncestry := flag.Bool("ancestry", false,
+		"show how the foreground process came to be (e.g. sshd→bash→make→cc1)")
+	sandboxed := flag.Bool("sandbox", true,
+		"once collected, confine go-what to the files left to read (Landlock) and deny it exec"+
+			" and other system calls it never makes (seccomp), where available")
+	privileged := flag.Bool("privileged", false,
+		"use CAP_SYS_PTRACE/CAP_DAC_READ_SEARCH, if permitted, to inspect other users' processes")
+	humansOnly := flag.Bool("humans-only", false,
+		"hide sessions spawned by automation (ansible, cloud-init, cron, expect, CI runners)")
+	allTTYs := flag.Bool("all-ttys", false,
+		"also list terminals sitting at a login prompt (getty), marked LOGIN")
+	idleShells := flag.Bool("idle-shells", false,
+		"also list terminals where a login shell is sitting at its prompt, as (shell)")
+	graphical := flag.Bool("graphical", true,
+		"list X11 and Wayland sessions, with their compositor or window manager as WHAT")
+	noNoTTY := flag.Bool("no-notty", false,
+		"leave out the summary of processes without a terminal (\"N more processes\")")
+	nottyAll := flag.Bool("notty-all", false,
+		"summarize every user's processes without a terminal, not just logged-in users' and root's")
+	nottyRoot := flag.String("notty-root", "always",
+		"when root is in that summary: always, session (only if logged in, like others), never")
+	nottyZero := flag.Bool("notty-zero", false,
+		"also list users in that summary who have no such processes, as \"0 more processes\"")
+	pgrp := flag.String("pgrp", "off",
+		"list the whole foreground process group: off, collapse (onto one line), or expand")
+	mergeDuplicates := flag.Bool("collapse", false,
+		"show a user's sessions running the same command as one row, counted (e.g. pts/3 ×14)")
+	fixedWidth := flag.Bool("fixed-width", false,
+		"keep table columns at fixed offsets, truncating values that do not fit")
+	debugErrors := flag.Bool("debug-errors", false,
+		"report everything that was skipped while collecting, and why")
+	strict := flag.Bool("strict", false,
+		"like --debug-errors, but exit with status 3 if anything was skipped")
+	verbose := flag.Bool("v", false,
+		"log collection decisions to standard error")
+	veryVerbose := flag.Bool("vv", false,
+		"like -v, but also log the decision made for every process")
+	timeout := flag.Duration("timeout", 0,
+		"give up if collection takes longer than this (e.g. 5s)")
+	users := flag.String("u", "",
+		"only report sessions of these users (comma-separated)")
+	queryText := flag.String("query", "",
+		"only report sessions matching this expression, e.g. 'user == \"alice\" && idle > 1h'")
+	groups := flag.String("group", "",
+		"only report sessions of users in these groups (comma-separated), e.g. wheel,sudo")
+	idleOver := flag.Duration("idle-over", 0,
+		"only report sessions that have had no input for longer than this (e.g. 8h)")
+	pids := flag.Bool("pids", false,
+		"print only the PIDs of the selected foreground processes, one per line")
+	nul := flag.Bool("0", false,
+		"with --pids, separate PIDs with NUL characters instead of newlines (for xargs -0)")
+	followMode := flag.Bool("follow", false,
+		"keep running, printing a timestamped line whenever a session starts, ends, or changes")
+	interval := flag.Duration("interval", 2*time.Second,
+		"time between collections with --follow, --collector, or --rate")
+	collectorMode := flag.Bool("collector", false,
+		"keep running, collecting every --interval, and serve the latest snapshot on --socket")
+	collectorSocket := flag.String("socket", defaultCollectorSocket,
+		"use the snapshot of the collector listening here, if it collects with the same options"+
+			" (\"\" to always collect)")
+	socketMode := flag.String("socket-mode", "0600",
+		"the permissions of the --collector socket; connecting takes write permission")
+	socketGroup := flag.String("socket-group", "",
+		"the group (name or GID) to give the --collector socket, for --socket-mode to apply to")
+	journal := flag.Bool("journal", false,
+		"with --follow, send the events to journald as structured WHAT_* fields, not stdout")
+	syslogTarget := flag.String("syslog", "",
+		"with --follow, send the events as RFC 5424 syslog to udp://, tcp://, tls://, or unix://")
+	syslogFacility := flag.String("syslog-facility", "auth",
+		"the facility of --syslog messages")
+	syslogSeverity := flag.String("syslog-severity", "info",
+		"the severity of --syslog messages")
+	syslogCA := flag.String("syslog-ca", "",
+		"the CA certificates (PEM) that a tls:// --syslog server is verified against")
+	alertConcurrent := flag.Bool("alert-concurrent", false,
+		"with --follow, alert when an account logs in from two source addresses at once")
+	hoursFile := flag.String("hours", "",
+		"with --follow, alert on interactive logins outside the business hours of this file")
+	webhook := flag.String("webhook", "",
+		"with --follow, POST every alert as JSON to this http:// or https:// URL")
+
+	agents := flag.String("agents", "",
+		"collect from these go-what agents (host:port, comma-separated) instead of this host")
+	discovered := flag.Bool("discover", false,
+		"also collect from the agents that answer an mDNS query on the local network")
+	sshHosts := flag.String("hosts", "",
+		"collect from these hosts over ssh (comma-separated patterns; see also --inventory)")
+	sshConfig := flag.String("ssh-config", sshConfigPath(),
+		"the ssh_config whose Host aliases --hosts patterns are matched against")
+	inventoryFile := flag.String("inventory", "",
+		"select --hosts from this Ansible inventory (INI, JSON, a script, or - for JSON on stdin)")
+	remoteCommand := flag.String("remote-command", "go-what",
+		"the command that runs go-what on the --hosts")
+	parallel := flag.Int("parallel", fleetParallel,
+		"with several hosts, how many to collect from at once")
+	connectTimeout := flag.Duration("connect-timeout", fleetConnectTimeout,
+		"with several hosts, how long connecting to one may take")
+	hostTimeout := flag.Duration("host-timeout", fleetHostTimeout,
+		"with several hosts, how long collecting from one may take before it is reported as failed")
+	grouped := flag.Bool("group-by-host", false,
+		"with several hosts, show each host's sessions under its uptime and load")
+	agentCert := flag.String("agent-cert", "",
+		"with --agents, the client certificate (PEM) to present to agents running with --mtls")
+	agentKey := flag.String("agent-key", "",
+		"the private key of --agent-cert (PEM)")
+	agentCA := flag.String("agent-ca", "",
+		"with --agents, the CA certificates (PEM) to verify agents by, instead of the system's")
+
+	flag.Parse()
+
+	if *showVersion {
+		printVersion(os.Stdout)
+
+		return
+	}
+
+	if *commOnly && *fullCommand {
+		fmt.Fprintf(os.Stderr, "go-what: --comm and --full are mutually exclusive\n")
+		os.Exit(2)
+	}
+
+	if *showGeo && !haveGeoIP {
+		fmt.Fprintf(os.Stderr, "go-what: --geo: not in this build (-tags minimal)\n")
+		os.Exit(2)
+	}
+
+	passwdFile = cmp.Or(*passwdPath, defaultPasswdFile())
+	if _, err := os.Stat(passwdFile); passwdFile != "" && err != nil {
+		fmt.Fprintf(os.Stderr, "go-what: --passwd-file: %v\n",
+			err)
+		os.Exit(2)
+	}
+
+	if !slices.Contains([]string{"table", "json", "ndjson", "gob", "cef", "leef"}, *format) {
+		fmt.Fprintf(os.Stderr, "go-what: unknown --format %q\n",
+			*format)
+		os.Exit(2)
+	}
+
+	if !slices.Contains([]string{"auto", "always", "never"}, *colorMode) {
+		fmt.Fprintf(os.Stderr, "go-what: unknown --color %q\n",
+			*colorMode)
+		os.Exit(2)
+	}
+
+	nottyRootModes := []string{"always", "session", "never"}
+	if !slices.Contains(nottyRootModes, *nottyRoot) {
+		fmt.Fprintf(os.Stderr, "go-what: unknown --notty-root %q\n",
+			*nottyRoot)
+		os.Exit(2)
+	}
+
+	if !slices.Contains([]string{"off", "collapse", "expand"}, *pgrp) {
+		fmt.Fprintf(os.Stderr, "go-what: unknown --pgrp %q\n",
+			*pgrp)
+		os.Exit(2)
+	}
+
+	if !slices.Contains(sortKeys, *sortBy) {
+		fmt.Fprintf(os.Stderr, "go-what: unknown --sort %q\n",
+			*sortBy)
+		os.Exit(2)
+	}
+
+	if *sortBy == "cpu" && *sample <= 0 {
+		fmt.Fprintf(os.Stderr, "go-what: --sort %s needs --sample\n",
+			*sortBy)
+		os.Exit(2)
+	}
+
+	if *followMode && (*format == "json" || *format == "ndjson" || *format == "gob") {
+		fmt.Fprintf(os.Stderr, "go-what: --follow does not support --format %s\n",
+			*format)
+		os.Exit(2)
+	}
+
+	if *followMode && *idleOver != 0 {
+		fmt.Fprintf(os.Stderr, "go-what: --follow does not support --idle-over, "+
+			"as idle time changes without an event\n")
+		os.Exit(2)
+	}
+
+	if !*followMode && (*format == "cef" || *format == "leef") {
+		fmt.Fprintf(os.Stderr, "go-what: --format %s needs --follow\n",
+			*format)
+		os.Exit(2)
+	}
 
-	procFiles, _ := os.ReadDir("/proc")
-	for _, f := range procFiles {
-		pid, err := strconv.Atoi(f.Name())
+	if *collectorMode && (*followMode || *collectorSocket == "") {
+		fmt.Fprintf(os.Stderr, "go-what: --collector needs a --socket, and not --follow\n")
+		os.Exit(2)
+	}
+
+	if *collectorMode && *interval <= 0 {
+		fmt.Fprintf(os.Stderr, "go-what: --collector needs a positive --interval\n")
+		os.Exit(2)
+	}
+
+	if (*journal || *syslogTarget != "") && !*followMode {
+		fmt.Fprintf(os.Stderr, "go-what: --journal and --syslog need --follow\n")
+		os.Exit(2)
+	}
+
+	if (*alertConcurrent || *hoursFile != "" || *webhook != "") && !*followMode {
+		fmt.Fprintf(os.Stderr,
+			"go-what: --alert-concurrent, --hours, and --webhook need --follow\n")
+		os.Exit(2)
+	}
+
+	multiHost := *agents != "" || *discovered || *sshHosts != "" || *inventoryFile != ""
+
+	if multiHost && (*followMode || *format == "ndjson" || *format == "gob") {
+		fmt.Fprintf(os.Stderr, "go-what: multi-host reports support only the table and json\n")
+		os.Exit(2)
+	}
+
+	// The hosts' snapshots are documents, without the user databases or processes these need.
+	if multiHost && (*users != "" || *groups != "" || *idleOver != 0 || *queryText != "" ||
+		*pids) {
+		fmt.Fprintf(os.Stderr, "go-what: -u, --group, --idle-over, --query, and --pids "+
+			"apply only to the local host, not multi-host reports\n")
+		os.Exit(2)
+	}
+
+	if *privileged {
+		err := raisePrivileges()
 		if err != nil {
-			continue
+			fmt.Fprintf(os.Stderr, "go-what: --privileged: %v\n",
+				err)
 		}
+	}
 
-		statPath := fmt.Sprintf("/proc/%d/stat",
-			pid)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		statContent, err := os.ReadFile(statPath) //nolint:gosec
-		if err != nil {
-			continue
+	var logger *slog.Logger
+
+	if *verbose || *veryVerbose {
+		level := slog.LevelDebug
+		if *veryVerbose {
+			level = what.LevelTrace
 		}
 
-		cmdlinePath := fmt.Sprintf("/proc/%d/cmdline",
-			pid)
+		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+			Level: level,
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.LevelKey && a.Value.Any() == what.LevelTrace {
+					a.Value = slog.StringValue("TRACE")
+				}
+
+				return a
+			},
+		}))
+	}
+
+	opts := what.Options{
+		Origin:       *showOrigin,
+		HumansOnly:   *humansOnly,
+		AllTTYs:      *allTTYs,
+		IdleShells:   *idleShells,
+		ProcessGroup: *pgrp != "off",
+		Graphical:    *graphical,
+		NoTTYAll:     *nottyAll,
+		NoTTYRoot:    what.NoTTYRoot(slices.Index(nottyRootModes, *nottyRoot)),
+		NoTTYZero:    *nottyZero,
+		Ancestry:     *showAncestry,
+		Sample:       *sample,
+		Output:       *showRate,
+		Logger:       logger,
+		Timeout:      *timeout,
+	}
 
-		cmdlineContent, err := os.ReadFile(cmdlinePath) //nolint:gosec
+	filter := sessionFilter{IdleOver: *idleOver, HideNoTTY: *noNoTTY}
+	if *users != "" {
+		filter.Users = strings.Split(*users, ",")
+	}
+
+	if *groups != "" {
+		filter.Groups = strings.Split(*groups, ",")
+	}
+
+	if *queryText != "" {
+		q, err := parseQuery(*queryText)
 		if err != nil {
-			continue
+			fmt.Fprintf(os.Stderr, "go-what: --query: %v\n",
+				err)
+			os.Exit(2)
+		}
+
+		filter.Query = q
+	}
+
+	if multiHost {
+		config := fleetConfig{
+			Discover:       *discovered,
+			CertFile:       *agentCert,
+			KeyFile:        *agentKey,
+			CAFile:         *agentCA,
+			SSHConfig:      *sshConfig,
+			Inventory:      *inventoryFile,
+			RemoteCommand:  *remoteCommand,
+			Parallel:       *parallel,
+			ConnectTimeout: *connectTimeout,
+			Timeout:        *hostTimeout,
+			Format:         *format,
+			Grouped:        *grouped,
+		}
+
+		if *agents != "" {
+			config.Agents = strings.Split(*agents, ",")
+		}
+
+		if *sshHosts != "" {
+			config.Hosts = strings.Split(*sshHosts, ",")
 		}
 
-		var procStat syscall.Stat_t
+		os.Exit(fleetMain(ctx, config))
+	}
+
+	if *collectorMode {
+		listener, err := listenUnix(*collectorSocket, *socketMode, *socketGroup)
+		if err == nil {
+			if *sandboxed {
+				if err := confineService(); err != nil {
+					fmt.Fprintf(os.Stderr, "go-what: --sandbox: %v\n",
+						err)
+				}
+			}
+
+			err = runCollector(ctx, listener, opts, *interval)
+		}
 
-		err = syscall.Stat(fmt.Sprintf("/proc/%d",
-			pid),
-			&procStat)
 		if err != nil {
-			continue
+			fmt.Fprintf(os.Stderr, "go-what: --collector: %v\n",
+				err)
+			os.Exit(1)
 		}
 
-		uids[procStat.Uid] = true
+		return
+	}
+
+	if *followMode {
+		var sinks []eventSink
+
+		if *journal {
+			sink, err := newJournalSink()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "go-what: --journal: %v\n",
+					err)
+				os.Exit(1)
+			}
 
-		i := strings.LastIndex(string(statContent), ")")
-		if i == -1 {
-			continue
+			sinks = append(sinks, sink)
 		}
 
-		parts := strings.Fields(string(statContent)[i+2:])
+		if *syslogTarget != "" {
+			config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+			if *syslogCA != "" {
+				pool, err := loadCertPool(*syslogCA)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "go-what: --syslog-ca: %v\n",
+						err)
+					os.Exit(1)
+				}
 
-		ttyNr, _ := strconv.ParseUint(parts[4], 10, 64)
-		tpgid, _ := strconv.Atoi(parts[5])
+				config.RootCAs = pool
+			}
 
-		if ttyNr == 0 || tpgid == -1 {
-			notty[procStat.Uid]++
+			sink, err := newSyslogSink(*syslogTarget, *syslogFacility, *syslogSeverity, config)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "go-what: --syslog: %v\n",
+					err)
+				os.Exit(1)
+			}
 
-			continue
+			sinks = append(sinks, sink)
 		}
 
-		cmdline := string(cmdlineContent)
-		if strings.HasPrefix(cmdline, "/sbin/getty") ||
-			strings.HasPrefix(cmdline, "/sbin/agetty") ||
-			strings.HasPrefix(cmdline, "tmux") ||
-			strings.HasPrefix(cmdline, "screen") ||
-			strings.HasPrefix(cmdline, "dtach") ||
-			strings.HasPrefix(cmdline, "-zsh") ||
-			strings.HasPrefix(cmdline, "-ksh") ||
-			strings.HasPrefix(cmdline, "-ksh93") ||
-			strings.HasPrefix(cmdline, "-sh") ||
-			strings.HasPrefix(cmdline, "-bash") ||
-			strings.HasPrefix(cmdline, "/sbin/mingetty") {
-			continue
+		if len(sinks) == 0 {
+			sinks = append(sinks, stdoutSink{Format: *format})
 		}
 
-		tty, ok := ttys[ttyNr]
-		if ok && tpgid == pid {
-			tty.Processes = append(tty.Processes, strings.ReplaceAll(cmdline, "\x00", " "))
+		// A webhook is sent only alerts, so the events still go to stdout without another sink.
+		if *webhook != "" {
+			sink, err := newWebhookSink(*webhook)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "go-what: --webhook: %v\n",
+					err)
+				os.Exit(2)
+			}
+
+			sinks = append(sinks, sink)
 		}
-	}
 
-	sortedTtys := make([]*TTY, 0, len(ttys))
+		var rules []alertRule
 
-	for _, tty := range ttys {
-		sortedTtys = append(sortedTtys, tty)
+		if *alertConcurrent {
+			rules = append(rules, newConcurrentLogins())
+		}
+
+		if *hoursFile != "" {
+			hours, err := loadBusinessHours(*hoursFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "go-what: --hours: %v\n",
+					err)
+				os.Exit(2)
+			}
+
+			rules = append(rules, hours)
+		}
+
+		if *sandboxed {
+			if err := confineService(); err != nil {
+				fmt.Fprintf(os.Stderr, "go-what: --sandbox: %v\n",
+					err)
+			}
+		}
+
+		err := follow(ctx, what.WatchOptions{Options: opts, Interval: *interval}, filter, sinks,
+			rules)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: %v\n",
+				err)
+			os.Exit(1)
+		}
+
+		return
 	}
 
-	sort.Slice(sortedTtys, func(i, j int) bool {
-		return sortedTtys[i].Stat.Atim.Sec < sortedTtys[j].Stat.Atim.Sec
-	})
+	var (
+		snap *what.Snapshot
+		err  error
+	)
+
+	if *collectorSocket != "" {
+		snap, err = fetchCollected(*collectorSocket, opts)
+		if err != nil && logger != nil {
+			logger.DebugContext(ctx, "collecting without the collector",
+				"socket", *collectorSocket, "err", err)
+		}
 
-	uptimeContent, _ := os.ReadFile("/proc/uptime")
-	uptimeParts := strings.Split(string(uptimeContent), " ")
-	uptime, _ := strconv.ParseFloat(uptimeParts[0], 64)
+		// A collector that has only collected once has no rates yet.
+		if snap != nil && *showRate && snap.OutputInterval == 0 {
+			snap = nil
+		}
+	}
 
-	loadavgContent, _ := os.ReadFile("/proc/loadavg")
-	loadavgParts := strings.Split(string(loadavgContent), " ")
+	if snap == nil {
+		snap, err = what.Collect(ctx, opts)
 
-	fmt.Printf(" up %s  %2d users  load %s %s %s  procs %s\n",
-		strings.TrimSpace(prettyTime(time.Now().Unix()-int64(uptime))), len(uids),
-		loadavgParts[0], loadavgParts[1], loadavgParts[2], loadavgParts[3])
+		// Without a collector, the rates are measured against a second collection.
+		if err == nil && *showRate {
+			first := snap
 
-	cols, _ := getTermSize()
+			select {
+			case <-time.After(*interval):
+			case <-ctx.Done():
+			}
 
-	fmt.Printf("% -8s %-7s %6s %6s %6s %s\n",
-		"USER", "TTY", "LOGIN", "\x1b[4mINPUT\x1b[0m", "OUTPUT", "WHAT")
+			snap, err = what.Collect(ctx, opts)
+			if err == nil {
+				snap.MeasureOutput(first)
+			}
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: %v\n",
+				err)
+			os.Exit(1) //nolint:gocritic
+		}
+	}
 
-	uidColors := make(map[uint32]int)
-	colors := []int{32, 33, 35, 36}
+	// The audit and sshd logs are commonly readable only by root or the adm group, so they are
+	// read before the capabilities go; they are matched to the sessions once those are filtered.
+	var trail what.AuditTrail
 
-	loggedInUids := make(map[uint32]bool)
+	if *audit {
+		trail, err = what.ReadAuditLog(*auditLog)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: --audit: %v\n",
+				err)
 
-	for _, tty := range sortedTtys {
-		if len(tty.Processes) > 0 {
-			loggedInUids[tty.Stat.Uid] = true
+			trail = nil
 		}
 	}
 
-	for _, tty := range sortedTtys {
-		if len(tty.Processes) == 0 {
-			continue
+	var auths []what.SSHAuth
+
+	if *sshKey || *authMethod {
+		auths, err = what.ReadSSHAuthLog(*authLog)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: sshd's log: %v\n",
+				err)
 		}
+	}
+
+	// Nothing after this needs the capabilities --privileged raised, or root's: the databases
+	// read from here on are read with the user's own permissions.
+	if err := dropPrivileges(0); err != nil {
+		fmt.Fprintf(os.Stderr, "go-what: %v\n",
+			err)
+		os.Exit(1)
+	}
+
+	// Landlock denies access to other processes' fd, ns, cwd, and root links, so the sandbox
+	// can only go up once they have been read; what is left is the user and group lookups and
+	// whatever the flags ask for.
+	if *sandboxed {
+		var extra []string
 
-		if _, ok := uidColors[tty.Stat.Uid]; !ok {
-			uidColors[tty.Stat.Uid] = len(uidColors) % len(colors)
+		if *lastLogin {
+			extra = append(extra, what.WtmpPath)
 		}
 
-		color := fmt.Sprintf("\x1b[%dm",
-			colors[uidColors[tty.Stat.Uid]])
+		if *showGeo {
+			extra = append(append(extra, geoConfigPath()), geoConfigPaths(geoConfigPath())...)
+			if *geoPaths != "" {
+				extra = append(extra, strings.Split(*geoPaths, ",")...)
+			}
+		}
 
-		u, err := user.LookupId(strconv.Itoa(int(tty.Stat.Uid)))
-		if err != nil || u == nil {
-			u = &user.User{Username: strconv.Itoa(int(tty.Stat.Uid))}
+		if passwdFile != "" {
+			extra = append(extra, passwdFile, filepath.Join(filepath.Dir(passwdFile), "group"))
 		}
 
-		for _, cmd := range tty.Processes {
-			line := fmt.Sprintf("% -8.8s %-7s %6s %6s %6s %s",
-				u.Username, tty.Name, prettyTime(tty.Stat.Ctim.Sec),
-				prettyTime(tty.Stat.Atim.Sec), prettyTime(tty.Stat.Mtim.Sec), cmd)
-			if len(line) > cols {
-				line = line[:cols]
+		// A --full-name-command helper inherits the Landlock domain, and what it runs and reads
+		// (an interpreter, its configuration, a directory service's tools) cannot be known
+		// here, so it is left to the seccomp filter alone.
+		if fullNameCommand == "" {
+			if err := sandbox(extra); err != nil && !errors.Is(err, errNoLandlock) {
+				fmt.Fprintf(os.Stderr, "go-what: --sandbox: %v\n",
+					err)
 			}
+		}
 
-			fmt.Println(color + line + "\x1b[0m")
+		err := denySyscalls(fullNameCommand != "")
+		if err != nil && !errors.Is(err, errNoSeccomp) {
+			fmt.Fprintf(os.Stderr, "go-what: --sandbox: %v\n",
+				err)
 		}
 	}
 
-	if _, ok := notty[0]; !ok {
-		notty[0] = 0
+	filter.apply(snap)
+
+	if trail != nil {
+		what.CorrelateAudit(snap, trail)
+	}
+
+	if *sshKey || *authMethod {
+		what.AttributeSSHAuth(snap, auths)
 	}
 
-	var nottyUids []uint32
+	if *sortBy != "input" {
+		sortSessions(snap, *sortBy)
+	}
 
-	for uid := range notty {
-		_, ok := loggedInUids[uid]
-		if ok || uid == 0 {
-			nottyUids = append(nottyUids, uid)
-		}
+	if snap.Hidepid != "" {
+		fmt.Fprintf(os.Stderr, "go-what: /proc is mounted with hidepid=%s; "+
+			"other users' sessions are taken from utmp/logind\n",
+			snap.Hidepid)
 	}
 
-	slices.Sort(nottyUids)
+	switch {
+	case *pids:
+		sep := "\n"
+		if *nul {
+			sep = "\x00"
+		}
 
-	for _, uid := range nottyUids {
-		count := notty[uid]
+		for _, tty := range snap.TTYs {
+			for _, p := range tty.Processes {
+				if p.PID != 0 {
+					fmt.Print(strconv.Itoa(p.PID) + sep)
+				}
+			}
+		}
 
-		u, err := user.LookupId(strconv.Itoa(int(uid)))
-		if err != nil || u == nil {
-			u = &user.User{Username: strconv.Itoa(int(uid))}
+	case *format == "gob":
+		err := gob.NewEncoder(os.Stdout).Encode(jsonSnapshot(snap, *sample > 0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: %v\n",
+				err)
+			os.Exit(1)
 		}
 
-		if u.Username == "root" && count == 0 {
-			continue
+	case *format == "json", *format == "ndjson":
+		err := printJSON(snap, *format == "ndjson", *sample > 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: %v\n",
+				err)
+			os.Exit(1)
 		}
 
-		processString := "processes"
+	default:
+		var wtmp []what.Utmp
+
+		if *lastLogin {
+			wtmp, err = what.ReadUtmp(what.WtmpPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "go-what: --last-login: %v\n",
+					err)
+			}
 
-		if count == 1 {
-			processString = "process"
+			// An unreadable wtmp still gets the column, so that every row reads "none".
+			if wtmp == nil {
+				wtmp = []what.Utmp{}
+			}
+		}
+
+		var procs map[uint32]int
+		if *showProcs {
+			procs = snap.Processes
+		}
+
+		var geo *geoDB
+		if *showGeo {
+			geo = newGeoDB(*geoPaths)
+		}
+
+		link := *linkTemplate
+		if !useHyperlinks(*colorMode) {
+			link = ""
+		}
+
+		width := outputWidth()
+		if *fullCommand {
+			width = 0
 		}
 
-		fmt.Printf("% -8.8s %-7s %d more %s\n",
-			u.Username, "none", count, processString)
+		printTable(os.Stdout, snap, tableOptions{
+			Origin:     *showOrigin,
+			Container:  *showContainer,
+			Namespaces: *showNamespaces,
+			Audit:      *audit,
+			SSHKey:     *sshKey,
+			AuthMethod: *authMethod,
+			SetUID:     *setUID,
+			Type:       *showType,
+			Group:      *showGroup,
+			IdleRatio:  *idleRatio,
+			Rate:       *showRate,
+			FullNames:  *fullNames,
+			Comm:       *commOnly,
+			Scripts:    *showScripts,
+			StripPaths: *stripPaths && !*fullPaths,
+			Link:       link,
+			Geo:        geo,
+			Procs:      procs,
+			PID:        *showPID,
+			PPID:       *showPPID,
+			Time:       *showTime,
+			Sample:     *sample > 0,
+			Sort:       *sortBy,
+			Wtmp:       wtmp,
+			Ancestry:   *showAncestry,
+			Color:      useColor(*colorMode),
+			Width:      width,
+			FixedWidth: *fixedWidth,
+			Collapse:   *pgrp == "collapse",
+
+			MergeDuplicates: *mergeDuplicates,
+		})
 	}
+
+	warnDenied(snap.Denied, *privileged)
+
+	if *debugErrors || *strict {
+		for _, err := range snap.Skipped {
+			fmt.Fprintf(os.Stderr, "go-what: skipped: %v\n",
+				err)
+		}
+	}
+
+	if *strict && len(snap.Skipped) > 0 {
+		os.Exit(3)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// warnDenied prints the one-line capability warning, if anything was denied.
+func warnDenied(d what.Denials, privileged bool) {
+	if d.Processes == 0 && d.Details == 0 {
+		return
+	}
+
+	hint := "run as root or see --privileged"
+	if privileged {
+		hint = "capabilities were not sufficient"
+	}
+
+	fmt.Fprintf(os.Stderr, "go-what: %d processes hidden, %d partially read (%s)\n",
+		d.Processes, d.Details, hint)
 }
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,117 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - main_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 5533f5a6-c7b1-11f1-befa-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"testing/fstest"
+
+	"golang.org/x/sys/unix"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// The users and groups of the tests, looked up in passwd and group files of their own rather
+// than the system's.
+const (
+	testPasswd = "root:x:0:0:root:/root:/bin/sh\n" +
+		"alice:x:1000:1000:Alice Liddell:/home/alice:/bin/bash\n" +
+		"bob:x:1001:1001:Bob:/home/bob:/bin/bash\n" +
+		"carol:x:1002:1002::/home/carol:/bin/bash\n"
+	testGroup = "root:x:0:\nalice:x:1000:\nbob:x:1001:\ncarol:x:1002:\n" +
+		"wheel:x:10:alice\nstaff:x:50:bob,carol\n"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "go-what-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	passwdFile = filepath.Join(dir, "passwd")
+
+	err = os.WriteFile(passwdFile, []byte(testPasswd), 0o644)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "group"), []byte(testGroup), 0o644)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fixtureSession is a session of a fixture host: a pty of uid, logged in at login (in seconds
+// since the epoch) from host, running the foreground command.
+type fixtureSession struct {
+	pts     int
+	uid     uint32
+	login   int64
+	command string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fixtureHost returns a host for what.Options.FS with the sessions, each with its foreground
+// command as a single process, PIDs from 100 on.
+func fixtureHost(sessions ...fixtureSession) fstest.MapFS {
+	fsys := fstest.MapFS{
+		"proc/sys/kernel/hostname": {Data: []byte("fixture\n")},
+	}
+
+	for i, s := range sessions {
+		rdev := unix.Mkdev(136, uint32(s.pts))
+		pid := 100 + i
+		dir := fmt.Sprintf("proc/%d",
+			pid)
+
+		fsys[fmt.Sprintf("dev/pts/%d", s.pts)] = &fstest.MapFile{
+			Mode: fs.ModeDevice | fs.ModeCharDevice,
+			Sys: &syscall.Stat_t{
+				Rdev: rdev, Uid: s.uid,
+				Ctim: syscall.Timespec{Sec: s.login}, Atim: syscall.Timespec{Sec: s.login},
+			},
+		}
+		fsys[dir] = &fstest.MapFile{Mode: fs.ModeDir | 0o555, Sys: &syscall.Stat_t{Uid: s.uid}}
+		fsys[dir+"/stat"] = &fstest.MapFile{Data: fmt.Appendf(nil,
+			"%d (%s) S 1 %d %d %d %d 0 0 0 0 0 0 0 0 0 20\n",
+			pid, s.command, pid, pid, rdev, pid)}
+		fsys[dir+"/cmdline"] = &fstest.MapFile{Data: []byte(s.command + "\x00")}
+	}
+
+	return fsys
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
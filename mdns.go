@@ -0,0 +1,514 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - mdns.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 38f4b406-c7a5-11f1-8c28-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// mdnsService is the DNS-SD service type agents advertise themselves under.
+const mdnsService = "_go-what._tcp.local."
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// discoverWait is how long --discover listens for agents to answer.
+const discoverWait = 2 * time.Second
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// mdnsGroup is the IPv4 mDNS multicast group and port.
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// DNS record types and class used by DNS-SD.
+const (
+	dnsTypeA   = 1
+	dnsTypePTR = 12
+	dnsTypeTXT = 16
+	dnsTypeSRV = 33
+	dnsTypeANY = 255
+	dnsClassIN = 1
+
+	// dnsCacheFlush marks a record as the only one of its name and type, as mDNS requires of
+	// SRV, TXT, and A records.
+	dnsCacheFlush = 0x8000
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// errDNSMalformed reports a DNS message that could not be parsed.
+var errDNSMalformed = errors.New("malformed DNS message")
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// dnsQuestion is one question of a DNS message.
+type dnsQuestion struct {
+	Name string
+	Type uint16
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// dnsRecord is one resource record of the types DNS-SD uses.  Target is set for PTR and SRV
+// records, Port for SRV, Addr for A, and Text for TXT.
+type dnsRecord struct {
+	Name   string
+	Type   uint16
+	Class  uint16
+	TTL    uint32
+	Target string
+	Port   uint16
+	Addr   netip.Addr
+	Text   []string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// dnsMessage is the subset of a DNS message that service discovery needs; the answer and
+// additional sections are read into Records together, and written as answers.
+type dnsMessage struct {
+	ID        uint16
+	Response  bool
+	Questions []dnsQuestion
+	Records   []dnsRecord
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// appendName appends name in uncompressed wire format.
+func appendName(b []byte, name string) []byte {
+	for label := range strings.SplitSeq(strings.TrimSuffix(name, "."), ".") {
+		if label == "" || len(label) > 63 {
+			continue
+		}
+
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+
+	return append(b, 0)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// pack encodes the message.  Names are not compressed.
+func (m *dnsMessage) pack() []byte {
+	var flags uint16
+	if m.Response {
+		flags = 0x8400 // a response, and authoritative, as mDNS responses always are
+	}
+
+	b := binary.BigEndian.AppendUint16(nil, m.ID)
+	b = binary.BigEndian.AppendUint16(b, flags)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(m.Questions)))
+	b = binary.BigEndian.AppendUint16(b, uint16(len(m.Records)))
+	b = binary.BigEndian.AppendUint32(b, 0)
+
+	for _, q := range m.Questions {
+		b = appendName(b, q.Name)
+		b = binary.BigEndian.AppendUint16(b, q.Type)
+		b = binary.BigEndian.AppendUint16(b, dnsClassIN)
+	}
+
+	for _, r := range m.Records {
+		b = appendName(b, r.Name)
+		b = binary.BigEndian.AppendUint16(b, r.Type)
+		b = binary.BigEndian.AppendUint16(b, r.Class)
+		b = binary.BigEndian.AppendUint32(b, r.TTL)
+
+		var data []byte
+
+		switch r.Type {
+		case dnsTypePTR:
+			data = appendName(nil, r.Target)
+
+		case dnsTypeSRV:
+			data = binary.BigEndian.AppendUint32(nil, 0) // priority and weight
+			data = binary.BigEndian.AppendUint16(data, r.Port)
+			data = appendName(data, r.Target)
+
+		case dnsTypeA:
+			data = r.Addr.AsSlice()
+
+		case dnsTypeTXT:
+			for _, text := range r.Text {
+				data = append(data, byte(len(text)))
+				data = append(data, text...)
+			}
+		}
+
+		b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+		b = append(b, data...)
+	}
+
+	return b
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readName decodes the possibly compressed name at off, returning it and the offset just past
+// it in the message (not past any name a pointer led to).
+func readName(b []byte, off int) (string, int, error) {
+	var labels []string
+
+	end := -1
+
+	for jumps := 0; ; {
+		if off >= len(b) {
+			return "", 0, errDNSMalformed
+		}
+
+		n := int(b[off])
+
+		switch {
+		case n == 0:
+			if end == -1 {
+				end = off + 1
+			}
+
+			return strings.Join(labels, ".") + ".", end, nil
+
+		case n&0xc0 == 0xc0:
+			if off+1 >= len(b) || jumps > 16 {
+				return "", 0, errDNSMalformed
+			}
+
+			if end == -1 {
+				end = off + 2
+			}
+
+			off = int(binary.BigEndian.Uint16(b[off:]) & 0x3fff)
+			jumps++
+
+		default:
+			if off+1+n > len(b) {
+				return "", 0, errDNSMalformed
+			}
+
+			labels = append(labels, string(b[off+1:off+1+n]))
+			off += 1 + n
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// parseDNS decodes a DNS message, skipping records of types it does not know.
+func parseDNS(b []byte) (*dnsMessage, error) {
+	if len(b) < 12 {
+		return nil, errDNSMalformed
+	}
+
+	m := &dnsMessage{
+		ID:       binary.BigEndian.Uint16(b),
+		Response: b[2]&0x80 != 0,
+	}
+
+	questions := int(binary.BigEndian.Uint16(b[4:]))
+	records := int(binary.BigEndian.Uint16(b[6:])) + int(binary.BigEndian.Uint16(b[8:])) +
+		int(binary.BigEndian.Uint16(b[10:]))
+
+	off := 12
+
+	for range questions {
+		name, next, err := readName(b, off)
+		if err != nil || next+4 > len(b) {
+			return nil, errDNSMalformed
+		}
+
+		m.Questions = append(m.Questions, dnsQuestion{
+			Name: name, Type: binary.BigEndian.Uint16(b[next:]),
+		})
+		off = next + 4
+	}
+
+	for range records {
+		name, next, err := readName(b, off)
+		if err != nil || next+10 > len(b) {
+			return nil, errDNSMalformed
+		}
+
+		r := dnsRecord{
+			Name:  name,
+			Type:  binary.BigEndian.Uint16(b[next:]),
+			Class: binary.BigEndian.Uint16(b[next+2:]),
+			TTL:   binary.BigEndian.Uint32(b[next+4:]),
+		}
+
+		length := int(binary.BigEndian.Uint16(b[next+8:]))
+
+		start := next + 10
+		if start+length > len(b) {
+			return nil, errDNSMalformed
+		}
+
+		data := b[start : start+length]
+		off = start + length
+
+		switch r.Type {
+		case dnsTypePTR:
+			r.Target, _, err = readName(b, start)
+
+		case dnsTypeSRV:
+			if length < 7 {
+				return nil, errDNSMalformed
+			}
+
+			r.Port = binary.BigEndian.Uint16(data[4:])
+			r.Target, _, err = readName(b, start+6)
+
+		case dnsTypeA:
+			var ok bool
+			if r.Addr, ok = netip.AddrFromSlice(data); !ok || length != 4 {
+				return nil, errDNSMalformed
+			}
+
+		case dnsTypeTXT:
+			for len(data) > 0 && int(data[0]) < len(data) {
+				r.Text = append(r.Text, string(data[1:1+data[0]]))
+				data = data[1+data[0]:]
+			}
+
+		default:
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		m.Records = append(m.Records, r)
+	}
+
+	return m, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// localIPv4 returns the host's IPv4 addresses to advertise, leaving out loopback unless there
+// is nothing else.
+func localIPv4() []netip.Addr {
+	var addrs, loopback []netip.Addr
+
+	ifaddrs, _ := net.InterfaceAddrs()
+
+	for _, ifaddr := range ifaddrs {
+		prefix, err := netip.ParsePrefix(ifaddr.String())
+		if err != nil || !prefix.Addr().Is4() {
+			continue
+		}
+
+		if prefix.Addr().IsLoopback() {
+			loopback = append(loopback, prefix.Addr())
+		} else {
+			addrs = append(addrs, prefix.Addr())
+		}
+	}
+
+	if len(addrs) == 0 {
+		return loopback
+	}
+
+	return addrs
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// advertise answers mDNS queries for mdnsService with this host's agent on port, until ctx is
+// done.  The TXT record carries serverName, if set, as the name the agent's certificate is
+// valid for.  Queries from port 5353 are answered to the group; others, as legacy unicast, to
+// the sender alone.
+func advertise(ctx context.Context, port int, serverName string) error {
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	hostname, _ := os.Hostname()
+	hostname, _, _ = strings.Cut(cmp.Or(hostname, "localhost"), ".")
+
+	instance := hostname + "." + mdnsService
+	target := hostname + ".local."
+
+	text := []string{"path=" + agentSnapshotPath}
+	if serverName != "" {
+		text = append(text, "server-name="+serverName)
+	}
+
+	records := []dnsRecord{
+		{Name: mdnsService, Type: dnsTypePTR, Class: dnsClassIN, TTL: 4500, Target: instance},
+		{
+			Name: instance, Type: dnsTypeSRV, Class: dnsClassIN | dnsCacheFlush, TTL: 120,
+			Target: target, Port: uint16(port),
+		},
+		{
+			Name: instance, Type: dnsTypeTXT, Class: dnsClassIN | dnsCacheFlush, TTL: 4500,
+			Text: text,
+		},
+	}
+
+	for _, addr := range localIPv4() {
+		records = append(records, dnsRecord{
+			Name: target, Type: dnsTypeA, Class: dnsClassIN | dnsCacheFlush, TTL: 120,
+			Addr: addr,
+		})
+	}
+
+	buf := make([]byte, 9000)
+
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		query, err := parseDNS(buf[:n])
+		if err != nil || query.Response {
+			continue
+		}
+
+		wanted := slices.ContainsFunc(query.Questions, func(q dnsQuestion) bool {
+			return strings.EqualFold(q.Name, mdnsService) &&
+				(q.Type == dnsTypePTR || q.Type == dnsTypeANY)
+		})
+		if !wanted {
+			continue
+		}
+
+		reply := &dnsMessage{Response: true, Records: records}
+		to := mdnsGroup
+
+		if from.Port != mdnsGroup.Port {
+			reply.ID, reply.Questions, to = query.ID, query.Questions, from
+		}
+
+		_, _ = conn.WriteToUDP(reply.pack(), to)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// discoveredAgent is an agent that answered --discover: Name (host and port) is what its
+// certificate is checked against, and Addr the IP address and port to connect to.
+type discoveredAgent struct {
+	Name string
+	Addr string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// discover asks the local network for agents, collecting answers for wait.  An agent is named
+// by the server-name it advertised, or else its mDNS host name.
+func discover(ctx context.Context, wait time.Duration) ([]discoveredAgent, error) {
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	query := &dnsMessage{
+		ID:        uint16(time.Now().UnixNano()) | 1,
+		Questions: []dnsQuestion{{Name: mdnsService, Type: dnsTypePTR}},
+	}
+
+	if _, err := conn.WriteToUDP(query.pack(), mdnsGroup); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(wait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	_ = conn.SetReadDeadline(deadline)
+
+	services := make(map[string]dnsRecord) // SRV records, by instance
+	names := make(map[string]string)       // advertised server names, by instance
+	addrs := make(map[string]netip.Addr)   // A records, by host name
+
+	buf := make([]byte, 9000)
+
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			break
+		}
+
+		reply, err := parseDNS(buf[:n])
+		if err != nil || !reply.Response {
+			continue
+		}
+
+		for _, r := range reply.Records {
+			switch r.Type {
+			case dnsTypeSRV:
+				services[strings.ToLower(r.Name)] = r
+
+			case dnsTypeTXT:
+				for _, text := range r.Text {
+					if name, ok := strings.CutPrefix(text, "server-name="); ok {
+						names[strings.ToLower(r.Name)] = name
+					}
+				}
+
+			case dnsTypeA:
+				addrs[strings.ToLower(r.Name)] = r.Addr
+			}
+		}
+	}
+
+	var agents []discoveredAgent
+
+	for instance, srv := range services {
+		port := strconv.Itoa(int(srv.Port))
+		host := strings.TrimSuffix(srv.Target, ".")
+
+		agent := discoveredAgent{
+			Name: net.JoinHostPort(cmp.Or(names[instance], host), port),
+			Addr: net.JoinHostPort(host, port),
+		}
+
+		if addr, ok := addrs[strings.ToLower(srv.Target)]; ok {
+			agent.Addr = net.JoinHostPort(addr.String(), port)
+		}
+
+		agents = append(agents, agent)
+	}
+
+	slices.SortFunc(agents, func(a, b discoveredAgent) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return agents, nil
+}
@@ -0,0 +1,158 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - mdns_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 0b93174e-c7b2-11f1-91c5-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestDNSRoundTrip(t *testing.T) {
+	instance := "host1." + mdnsService
+
+	for _, m := range []*dnsMessage{
+		{Questions: []dnsQuestion{{Name: mdnsService, Type: dnsTypePTR}}},
+		{
+			Response: true,
+			Records: []dnsRecord{
+				{
+					Name: mdnsService, Type: dnsTypePTR, Class: dnsClassIN, TTL: 120,
+					Target: instance,
+				},
+				{
+					Name: instance, Type: dnsTypeSRV, Class: dnsClassIN | dnsCacheFlush, TTL: 120,
+					Target: "host1.local.", Port: 7070,
+				},
+				{
+					Name: instance, Type: dnsTypeTXT, Class: dnsClassIN | dnsCacheFlush, TTL: 4500,
+					Text: []string{"txtvers=1", "name=host1.example", ""},
+				},
+				{
+					Name: "host1.local.", Type: dnsTypeA, Class: dnsClassIN | dnsCacheFlush,
+					TTL: 120, Addr: netip.MustParseAddr("192.0.2.7"),
+				},
+			},
+		},
+	} {
+		got, err := parseDNS(m.pack())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(got, m) {
+			t.Errorf("parseDNS(pack()) = %+v, want %+v",
+				got, m)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// dnsHeader returns the header of a message with the given numbers of questions, answers,
+// authority records, and additional records.
+func dnsHeader(counts ...byte) []byte {
+	b := []byte{0x12, 0x34, 0x84, 0x00}
+	for _, n := range counts {
+		b = append(b, 0, n)
+	}
+
+	return b
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestParseDNS(t *testing.T) {
+	// An answer whose name points back to the question's, and then a record of a type parseDNS
+	// does not know (AAAA), skipped, and a PTR whose target is a label before a pointer.
+	b := dnsHeader(1, 3, 0, 0)
+	b = append(b, 5, 'h', 'o', 's', 't', '1', 5, 'l', 'o', 'c', 'a', 'l', 0, 0, dnsTypeA, 0, 1)
+	b = append(b, 0xc0, 12, 0, dnsTypeA, 0, 1, 0, 0, 0, 120, 0, 4, 192, 0, 2, 7)
+	b = append(b, 0xc0, 12, 0, 28, 0, 1, 0, 0, 0, 120, 0, 16)
+	b = append(b, make([]byte, 16)...)
+	b = append(b, 0xc0, 18, 0, dnsTypePTR, 0, 1, 0, 0, 0, 120, 0, 5, 3, 'w', 'w', 'w', 0xc0, 12)
+
+	got, err := parseDNS(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &dnsMessage{
+		ID:        0x1234,
+		Response:  true,
+		Questions: []dnsQuestion{{Name: "host1.local.", Type: dnsTypeA}},
+		Records: []dnsRecord{
+			{
+				Name: "host1.local.", Type: dnsTypeA, Class: dnsClassIN, TTL: 120,
+				Addr: netip.MustParseAddr("192.0.2.7"),
+			},
+			{
+				Name: "local.", Type: dnsTypePTR, Class: dnsClassIN, TTL: 120,
+				Target: "www.host1.local.",
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDNS = %+v, want %+v",
+			got, want)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestParseDNSMalformed(t *testing.T) {
+	valid := (&dnsMessage{Records: []dnsRecord{{
+		Name: "host1.local.", Type: dnsTypeSRV, Class: dnsClassIN, Target: "host1.local.",
+		Port: 7070,
+	}}}).pack()
+
+	loop := append(dnsHeader(1, 0, 0, 0), 0xc0, 12, 0, dnsTypeA, 0, 1)
+
+	shortA := append(dnsHeader(0, 1, 0, 0), 0, 0, dnsTypeA, 0, 1, 0, 0, 0, 0, 0, 3, 192, 0, 2)
+
+	shortSRV := append(dnsHeader(0, 1, 0, 0), 0, 0, dnsTypeSRV, 0, 1, 0, 0, 0, 0, 0, 6)
+	shortSRV = append(shortSRV, 0, 0, 0, 0, 0x1b, 0x9e)
+
+	for _, tc := range []struct {
+		name string
+		b    []byte
+	}{
+		{"a short header", valid[:11]},
+		{"a truncated name", valid[:16]},
+		{"a truncated record", valid[:len(valid)-1]},
+		{"a missing record", dnsHeader(0, 2, 0, 0)},
+		{"a missing question", dnsHeader(1, 0, 0, 0)},
+		{"a pointer loop", loop},
+		{"a short address", shortA},
+		{"an SRV record without a target", shortSRV},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if m, err := parseDNS(tc.b); !errors.Is(err, errDNSMalformed) {
+				t.Errorf("parseDNS = %+v, %v, want errDNSMalformed",
+					m, err)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
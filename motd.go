@@ -0,0 +1,199 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - motd.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: edcfef4e-c7ab-11f1-8c4e-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+	"github.com/johnsonjh/go-what/whatjson"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// motdTimeout bounds the collection behind "go-what motd", which should not hold up a login.
+const motdTimeout = 5 * time.Second
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// motdOptions controls the summary "go-what motd" prints.
+type motdOptions struct {
+	Width int           // no line is longer than this, in runes
+	Idle  time.Duration // how long a session must have been idle to be listed
+	Max   int           // the most idle sessions listed; the rest are counted
+	Color bool
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// printMOTD writes a few lines for a message of the day: the uptime, load, and number of users
+// and sessions, then the sessions idle for longer than opts.Idle, the longest idle first.
+func printMOTD(w io.Writer, doc *whatjson.Snapshot, opts motdOptions) {
+	bold, dim, reset := "", "", ""
+	if opts.Color {
+		bold, dim, reset = "\x1b[1m", "\x1b[2m", "\x1b[0m"
+	}
+
+	line := func(color, s string) {
+		if runes := []rune(s); len(runes) > opts.Width {
+			s = string(runes[:opts.Width])
+		}
+
+		if color != "" {
+			s = color + s + reset
+		}
+
+		fmt.Fprintln(w, s)
+	}
+
+	now := time.Now()
+
+	// A terminal's foreground processes are each a session of the document.
+	var idle []whatjson.Session
+
+	terminals := make(map[string]bool)
+
+	for _, s := range doc.Sessions {
+		if terminals[s.TTY] {
+			continue
+		}
+
+		terminals[s.TTY] = true
+
+		if !s.Graphical && now.Sub(s.Input) >= opts.Idle {
+			idle = append(idle, s)
+		}
+	}
+
+	load := make([]string, len(doc.Load))
+	for i, l := range doc.Load {
+		load[i] = strconv.FormatFloat(l, 'f', 2, 64)
+	}
+
+	line(bold, fmt.Sprintf("up %s, %d users, %d sessions, load %s",
+		strings.TrimSpace(prettyTime(now.Unix()-int64(doc.Uptime))), doc.Users, len(terminals),
+		strings.Join(load, " ")))
+
+	if len(idle) == 0 {
+		return
+	}
+
+	slices.SortFunc(idle, func(a, b whatjson.Session) int {
+		return a.Input.Compare(b.Input)
+	})
+
+	line("", fmt.Sprintf("idle for over %s:",
+		strings.TrimSpace(prettyTime(now.Unix()-int64(opts.Idle/time.Second)))))
+
+	for _, s := range idle[:min(len(idle), opts.Max)] {
+		command := s.Command
+		if s.AtPrompt {
+			command = "(shell)"
+		}
+
+		line("", fmt.Sprintf("  %-8s %-7s %6s  %s",
+			s.User, s.TTY, prettyTime(s.Input.Unix()), command))
+	}
+
+	if more := len(idle) - opts.Max; more > 0 {
+		line(dim, fmt.Sprintf("  and %d more",
+			more))
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// localOrServed returns a snapshot for motd and prompt: from go-what serve, if socket is set,
+// or collected here, where a user sitting at a shell prompt is still logged in.
+func localOrServed(ctx context.Context, socket string) (*whatjson.Snapshot, error) {
+	if socket != "" {
+		return fetchSocket(ctx, socket)
+	}
+
+	snap, err := what.Collect(ctx, what.Options{IdleShells: true})
+	if err != nil {
+		return nil, err
+	}
+
+	doc := jsonSnapshot(snap, false)
+
+	return &doc, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// motdMain implements "go-what motd", a compact summary for /etc/update-motd.d scripts and the
+// like.
+func motdMain(args []string) int {
+	flags := flag.NewFlagSet("go-what motd", flag.ExitOnError)
+
+	width := flags.Int("width", 78,
+		"the longest a line may be; longer ones are cut off")
+	idle := flags.Duration("idle", time.Hour,
+		"list the sessions idle for longer than this")
+	maxIdle := flags.Int("max-idle", 5,
+		"list at most this many idle sessions, and count the rest")
+	colorMode := flags.String("color", "auto",
+		"highlight the summary: auto (if standard output is a terminal), always, or never")
+	socket := flags.String("socket", "",
+		"ask go-what serve, listening on this unix socket, rather than collecting")
+
+	_ = flags.Parse(args)
+
+	if !slices.Contains([]string{"auto", "always", "never"}, *colorMode) {
+		fmt.Fprintf(os.Stderr, "go-what: unknown --color %q\n",
+			*colorMode)
+
+		return 2
+	}
+
+	if *width < 1 || *maxIdle < 0 {
+		fmt.Fprintf(os.Stderr, "go-what: --width must be positive, and --max-idle not negative\n")
+
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), motdTimeout)
+	defer cancel()
+
+	doc, err := localOrServed(ctx, *socket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-what: %v\n",
+			err)
+
+		return 1
+	}
+
+	printMOTD(os.Stdout, doc, motdOptions{
+		Width: *width, Idle: *idle, Max: *maxIdle, Color: useColor(*colorMode),
+	})
+
+	return 0
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,103 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - motd_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: c0958f54-c7b4-11f1-9411-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/johnsonjh/go-what/whatjson"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// motdEscape matches the SGR sequences printMOTD colors lines with.
+var motdEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestPrintMOTD(t *testing.T) {
+	now := time.Now()
+	doc := &whatjson.Snapshot{
+		Uptime: 3*86400 + 3600, Users: 3, Load: []float64{0.5, 1.25, 2},
+		Sessions: []whatjson.Session{
+			{User: "alice", TTY: "pts/0", Input: now.Add(-2 * time.Hour), Command: "vim notes.txt"},
+			// The terminal's second foreground process is not another session.
+			{User: "alice", TTY: "pts/0", Input: now.Add(-2 * time.Hour), Command: "less"},
+			{User: "bob", TTY: "pts/1", Input: now.Add(-5 * time.Hour), AtPrompt: true},
+			{User: "carol", TTY: "pts/2", Input: now.Add(-time.Minute), Command: "top"},
+			{User: "carol", TTY: "tty2", Input: now.Add(-9 * time.Hour), Graphical: true},
+			{
+				User: "日本語のユーザー", TTY: "pts/3", Input: now.Add(-3 * time.Hour),
+				Command: "make -j8 all 2>&1 | tee ビルド.log",
+			},
+		},
+	}
+
+	for _, color := range []bool{false, true} {
+		for _, width := range []int{1, 12, 40, 78} {
+			var b strings.Builder
+
+			printMOTD(&b, doc, motdOptions{Width: width, Idle: time.Hour, Max: 2, Color: color})
+
+			out := b.String()
+			if strings.Contains(out, "\x1b") != color {
+				t.Errorf("color %v, width %d: escapes are wrong:\n%q",
+					color, width, out)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(motdEscape.ReplaceAllString(out, ""),
+				"\n"), "\n")
+			if len(lines) != 5 {
+				t.Errorf("color %v, width %d: %d lines, want 5:\n%s",
+					color, width, len(lines), out)
+			}
+
+			for _, line := range lines {
+				if n := utf8.RuneCountInString(line); n > width {
+					t.Errorf("color %v, width %d: %q has %d runes",
+						color, width, line, n)
+				}
+			}
+
+			if width < 78 {
+				continue
+			}
+
+			for i, want := range []string{
+				"up 3d01h, 3 users, 5 sessions, load 0.50 1.25 2.00",
+				"idle for over 1h00m:",
+				"  bob      pts/1    5h00m  (shell)",
+				"  日本語のユーザー pts/3    3h00m  make -j8 all 2>&1 | tee ビルド.log",
+				"  and 1 more",
+			} {
+				if i < len(lines) && lines[i] != want {
+					t.Errorf("color %v: line %d is %q, want %q",
+						color, i, lines[i], want)
+				}
+			}
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
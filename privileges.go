@@ -0,0 +1,134 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - privileges.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 7b691f05-c7a0-11f1-b897-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// inspectCaps are the capabilities that let an unprivileged go-what read the /proc entries of
+// other users' processes: CAP_SYS_PTRACE for fd, fdinfo, and hidepid-protected directories,
+// and CAP_DAC_READ_SEARCH for anything merely mode-restricted.
+const inspectCaps = 1<<unix.CAP_SYS_PTRACE | 1<<unix.CAP_DAC_READ_SEARCH
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// raisePrivileges moves CAP_SYS_PTRACE and CAP_DAC_READ_SEARCH from the permitted into the
+// effective set, e.g. after "setcap cap_sys_ptrace,cap_dac_read_search+p go-what".  The
+// capabilities are left dormant unless --privileged asks for them.
+func raisePrivileges() error {
+	hdr := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+
+	var data [2]unix.CapUserData
+
+	err := unix.Capget(&hdr, &data[0])
+	if err != nil {
+		return fmt.Errorf("capget: %w",
+			err)
+	}
+
+	if data[0].Permitted&inspectCaps == 0 {
+		return errors.New("neither CAP_SYS_PTRACE nor CAP_DAC_READ_SEARCH is permitted")
+	}
+
+	data[0].Effective |= data[0].Permitted & inspectCaps
+
+	// Capabilities are per-thread, and the Go runtime has already started several threads.
+	_, _, errno := syscall.AllThreadsSyscall(syscall.SYS_CAPSET,
+		uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&data[0])), 0) //nolint:gosec
+	if errno == 0 {
+		return nil
+	}
+
+	if !errors.Is(errno, syscall.ENOTSUP) {
+		return fmt.Errorf("capset: %w",
+			errno)
+	}
+
+	// AllThreadsSyscall is unavailable in cgo builds; all collection happens on the main
+	// goroutine, so pinning it to the one thread that holds the capabilities is sufficient.
+	runtime.LockOSThread()
+
+	err = unix.Capset(&hdr, &data[0])
+	if err != nil {
+		return fmt.Errorf("capset: %w",
+			err)
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// dropPrivileges gives up, for good, every capability but those in keep: a one-shot run keeps
+// none once it has collected, and the modes that go on collecting keep inspectCaps.  Running as
+// root, this drops the capabilities root has by right too.  Helpers started afterwards could
+// still regain them from the bounding set, which is why the seccomp filter denies exec.
+func dropPrivileges(keep uint64) error {
+	hdr := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+
+	var data [2]unix.CapUserData
+
+	err := unix.Capget(&hdr, &data[0])
+	if err != nil {
+		return fmt.Errorf("capget: %w",
+			err)
+	}
+
+	for i := range data {
+		mask := uint32(keep >> (32 * i)) //nolint:gosec
+		data[i].Effective &= mask
+		data[i].Permitted &= mask
+		data[i].Inheritable &= mask
+	}
+
+	_, _, errno := syscall.AllThreadsSyscall(syscall.SYS_CAPSET,
+		uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&data[0])), 0) //nolint:gosec
+	if errno == 0 {
+		return nil
+	}
+
+	if !errors.Is(errno, syscall.ENOTSUP) {
+		return fmt.Errorf("capset: %w",
+			errno)
+	}
+
+	// In cgo builds only the calling thread can be changed; that is the one raisePrivileges
+	// raised, but running as root the others keep root's capabilities.
+	runtime.LockOSThread()
+
+	err = unix.Capset(&hdr, &data[0])
+	if err != nil {
+		return fmt.Errorf("capset: %w",
+			err)
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
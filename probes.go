@@ -0,0 +1,166 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - probes.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 2b72d2c1-c7a6-11f1-b1ec-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// The supervision endpoints of the server modes: /healthz answers as long as the server does,
+// /readyz only if a collection succeeds, and /v1/buildinfo describes the binary.
+const (
+	healthzPath   = "/healthz"
+	readyzPath    = "/readyz"
+	buildInfoPath = "/v1/buildinfo"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readyTimeout bounds the collection behind /readyz, which should answer promptly.
+const readyTimeout = 5 * time.Second
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readyTTL is how long the outcome of a /readyz collection is reused.  The endpoint is exempt
+// from authentication and the rate limit, so without it anyone could make the server collect
+// as often as they liked.
+const readyTTL = 10 * time.Second
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readiness is the cached outcome of the collection behind /readyz.
+type readiness struct {
+	opts what.Options
+
+	mu      sync.Mutex
+	checked time.Time
+	err     error
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// check returns the outcome of the last collection if it is under readyTTL old, and otherwise
+// collects again.  Concurrent probes wait for one collection rather than each making their own;
+// it is not bound to any one request, whose going away would otherwise be cached as a failure.
+func (rd *readiness) check() error {
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+
+	if !rd.checked.IsZero() && time.Since(rd.checked) < readyTTL {
+		return rd.err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), readyTimeout)
+	defer cancel()
+
+	_, rd.err = what.Collect(ctx, rd.opts)
+	rd.checked = time.Now()
+
+	return rd.err
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// buildInfo is the document served at /v1/buildinfo.
+type buildInfo struct {
+	Version   string   `json:"version"`
+	GoVersion string   `json:"go_version"`
+	Revision  string   `json:"revision,omitempty"`
+	Time      string   `json:"time,omitempty"`
+	Modified  bool     `json:"modified,omitempty"`
+	CGO       bool     `json:"cgo"`
+	Features  []string `json:"features"`
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readBuildInfo describes the running binary from what the Go toolchain recorded in it.
+func readBuildInfo() buildInfo {
+	info := buildInfo{Version: "(devel)", Features: builtFeatures()}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.Version, info.GoVersion = bi.Main.Version, bi.GoVersion
+
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.Time = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		case "CGO_ENABLED":
+			info.CGO = setting.Value == "1"
+		}
+	}
+
+	return info
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// handleProbes adds the supervision endpoints to mux, for systemd watchdogs and Kubernetes
+// liveness and readiness probes; readiness is tested by collecting with opts, at most once per
+// readyTTL.
+func handleProbes(mux *http.ServeMux, opts what.Options) {
+	mux.HandleFunc("GET "+healthzPath, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok\n")
+	})
+
+	ready := &readiness{opts: opts}
+
+	mux.HandleFunc("GET "+readyzPath, func(w http.ResponseWriter, _ *http.Request) {
+		// The endpoint is open to anyone, so why is logged rather than answered.
+		if err := ready.check(); err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: %s: %v\n",
+				readyzPath, err)
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		_, _ = io.WriteString(w, "ok\n")
+	})
+
+	info := readBuildInfo()
+
+	mux.HandleFunc("GET "+buildInfoPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(info)
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,80 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - probes_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: d4df45fc-c7b1-11f1-b38a-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"io/fs"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// countingFS is a host whose collections are counted, by its /proc listings.
+type countingFS struct {
+	fstest.MapFS
+
+	listings atomic.Int32
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ReadDir lists the directory name, counting the listings of /proc.
+func (c *countingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == "proc" {
+		c.listings.Add(1)
+	}
+
+	return c.MapFS.ReadDir(name)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestReadinessCached(t *testing.T) {
+	fsys := &countingFS{MapFS: fixtureHost(fixtureSession{0, 1000, 1, "bash"})}
+	ready := &readiness{opts: what.Options{FS: fsys}}
+
+	for range 3 {
+		if err := ready.check(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := fsys.listings.Load(); got != 1 {
+		t.Errorf("three probes collected %d times, want once",
+			got)
+	}
+
+	ready.checked = ready.checked.Add(-readyTTL)
+
+	if err := ready.check(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fsys.listings.Load(); got != 2 {
+		t.Errorf("a probe after readyTTL collected %d times in all, want twice",
+			got)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,208 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - prompt.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 04181e8b-c7ac-11f1-b1d9-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/johnsonjh/go-what/whatjson"
+	"golang.org/x/sys/unix"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// promptTimeout bounds the collection a "go-what prompt --refresh" makes in the background.
+const promptTimeout = time.Minute
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// promptCachePath returns the file "go-what prompt" keeps its token in: in $XDG_RUNTIME_DIR,
+// which is per user and in memory, if there is one, or else the user's cache directory.
+func promptCachePath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "go-what-prompt")
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "go-what", "prompt")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// promptToken sums up who is logged in as "3u", for three users with sessions, followed by
+// "/1root" if root has one (or "/2root" for two).  A session is a user's terminal, the rows of
+// its processes counted once; sessions without one are told apart by their login time.
+func promptToken(doc *whatjson.Snapshot) string {
+	type session struct {
+		uid   uint32
+		tty   string
+		login time.Time
+	}
+
+	users := make(map[uint32]bool)
+	sessions := make(map[session]bool)
+	root := 0
+
+	for _, s := range doc.Sessions {
+		users[s.UID] = true
+
+		key := session{uid: s.UID, tty: s.TTY}
+		if s.TTY == "" {
+			key.login = s.Login
+		}
+
+		if s.UID == 0 && !sessions[key] {
+			root++
+		}
+
+		sessions[key] = true
+	}
+
+	token := strconv.Itoa(len(users)) + "u"
+	if root > 0 {
+		token += "/" + strconv.Itoa(root) + "root"
+	}
+
+	return token
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// refreshPrompt collects (or asks go-what serve on socket, if set) and replaces the cached
+// token, unless another refresh holding the lock beside the cache is already at it.
+func refreshPrompt(cache, socket string) error {
+	if err := os.MkdirAll(filepath.Dir(cache), 0o700); err != nil {
+		return err
+	}
+
+	lock, err := os.OpenFile(cache+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+
+	defer lock.Close()
+
+	if unix.Flock(int(lock.Fd()), unix.LOCK_EX|unix.LOCK_NB) != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), promptTimeout)
+	defer cancel()
+
+	doc, err := localOrServed(ctx, socket)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(cache), filepath.Base(cache)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(tmp, promptToken(doc))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), cache)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// promptMain implements "go-what prompt", which prints a token for a shell prompt (see
+// promptToken) without collecting: it reads the one cached by an earlier run, and, if that is
+// older than --max-age, or missing, starts "go-what prompt --refresh" in the background to
+// replace it.  It prints nothing until there is a token in the cache.
+func promptMain(args []string) int {
+	flags := flag.NewFlagSet("go-what prompt", flag.ExitOnError)
+
+	maxAge := flags.Duration("max-age", 30*time.Second,
+		"refresh the cached token, in the background, once it is this old")
+	refresh := flags.Bool("refresh", false,
+		"collect and cache the token, and print nothing (what the background refresh runs)")
+	socket := flags.String("socket", "",
+		"ask go-what serve, listening on this unix socket, rather than collecting")
+
+	_ = flags.Parse(args)
+
+	cache := promptCachePath()
+	if cache == "" {
+		fmt.Fprintf(os.Stderr, "go-what: prompt: no directory for the cache\n")
+
+		return 1
+	}
+
+	if *refresh {
+		if err := refreshPrompt(cache, *socket); err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: prompt: %v\n",
+				err)
+
+			return 1
+		}
+
+		return 0
+	}
+
+	token, err := os.ReadFile(cache)
+	if err == nil {
+		_, _ = os.Stdout.Write(token)
+	}
+
+	if info, err := os.Stat(cache); err == nil && time.Since(info.ModTime()) < *maxAge {
+		return 0
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return 0
+	}
+
+	// In its own session, so that the refresh neither holds up the shell nor dies with it.
+	cmd := exec.Command(self, "prompt", "--refresh", "--socket", *socket)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if cmd.Start() == nil {
+		_ = cmd.Process.Release()
+	}
+
+	return 0
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
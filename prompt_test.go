@@ -0,0 +1,84 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - prompt_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: c5febbb0-c7b3-11f1-acc2-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johnsonjh/go-what/whatjson"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestPromptToken(t *testing.T) {
+	login := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	row := func(uid uint32, tty string, login time.Time) whatjson.Session {
+		return whatjson.Session{UID: uid, TTY: tty, Login: login}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		sessions []whatjson.Session
+		want     string
+	}{
+		{"no one", nil, "0u"},
+		{"one user", []whatjson.Session{row(1000, "pts/0", login)}, "1u"},
+		{
+			"a pipeline",
+			[]whatjson.Session{
+				row(1000, "pts/0", login), row(1000, "pts/0", login), row(1001, "pts/1", login),
+			},
+			"2u",
+		},
+		{"root", []whatjson.Session{row(0, "pts/0", login), row(0, "pts/0", login)}, "1u/1root"},
+		{
+			"root on two terminals",
+			[]whatjson.Session{
+				row(0, "pts/0", login), row(0, "pts/1", login), row(1000, "pts/2", login),
+			},
+			"2u/2root",
+		},
+		{
+			// The terminal a user's su left behind, with root's row after it.
+			"root after another user on a terminal",
+			[]whatjson.Session{
+				row(1000, "pts/0", login), row(0, "pts/0", login),
+			},
+			"2u/1root",
+		},
+		{
+			"root without terminals",
+			[]whatjson.Session{
+				row(0, "", login), row(0, "", login.Add(time.Hour)), row(0, "", login),
+			},
+			"1u/2root",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := promptToken(&whatjson.Snapshot{Sessions: tc.sessions}); got != tc.want {
+				t.Errorf("promptToken = %q, want %q",
+					got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
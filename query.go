@@ -0,0 +1,559 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - query.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: ca72f453-c7a9-11f1-989a-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// queryType is the type of a --query value; every expression's is known when it is parsed, so
+// evaluating one cannot fail.
+type queryType int
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// --query value types.
+const (
+	queryString queryType = iota
+	queryNumber
+	queryBool
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (t queryType) String() string {
+	return [...]string{"string", "number", "boolean"}[t]
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// queryRow is what a --query is evaluated against: one foreground process of a session.
+type queryRow struct {
+	tty *what.TTY
+	p   *what.Process
+	now time.Time
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// queryField is a name a --query can use, and how to get its value (a string, float64, or
+// bool, as Type says) for a row.
+type queryField struct {
+	Type queryType
+	Get  func(r queryRow) any
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// queryFields are the rows' fields; times are in seconds ago.  The idle and output times of
+// graphical sessions are not known, and no comparison with them holds.
+var queryFields = map[string]queryField{
+	"user":    {queryString, func(r queryRow) any { return username(r.tty.Stat.Uid) }},
+	"uid":     {queryNumber, func(r queryRow) any { return float64(r.tty.Stat.Uid) }},
+	"tty":     {queryString, func(r queryRow) any { return r.tty.Name }},
+	"type":    {queryString, func(r queryRow) any { return r.tty.Type }},
+	"host":    {queryString, func(r queryRow) any { return r.tty.Host }},
+	"origin":  {queryString, func(r queryRow) any { return r.tty.Origin }},
+	"command": {queryString, func(r queryRow) any { return r.p.Command() }},
+	"comm":    {queryString, func(r queryRow) any { return r.p.Comm }},
+	"pid":     {queryNumber, func(r queryRow) any { return float64(r.p.PID) }},
+	"ppid":    {queryNumber, func(r queryRow) any { return float64(r.p.PPID) }},
+	"cpu":     {queryNumber, func(r queryRow) any { return r.p.CPUPercent }},
+	"age":     {queryNumber, func(r queryRow) any { return r.ago(r.tty.Stat.Ctim) }},
+	"idle":    {queryNumber, func(r queryRow) any { return r.activity(r.tty.Stat.Atim) }},
+	"output":  {queryNumber, func(r queryRow) any { return r.activity(r.tty.Stat.Mtim) }},
+
+	"automated": {queryBool, func(r queryRow) any { return r.tty.Automated }},
+	"graphical": {queryBool, func(r queryRow) any { return r.tty.Graphical }},
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ago returns how many seconds before the row's time ts was.
+func (r queryRow) ago(ts syscall.Timespec) any {
+	sec, _ := ts.Unix()
+
+	return float64(r.now.Unix() - sec)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// activity returns how many seconds before the row's time the terminal had input or output at
+// ts, or nil if the terminal is graphical and ts is only its login time.
+func (r queryRow) activity(ts syscall.Timespec) any {
+	if r.tty.Graphical {
+		return nil
+	}
+
+	return r.ago(ts)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// query is a parsed --query expression, such as `user == "alice" && idle > 1h`.  Its grammar:
+//
+//	expr    = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | compare
+//	compare = operand [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" | "=~" | "!~" ) operand ]
+//	operand = "(" expr ")" | field | string | number | "true" | "false"
+//
+// Strings are quoted with " (with Go's escapes) or '; numbers may end in s, m, h, or d, as
+// seconds, minutes, hours, or days; =~ and !~ match a string against a regular expression.
+type query struct {
+	eval func(r queryRow) any
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// matches reports whether the query selects process p of tty.
+func (q *query) matches(tty *what.TTY, p *what.Process, now time.Time) bool {
+	matched, _ := q.eval(queryRow{tty, p, now}).(bool)
+
+	return matched
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// queryParser parses a --query by recursive descent, over the tokens lexQuery splits it into.
+type queryParser struct {
+	tokens []queryToken
+	next   int
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// queryToken is one token of a --query; Offset is where it starts, for errors.
+type queryToken struct {
+	Text   string
+	Offset int
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// queryOperators are the --query operators, longest first so that lexing takes "<=" over "<".
+var queryOperators = []string{
+	"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!", "(", ")",
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// parseQuery parses a --query expression, which must be boolean.
+func parseQuery(src string) (*query, error) {
+	tokens, err := lexQuery(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{tokens: tokens}
+
+	eval, typ, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.next < len(p.tokens) {
+		return nil, p.errorf("unexpected %s",
+			p.tokens[p.next].Text)
+	}
+
+	if typ != queryBool {
+		return nil, fmt.Errorf("the expression is a %s, not a condition",
+			typ)
+	}
+
+	return &query{eval}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// lexQuery splits src into tokens: operators, quoted strings, and runs of letters, digits, dots,
+// and underscores (fields, numbers, and the boolean literals).
+func lexQuery(src string) ([]queryToken, error) {
+	var tokens []queryToken
+
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+			continue
+
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(src) && src[end] != byte(c) {
+				if src[end] == '\\' && c == '"' {
+					end++
+				}
+
+				end++
+			}
+
+			if end >= len(src) {
+				return nil, fmt.Errorf("at offset %d: unterminated string",
+					i)
+			}
+
+			tokens = append(tokens, queryToken{src[i : end+1], i})
+			i = end + 1
+
+			continue
+		}
+
+		if op := slices.IndexFunc(queryOperators, func(op string) bool {
+			return strings.HasPrefix(src[i:], op)
+		}); op >= 0 {
+			tokens = append(tokens, queryToken{queryOperators[op], i})
+			i += len(queryOperators[op])
+
+			continue
+		}
+
+		end := i
+		for end < len(src) && isQueryWord(rune(src[end])) {
+			end++
+		}
+
+		if end == i {
+			return nil, fmt.Errorf("at offset %d: unexpected %q",
+				i, c)
+		}
+
+		tokens = append(tokens, queryToken{src[i:end], i})
+		i = end
+	}
+
+	return tokens, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// isQueryWord reports whether c can be part of a field name or number.
+func isQueryWord(c rune) bool {
+	return c == '_' || c == '.' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// errorf returns an error located at the next token, or at the end of the expression.
+func (p *queryParser) errorf(format string, args ...any) error {
+	where := "at the end"
+	if p.next < len(p.tokens) {
+		where = fmt.Sprintf("at offset %d",
+			p.tokens[p.next].Offset)
+	}
+
+	return fmt.Errorf("%s: %s",
+		where, fmt.Sprintf(format, args...))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// accept consumes the next token if it is one of ops, returning it.
+func (p *queryParser) accept(ops ...string) (string, bool) {
+	if p.next < len(p.tokens) && slices.Contains(ops, p.tokens[p.next].Text) {
+		p.next++
+
+		return p.tokens[p.next-1].Text, true
+	}
+
+	return "", false
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// logical parses operands joined by op, with next parsing each operand.
+func (p *queryParser) logical(op string, next func() (func(queryRow) any, queryType, error)) (
+	func(queryRow) any, queryType, error,
+) {
+	left, typ, err := next()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for {
+		if _, ok := p.accept(op); !ok {
+			return left, typ, nil
+		}
+
+		right, rtyp, err := next()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if typ != queryBool || rtyp != queryBool {
+			return nil, 0, p.errorf("%s needs conditions on both sides",
+				op)
+		}
+
+		l := left
+		if op == "&&" {
+			left = func(r queryRow) any { return l(r).(bool) && right(r).(bool) }
+		} else {
+			left = func(r queryRow) any { return l(r).(bool) || right(r).(bool) }
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (p *queryParser) or() (func(queryRow) any, queryType, error) {
+	return p.logical("||", p.and)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (p *queryParser) and() (func(queryRow) any, queryType, error) {
+	return p.logical("&&", p.unary)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (p *queryParser) unary() (func(queryRow) any, queryType, error) {
+	if _, ok := p.accept("!"); !ok {
+		return p.compare()
+	}
+
+	operand, typ, err := p.unary()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if typ != queryBool {
+		return nil, 0, p.errorf("! needs a condition")
+	}
+
+	return func(r queryRow) any { return !operand(r).(bool) }, queryBool, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (p *queryParser) compare() (func(queryRow) any, queryType, error) {
+	left, typ, err := p.operand()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	op, ok := p.accept("==", "!=", "<", "<=", ">", ">=", "=~", "!~")
+	if !ok {
+		return left, typ, nil
+	}
+
+	if op == "=~" || op == "!~" {
+		return p.match(left, typ, op == "!~")
+	}
+
+	right, rtyp, err := p.operand()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if typ != rtyp {
+		return nil, 0, p.errorf("cannot compare a %s with a %s",
+			typ, rtyp)
+	}
+
+	if typ == queryBool && op != "==" && op != "!=" {
+		return nil, 0, p.errorf("conditions can only be compared with == and !=")
+	}
+
+	return func(r queryRow) any {
+		a, b := left(r), right(r)
+		if a == nil || b == nil {
+			return false
+		}
+
+		var c int
+
+		switch a := a.(type) {
+		case string:
+			c = strings.Compare(a, b.(string))
+
+		case float64:
+			c = cmp.Compare(a, b.(float64))
+
+		case bool:
+			if a != b.(bool) {
+				c = 1
+			}
+		}
+
+		switch op {
+		case "==":
+			return c == 0
+		case "!=":
+			return c != 0
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		}
+
+		return c >= 0
+	}, queryBool, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// match parses the regular expression after =~ (or, negated, !~) and returns the condition.
+func (p *queryParser) match(left func(queryRow) any, typ queryType, negated bool) (
+	func(queryRow) any, queryType, error,
+) {
+	if typ != queryString || p.next >= len(p.tokens) || !isQueryString(p.tokens[p.next].Text) {
+		return nil, 0, p.errorf("=~ and !~ match a string against a quoted regular expression")
+	}
+
+	pattern, err := unquoteQuery(p.tokens[p.next].Text)
+	if err != nil {
+		return nil, 0, p.errorf("%v",
+			err)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, 0, p.errorf("%v",
+			err)
+	}
+
+	p.next++
+
+	matched := func(r queryRow) any { return re.MatchString(left(r).(string)) != negated }
+
+	return matched, queryBool, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (p *queryParser) operand() (func(queryRow) any, queryType, error) {
+	if p.next >= len(p.tokens) {
+		return nil, 0, p.errorf("expected a value")
+	}
+
+	if _, ok := p.accept("("); ok {
+		inner, typ, err := p.or()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if _, ok := p.accept(")"); !ok {
+			return nil, 0, p.errorf("expected )")
+		}
+
+		return inner, typ, nil
+	}
+
+	text := p.tokens[p.next].Text
+
+	switch {
+	case isQueryString(text):
+		s, err := unquoteQuery(text)
+		if err != nil {
+			return nil, 0, p.errorf("%v",
+				err)
+		}
+
+		p.next++
+
+		return func(queryRow) any { return s }, queryString, nil
+
+	case text == "true" || text == "false":
+		p.next++
+
+		return func(queryRow) any { return text == "true" }, queryBool, nil
+
+	case text[0] >= '0' && text[0] <= '9' || text[0] == '.':
+		n, err := queryNumberOf(text)
+		if err != nil {
+			return nil, 0, p.errorf("%v",
+				err)
+		}
+
+		p.next++
+
+		return func(queryRow) any { return n }, queryNumber, nil
+	}
+
+	field, ok := queryFields[text]
+	if !ok {
+		return nil, 0, p.errorf("unknown field %q (known: %s)",
+			text, strings.Join(slices.Sorted(maps.Keys(queryFields)), ", "))
+	}
+
+	p.next++
+
+	return field.Get, field.Type, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// isQueryString reports whether a token is a quoted string.
+func isQueryString(text string) bool {
+	return text[0] == '"' || text[0] == '\''
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// unquoteQuery returns the contents of a quoted string token.
+func unquoteQuery(text string) (string, error) {
+	if text[0] == '\'' {
+		return text[1 : len(text)-1], nil
+	}
+
+	return strconv.Unquote(text)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// queryNumberOf parses a number token, with its optional unit of time.
+func queryNumberOf(text string) (float64, error) {
+	units := map[byte]float64{'s': 1, 'm': 60, 'h': 60 * 60, 'd': 24 * 60 * 60}
+
+	scale := 1.0
+	if unit, ok := units[text[len(text)-1]]; ok {
+		text, scale = text[:len(text)-1], unit
+	}
+
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad number %q",
+			text)
+	}
+
+	return n * scale, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,162 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - query_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 3c29b97b-c7b1-11f1-84c1-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// queryNow is the time the query tests are evaluated at.
+var queryNow = time.Unix(1_000_000, 0)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// queryTTY returns a pty session of uid 1000 from 192.0.2.1, logged in two hours before
+// queryNow, idle for 90 minutes and last written to a minute ago.
+func queryTTY() *what.TTY {
+	return &what.TTY{
+		Name: "pts/3", Type: "pty", Host: "192.0.2.1", Origin: "sshd",
+		Stat: syscall.Stat_t{
+			Uid:  1000,
+			Ctim: syscall.Timespec{Sec: queryNow.Unix() - 7200},
+			Atim: syscall.Timespec{Sec: queryNow.Unix() - 5400},
+			Mtim: syscall.Timespec{Sec: queryNow.Unix() - 60},
+		},
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestQueryMatches(t *testing.T) {
+	p := &what.Process{
+		PID: 4242, PPID: 4200, Comm: "vim", Cmdline: "vim\x00notes.txt\x00", CPUPercent: 12.5,
+	}
+
+	for _, tc := range []struct {
+		query string
+		want  bool
+	}{
+		{`uid == 1000`, true},
+		{`uid != 1000`, false},
+		{`tty == "pts/3" && type == 'pty'`, true},
+		{`comm == "vim"`, true},
+		{`command =~ "notes\\.txt$"`, true},
+		{`command !~ "^vim"`, false},
+		{`host =~ "^192\\.0\\.2\\."`, true},
+		{`origin == "sshd"`, true},
+		{`pid > 4000 && ppid < 4242`, true},
+		{`cpu >= 12.5`, true},
+		{`age > 1h && age < 3h`, true},
+		{`idle > 1h`, true},
+		{`idle > 90m`, false},
+		{`idle >= 90m`, true},
+		{`output < 2m`, true},
+		{`idle > 1d`, false},
+		{`idle > 5400s`, false},
+		{`automated || graphical`, false},
+		{`!automated`, true},
+		{`uid == 0 || comm == "vim" && cpu > 50`, false},
+		{`(uid == 0 || comm == "vim") && cpu > 10`, true},
+		{`graphical == false`, true},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			q, err := parseQuery(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := q.matches(queryTTY(), p, queryNow); got != tc.want {
+				t.Errorf("matches = %v, want %v",
+					got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		want  string
+	}{
+		{``, "expected a value"},
+		{`uid`, "is a number, not a condition"},
+		{`user == 1000`, "cannot compare a string with a number"},
+		{`automated < true`, "only be compared with == and !="},
+		{`uid && automated`, "&& needs conditions on both sides"},
+		{`!uid`, "! needs a condition"},
+		{`nosuch == 1`, `unknown field "nosuch"`},
+		{`(uid == 1`, "expected )"},
+		{`uid == 1 )`, "unexpected )"},
+		{`user == "alice`, "unterminated string"},
+		{`uid =~ "1"`, "match a string against a quoted regular expression"},
+		{`user =~ "("`, "missing closing )"},
+		{`uid == 1 @`, `unexpected '@'`},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			_, err := parseQuery(tc.query)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("parseQuery(%q) = %v, want an error containing %q",
+					tc.query, err, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestQueryGraphicalIdle(t *testing.T) {
+	tty := queryTTY()
+	tty.Graphical = true
+
+	for _, tc := range []struct {
+		query string
+		want  bool
+	}{
+		{`idle > 1h`, false},
+		{`idle < 1h`, false},
+		{`output >= 0`, false},
+		{`idle == idle`, false},
+		{`age > 1h`, true},
+		{`graphical`, true},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			q, err := parseQuery(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := q.matches(tty, &what.Process{Comm: "gnome-shell"}, queryNow); got != tc.want {
+				t.Errorf("matches = %v, want %v",
+					got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
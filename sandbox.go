@@ -0,0 +1,289 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - sandbox.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 2420fe88-c7aa-11f1-8c6b-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sandboxReadable are what a one-shot run reads whatever its flags once it has collected: the
+// user and group databases and NSS's configuration, sssd's cache of them, the time zone, and
+// the libraries NSS modules and helpers are loaded from.  The logs, wtmp, and GeoIP databases
+// are added by the flags that read them.
+var sandboxReadable = []string{
+	"/etc/passwd", "/etc/group", "/etc/nsswitch.conf", "/etc/ld.so.cache", "/etc/localtime",
+	"/usr/share/zoneinfo", "/var/lib/sss/mc", "/lib", "/lib64", "/usr/lib", "/usr/lib64",
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// errNoLandlock is returned by sandbox when the kernel does not support (or has disabled)
+// Landlock.
+var errNoLandlock = errors.New("landlock is not available")
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// errNoSeccomp is returned by denySyscalls on the architectures auditArches does not know.
+var errNoSeccomp = errors.New("no seccomp filter for " + runtime.GOARCH)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// auditArches are the seccomp architectures of the GOARCHes, which a filter has to check since
+// the same call has another number in another ABI (i386's, say, on amd64).
+var auditArches = map[string]uint32{
+	"amd64":   unix.AUDIT_ARCH_X86_64,
+	"386":     unix.AUDIT_ARCH_I386,
+	"arm64":   unix.AUDIT_ARCH_AARCH64,
+	"arm":     unix.AUDIT_ARCH_ARM,
+	"riscv64": unix.AUDIT_ARCH_RISCV64,
+	"ppc64le": unix.AUDIT_ARCH_PPC64LE,
+	"s390x":   unix.AUDIT_ARCH_S390X,
+	"loong64": unix.AUDIT_ARCH_LOONGARCH64,
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// deniedSyscalls are the system calls go-what never makes that capabilities granted to it could
+// be turned to: reading and tracing other processes, mounts and namespaces, kernel modules and
+// kexec, BPF and perf, the keyrings, and open_by_handle_at, which with CAP_DAC_READ_SEARCH
+// opens any file of a filesystem, Landlock or not.
+var deniedSyscalls = []uintptr{
+	unix.SYS_PTRACE, unix.SYS_PROCESS_VM_READV, unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_MOUNT, unix.SYS_UMOUNT2, unix.SYS_PIVOT_ROOT, unix.SYS_CHROOT, unix.SYS_SETNS,
+	unix.SYS_UNSHARE, unix.SYS_INIT_MODULE, unix.SYS_FINIT_MODULE, unix.SYS_DELETE_MODULE,
+	unix.SYS_KEXEC_LOAD, unix.SYS_REBOOT, unix.SYS_SWAPON, unix.SYS_SWAPOFF, unix.SYS_ACCT,
+	unix.SYS_BPF, unix.SYS_PERF_EVENT_OPEN, unix.SYS_USERFAULTFD, unix.SYS_KEYCTL,
+	unix.SYS_ADD_KEY, unix.SYS_REQUEST_KEY, unix.SYS_OPEN_BY_HANDLE_AT,
+	unix.SYS_NAME_TO_HANDLE_AT, unix.SYS_SETTIMEOFDAY, unix.SYS_CLOCK_SETTIME,
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Landlock file access rights, as of ABI 1; ABI 2 adds REFER and ABI 3 TRUNCATE.
+const (
+	landlockRead = unix.LANDLOCK_ACCESS_FS_READ_FILE | unix.LANDLOCK_ACCESS_FS_READ_DIR |
+		unix.LANDLOCK_ACCESS_FS_EXECUTE
+
+	landlockABI1 = landlockRead | unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR | unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR | unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG | unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO | unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sandbox confines go-what, with Landlock, to reading sandboxReadable and the extra paths (files
+// or directories; those that do not exist are ignored), and writing nothing but /dev/null, so
+// that capabilities granted for --privileged cannot be turned to anything else.  Files already
+// open, such as standard output, are unaffected, and so are sockets.
+func sandbox(extra []string) error {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0,
+		unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return errNoLandlock
+	}
+
+	handled := uint64(landlockABI1)
+	if abi >= 2 {
+		handled |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+
+	if abi >= 3 {
+		handled |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+
+	ruleset, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET,
+		uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr.Access_fs), 0) //nolint:gosec
+	if errno != 0 {
+		return fmt.Errorf("landlock_create_ruleset: %w",
+			errno)
+	}
+
+	defer unix.Close(int(ruleset))
+
+	for _, path := range append(sandboxReadable, extra...) {
+		if err := allowPath(int(ruleset), path, landlockRead); err != nil {
+			return err
+		}
+	}
+
+	// os/exec gives helpers /dev/null for the standard streams it is not told about.
+	err := allowPath(int(ruleset), "/dev/null",
+		unix.LANDLOCK_ACCESS_FS_READ_FILE|unix.LANDLOCK_ACCESS_FS_WRITE_FILE)
+	if err != nil {
+		return err
+	}
+
+	// Both calls apply only to the calling thread, and the Go runtime has several; see
+	// raisePrivileges.
+	_, _, errno = syscall.AllThreadsSyscall(syscall.SYS_PRCTL, unix.PR_SET_NO_NEW_PRIVS, 1, 0)
+	if errno == 0 {
+		_, _, errno = syscall.AllThreadsSyscall(unix.SYS_LANDLOCK_RESTRICT_SELF, ruleset, 0, 0)
+	}
+
+	// AllThreadsSyscall is unavailable in cgo builds, which can only confine the main goroutine,
+	// pinned to its thread; the user lookups on other threads then go unconfined.
+	if errors.Is(errno, syscall.ENOTSUP) {
+		runtime.LockOSThread()
+
+		_, _, errno = unix.Syscall(syscall.SYS_PRCTL, unix.PR_SET_NO_NEW_PRIVS, 1, 0)
+		if errno == 0 {
+			_, _, errno = unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, ruleset, 0, 0)
+		}
+	}
+
+	if errno != 0 {
+		return fmt.Errorf("landlock_restrict_self: %w",
+			errno)
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// denySyscalls installs a seccomp filter failing deniedSyscalls, and exec too unless allowExec,
+// with EPERM, in every thread.
+func denySyscalls(allowExec bool) error {
+	arch, ok := auditArches[runtime.GOARCH]
+	if !ok {
+		return errNoSeccomp
+	}
+
+	denied := deniedSyscalls
+	if !allowExec {
+		denied = append(slices.Clone(denied), unix.SYS_EXECVE, unix.SYS_EXECVEAT)
+	}
+
+	deny := uint32(unix.SECCOMP_RET_ERRNO | unix.EPERM)
+
+	// Each jump to deny skips the comparisons after it and the allow.
+	filter := []unix.SockFilter{
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: 4}, // seccomp_data.arch
+		{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, Jt: 1, K: arch},
+		{Code: unix.BPF_RET | unix.BPF_K, K: deny},
+		{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: 0}, // seccomp_data.nr
+		// amd64's x32 calls, numbered from 0x40000000.
+		{Code: unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K, Jt: uint8(len(denied) + 1), K: 1 << 30},
+	}
+
+	for i, nr := range denied {
+		filter = append(filter, unix.SockFilter{
+			Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K,
+			Jt:   uint8(len(denied) - i), K: uint32(nr),
+		})
+	}
+
+	filter = append(filter,
+		unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: unix.SECCOMP_RET_ALLOW},
+		unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: deny})
+
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+
+	// An unprivileged filter needs NO_NEW_PRIVS, and both have to be set from one thread;
+	// TSYNC then gives the filter, and NO_NEW_PRIVS with it, to all the others, cgo or not.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("prctl: %w",
+			err)
+	}
+
+	tid, _, errno := unix.Syscall(unix.SYS_SECCOMP, unix.SECCOMP_SET_MODE_FILTER,
+		unix.SECCOMP_FILTER_FLAG_TSYNC, uintptr(unsafe.Pointer(&prog))) //nolint:gosec
+
+	switch {
+	case errno != 0:
+		return fmt.Errorf("seccomp: %w",
+			errno)
+
+	case tid != 0:
+		return fmt.Errorf("seccomp: thread %d could not be synchronized",
+			tid)
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// confineService is the sandbox of the modes that go on collecting: --follow, --collector,
+// serve, and agent.  Landlock is no use to them, since a confined process can no longer read
+// the fd and ns links of the processes outside its domain, so they keep only the capabilities a
+// collection uses, and lose deniedSyscalls and exec.  It is called once they are listening.
+func confineService() error {
+	if err := dropPrivileges(inspectCaps); err != nil {
+		return err
+	}
+
+	if err := denySyscalls(false); err != nil && !errors.Is(err, errNoSeccomp) {
+		return err
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// allowPath adds a rule to ruleset granting access beneath path, limited to the rights that
+// apply to files if it is one.
+func allowPath(ruleset int, path string, access uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil //nolint:nilerr
+	}
+
+	defer unix.Close(fd)
+
+	var st unix.Stat_t
+	if err := unix.Fstat(fd, &st); err == nil && st.Mode&unix.S_IFMT != unix.S_IFDIR {
+		access &^= unix.LANDLOCK_ACCESS_FS_READ_DIR
+	}
+
+	rule := unix.LandlockPathBeneathAttr{
+		Allowed_access: access,
+		Parent_fd:      int32(fd), //nolint:gosec
+	}
+
+	_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(ruleset),
+		unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0) //nolint:gosec
+	if errno != 0 {
+		return fmt.Errorf("landlock_add_rule %s: %w",
+			path, errno)
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,515 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - serve.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 10212d10-c7a6-11f1-a2ae-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"crypto/tls"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// metricsPath is where go-what serve exposes its metrics, in the Prometheus text format.
+const metricsPath = "/metrics"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// uiFiles is the dashboard of go-what serve --ui, a page that shows the session table from
+// /v1/snapshot.
+//
+//go:embed ui
+var uiFiles embed.FS
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// idleBuckets are the upper bounds, in seconds, of the buckets of what_session_idle_seconds:
+// from a minute to three days, with twelve hours among them for alerting on forgotten shells.
+var idleBuckets = []float64{60, 300, 900, 3600, 4 * 3600, 12 * 3600, 24 * 3600, 72 * 3600}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// labelEscaper escapes a Prometheus label value.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// writeMetrics writes the snapshot as Prometheus metrics, every series labeled with the node if
+// it is set, and with per-user series if perUser (one per user with a session, which may be
+// many).  took is how long the collection took.
+func writeMetrics(w io.Writer, snap *what.Snapshot, node string, perUser bool,
+	took time.Duration,
+) {
+	nodeLabel := ""
+	if node != "" {
+		nodeLabel = `node="` + labelEscaper.Replace(node) + `"`
+	}
+
+	labels := func(extra ...string) string {
+		all := slices.DeleteFunc(append([]string{nodeLabel}, extra...),
+			func(s string) bool { return s == "" })
+		if len(all) == 0 {
+			return ""
+		}
+
+		return "{" + strings.Join(all, ",") + "}"
+	}
+
+	metric := func(name, help, kind string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n",
+			name, help, name, kind)
+	}
+
+	sessions := make(map[string]int)
+
+	for _, tty := range snap.TTYs {
+		sessions[tty.Type] += len(tty.Processes)
+	}
+
+	metric("what_sessions", "Foreground processes on terminals, by terminal type.", "gauge")
+
+	for _, kind := range slices.Sorted(maps.Keys(sessions)) {
+		fmt.Fprintf(w, "what_sessions%s %d\n",
+			labels(`type="`+labelEscaper.Replace(kind)+`"`), sessions[kind])
+	}
+
+	noTTY := 0
+	for _, n := range snap.NoTTY {
+		noTTY += n
+	}
+
+	metric("what_users", "Users with processes.", "gauge")
+	fmt.Fprintf(w, "what_users%s %d\n",
+		labels(), snap.Users)
+
+	metric("what_notty_processes", "Processes without a terminal.", "gauge")
+	fmt.Fprintf(w, "what_notty_processes%s %d\n",
+		labels(), noTTY)
+
+	metric("what_uptime_seconds", "Time since boot.", "gauge")
+	fmt.Fprintf(w, "what_uptime_seconds%s %g\n",
+		labels(), snap.Uptime)
+
+	metric("what_collection_warnings", "Things the last collection could not read.", "gauge")
+	fmt.Fprintf(w, "what_collection_warnings%s %d\n",
+		labels(), len(snap.Skipped))
+
+	metric("what_collection_duration_seconds", "How long the last collection took.", "gauge")
+	fmt.Fprintf(w, "what_collection_duration_seconds%s %g\n",
+		labels(), took.Seconds())
+
+	// Idle times are per terminal with a session, since input last reached it; root's are
+	// kept apart, as a forgotten root shell is the one worth alerting on.
+	type idleStats struct {
+		counts []int
+		sum    float64
+		total  int
+	}
+
+	idle := map[string]*idleStats{
+		"root": {counts: make([]int, len(idleBuckets))},
+		"user": {counts: make([]int, len(idleBuckets))},
+	}
+	userIdle := make(map[string]float64)
+	userSessions := make(map[string]int)
+
+	for _, tty := range snap.TTYs {
+		if len(tty.Processes) == 0 {
+			continue
+		}
+
+		if perUser {
+			userSessions[username(tty.Stat.Uid)]++
+		}
+
+		// A graphical session's idle time is not known, so it is left out of the histogram.
+		idleFor, known := tty.Idle(snap.Time)
+		if !known {
+			continue
+		}
+
+		seconds := max(idleFor.Seconds(), 0)
+
+		class := "user"
+		if tty.Stat.Uid == 0 {
+			class = "root"
+		}
+
+		stats := idle[class]
+
+		for i, bound := range idleBuckets {
+			if seconds <= bound {
+				stats.counts[i]++
+			}
+		}
+
+		stats.sum += seconds
+		stats.total++
+
+		if perUser {
+			name := username(tty.Stat.Uid)
+			userIdle[name] = max(userIdle[name], seconds)
+		}
+	}
+
+	metric("what_session_idle_seconds",
+		`Time since each session's terminal last had input, by class="root" or "user".`,
+		"histogram")
+
+	for _, class := range []string{"root", "user"} {
+		stats := idle[class]
+		classLabel := `class="` + class + `"`
+
+		for i, bound := range idleBuckets {
+			fmt.Fprintf(w, "what_session_idle_seconds_bucket%s %d\n",
+				labels(classLabel, fmt.Sprintf(`le="%g"`, bound)), stats.counts[i])
+		}
+
+		fmt.Fprintf(w, "what_session_idle_seconds_bucket%s %d\n",
+			labels(classLabel, `le="+Inf"`), stats.total)
+		fmt.Fprintf(w, "what_session_idle_seconds_sum%s %g\n",
+			labels(classLabel), stats.sum)
+		fmt.Fprintf(w, "what_session_idle_seconds_count%s %d\n",
+			labels(classLabel), stats.total)
+	}
+
+	if !perUser {
+		return
+	}
+
+	metric("what_user_sessions", "Sessions of each user.", "gauge")
+
+	for _, name := range slices.Sorted(maps.Keys(userSessions)) {
+		fmt.Fprintf(w, "what_user_sessions%s %d\n",
+			labels(`user="`+labelEscaper.Replace(name)+`"`), userSessions[name])
+	}
+
+	metric("what_user_idle_seconds_max", "The longest idle time among each user's sessions.",
+		"gauge")
+
+	for _, name := range slices.Sorted(maps.Keys(userIdle)) {
+		fmt.Fprintf(w, "what_user_idle_seconds_max%s %g\n",
+			labels(`user="`+labelEscaper.Replace(name)+`"`), userIdle[name])
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// metricsHandler serves a fresh collection as Prometheus metrics on every request.
+func metricsHandler(opts what.Options, node string, perUser bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		snap, err := what.Collect(r.Context(), opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writeMetrics(w, snap, node, perUser, time.Since(start))
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// serveMain implements "go-what serve": the JSON snapshot and Prometheus metrics over HTTP(S), for
+// running on every node of a cluster (as a Kubernetes DaemonSet with hostPID and the host's
+// root filesystem mounted, say).  It writes nothing to disk, so it runs with a read-only root
+// filesystem; with --root, users are looked up in the host's /etc/passwd rather than the
+// container's.
+func serveMain(args []string) int {
+	flags := flag.NewFlagSet("go-what serve", flag.ExitOnError)
+
+	listen := flags.String("listen", ":7080",
+		"the address to serve on (\"\" for none, with --socket)")
+	socket := flags.String("socket", "",
+		"also serve on this unix socket, without the token or basic authentication")
+	socketMode := flags.String("socket-mode", "0660",
+		"the permissions of the --socket; connecting takes write permission")
+	socketGroup := flags.String("socket-group", "",
+		"the group (name or GID) to give the --socket, for --socket-mode to apply to")
+	root := flags.String("root", "/",
+		"where the host's root filesystem is mounted (for /proc, /dev, /run, and /etc/passwd)")
+	passwdPath := flags.String("passwd-file", "",
+		"look users up in this passwd file (default the one under --root)")
+	node := flags.String("node-name", os.Getenv("NODE_NAME"),
+		"the name to label the data with (default $NODE_NAME, as set from the downward API)")
+	origin := flags.Bool("origin", false,
+		"resolve the process holding each pty master, as go-what --origin does")
+	humansOnly := flags.Bool("humans-only", false,
+		"leave out sessions spawned by automation")
+	tokenFile := flags.String("token-file", "",
+		"require one of the bearer tokens in this file, one per line")
+	basicFile := flags.String("basic-auth-file", "",
+		"require basic auth as one of the user:password lines of this file")
+	rateLimit := flags.Int("rate-limit", 0,
+		"allow each client address this many requests a minute (0 for no limit)")
+	tlsCert := flags.String("tls-cert", "",
+		"serve HTTPS with this certificate (PEM), read again whenever it changes")
+	tlsKey := flags.String("tls-key", "",
+		"the private key of --tls-cert (PEM)")
+	tlsDir := flags.String("tls-dir", "",
+		"serve HTTPS with the certificates an ACME client keeps in this directory, by name")
+	perUser := flags.Bool("metrics-per-user", false,
+		"add per-user session and idle-time metrics (a series per user: mind the cardinality)")
+	statsd := flags.String("statsd", "",
+		"also push gauges to the StatsD server at this host:port (UDP)")
+	statsdInterval := flags.Duration("statsd-interval", 10*time.Second,
+		"time between pushes to --statsd")
+	statsdPrefix := flags.String("statsd-prefix", "what.",
+		"the prefix of the --statsd gauge names")
+	statsdTags := flags.Bool("statsd-tags", false,
+		"send DogStatsD tags (host, tty_class, and user with --metrics-per-user)")
+	ui := flags.Bool("ui", false,
+		"also serve a web dashboard of the sessions at /")
+	eventsInterval := flags.Duration("events-interval", 2*time.Second,
+		"time between the collections behind "+eventsPath)
+	corsOrigins := flags.String("cors-origins", "",
+		"let pages from these origins (comma-separated, or *) query the API from the browser")
+	corsMethods := flags.String("cors-methods", "GET",
+		"the methods --cors-origins may use (comma-separated)")
+	sandboxed := flags.Bool("sandbox", true,
+		"once listening, keep only the capabilities collecting uses, and deny exec and other"+
+			" system calls (seccomp)")
+
+	_ = flags.Parse(args)
+
+	if *listen == "" && *socket == "" {
+		fmt.Fprintf(os.Stderr, "go-what: serve: nothing to serve on: no --listen or --socket\n")
+
+		return 2
+	}
+
+	if (*tlsCert == "") != (*tlsKey == "") {
+		fmt.Fprintf(os.Stderr, "go-what: serve: --tls-cert and --tls-key go together\n")
+
+		return 2
+	}
+
+	if *statsdInterval <= 0 || *eventsInterval <= 0 {
+		fmt.Fprintf(os.Stderr,
+			"go-what: serve: --statsd-interval and --events-interval must be positive\n")
+
+		return 2
+	}
+
+	creds, err := loadCredentials(*tokenFile, *basicFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-what: serve: %v\n",
+			err)
+
+		return 1
+	}
+
+	opts := what.Options{
+		Origin:     *origin,
+		HumansOnly: *humansOnly,
+		Graphical:  true,
+	}
+
+	if filepath.Clean(*root) != "/" {
+		if _, err := os.Stat(filepath.Join(*root, "proc", "self")); err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: serve: --root %s has no /proc mounted\n",
+				*root)
+
+			return 2
+		}
+
+		opts.FS = os.DirFS(*root)
+		passwdFile = filepath.Join(*root, "etc", "passwd")
+	}
+
+	if *passwdPath != "" {
+		passwdFile = *passwdPath
+	}
+
+	nodeName := *node
+	if nodeName == "" {
+		nodeName, _ = os.Hostname()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mux := http.NewServeMux()
+	mux.Handle("GET "+agentSnapshotPath, snapshotHandler(opts, nodeName))
+	mux.Handle("GET "+metricsPath, metricsHandler(opts, nodeName, *perUser))
+	mux.Handle("GET "+eventsPath, newEventHub(ctx, what.WatchOptions{
+		Options:  opts,
+		Interval: *eventsInterval,
+	}, nodeName))
+	handleProbes(mux, opts)
+
+	if *statsd != "" {
+		go pushStatsD(ctx, opts, statsdConfig{
+			Addr:     *statsd,
+			Interval: *statsdInterval,
+			Prefix:   *statsdPrefix,
+			Tags:     *statsdTags,
+			PerUser:  *perUser,
+		}, nodeName)
+	}
+
+	if *ui {
+		dashboard, _ := fs.Sub(uiFiles, "ui")
+		mux.Handle("GET /", http.FileServerFS(dashboard))
+	}
+
+	var origins []string
+	if *corsOrigins != "" {
+		origins = strings.Split(*corsOrigins, ",")
+	}
+
+	handler := allowCORS(protect(mux, creds, newLimiter(*rateLimit), healthzPath, readyzPath),
+		origins, strings.Split(*corsMethods, ","))
+
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ErrorLog:          log.New(os.Stderr, "go-what: serve: ", 0),
+	}
+
+	if *tlsCert != "" || *tlsDir != "" {
+		store := &certStore{CertFile: *tlsCert, KeyFile: *tlsKey, Dir: *tlsDir}
+
+		// Fail now, rather than at the first handshake, if the certificate cannot be read.
+		if *tlsCert != "" {
+			if _, err := store.load(*tlsCert, *tlsKey); err != nil {
+				fmt.Fprintf(os.Stderr, "go-what: serve: %v\n",
+					err)
+
+				return 1
+			}
+		}
+
+		server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: store.GetCertificate,
+		}
+	}
+
+	var listener net.Listener
+
+	if *listen != "" {
+		listener, err = net.Listen("tcp", *listen)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: serve: %v\n",
+				err)
+
+			return 1
+		}
+	}
+
+	// Whoever can connect to the socket, as its permissions allow, is trusted as a local user.
+	var socketServer *http.Server
+
+	if *socket != "" {
+		socketListener, err := listenUnix(*socket, *socketMode, *socketGroup)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: serve: --socket: %v\n",
+				err)
+
+			return 1
+		}
+
+		socketServer = &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+			ErrorLog:          server.ErrorLog,
+		}
+
+		go func() {
+			if err := socketServer.Serve(socketListener); !errors.Is(err, http.ErrServerClosed) {
+				fmt.Fprintf(os.Stderr, "go-what: serve: --socket: %v\n",
+					err)
+				stop()
+			}
+		}()
+	}
+
+	if *sandboxed {
+		if err := confineService(); err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: serve: --sandbox: %v\n",
+				err)
+		}
+	}
+
+	// Shutting the socket's server down also removes the socket.
+	shutDown := make(chan struct{})
+
+	go func() {
+		defer close(shutDown)
+
+		<-ctx.Done()
+
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = server.Shutdown(shutdown)
+		if socketServer != nil {
+			_ = socketServer.Shutdown(shutdown)
+		}
+	}()
+
+	switch {
+	case *listen == "":
+		<-ctx.Done()
+
+	case server.TLSConfig != nil:
+		err = server.ServeTLS(listener, "", "")
+
+	default:
+		err = server.Serve(listener)
+	}
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "go-what: serve: %v\n",
+			err)
+
+		return 1
+	}
+
+	<-shutDown
+
+	return 0
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,76 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - serve_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: b4583b75-c7b3-11f1-b7fd-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestWriteMetricsIdle(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	bash := []*what.Process{{PID: 1, Comm: "bash"}}
+
+	root := idleTTY("pts/0", 0, now, 2*time.Hour)
+	alice := idleTTY("pts/1", 1000, now, 30*time.Second)
+	graphical := idleTTY("tty2", 1000, now, 0)
+	graphical.Graphical = true
+
+	for _, tty := range []*what.TTY{root, alice, graphical} {
+		tty.Processes = bash
+	}
+
+	var b strings.Builder
+
+	writeMetrics(&b, &what.Snapshot{Time: now, TTYs: []*what.TTY{root, alice, graphical}},
+		`node"1`, true, time.Second)
+
+	got := b.String()
+
+	for _, want := range []string{
+		`# HELP what_session_idle_seconds Time since each session's terminal last had input, ` +
+			`by class="root" or "user".` + "\n",
+		`what_session_idle_seconds_bucket{node="node\"1",class="root",le="3600"} 0` + "\n",
+		`what_session_idle_seconds_bucket{node="node\"1",class="root",le="14400"} 1` + "\n",
+		`what_session_idle_seconds_bucket{node="node\"1",class="user",le="60"} 1` + "\n",
+		`what_session_idle_seconds_sum{node="node\"1",class="root"} 7200` + "\n",
+		// The graphical session's idle time is not known, but it is still alice's session.
+		`what_session_idle_seconds_count{node="node\"1",class="user"} 1` + "\n",
+		`what_user_idle_seconds_max{node="node\"1",user="alice"} 30` + "\n",
+		`what_user_sessions{node="node\"1",user="alice"} 2` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("the metrics do not contain %q:\n%s",
+				want, got)
+		}
+	}
+
+	if strings.Contains(got, "uid=") {
+		t.Errorf("the metrics have a uid label:\n%s",
+			got)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
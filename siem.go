@@ -0,0 +1,203 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - siem.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 2044dc74-c7a7-11f1-838d-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// siemSeverity is the CEF severity (0 to 10) and LEEF sev (1 to 10) of session events: low,
+// as they record normal activity.
+const siemSeverity = 3
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// siemAlertSeverity is the severity of alerts: high, as each calls for someone to look.
+const siemAlertSeverity = 7
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+var (
+	// cefHeaderEscaper escapes a field of a CEF or LEEF header.
+	cefHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+
+	// cefValueEscaper escapes a CEF extension value.
+	cefValueEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+
+	// leefValueEscaper keeps a LEEF attribute value from breaking the tab-separated attributes.
+	leefValueEscaper = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// siemField is a key and value of a CEF extension or LEEF attribute list.
+type siemField struct {
+	Key   string
+	Value string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// siemFields returns the fields both formats share for an action on a session at a time, in
+// the keys of CEF, or for each key as LEEF names it if leef is set.  Fields unknown for the
+// session are left out.
+func siemFields(action string, at time.Time, tty *what.TTY, severity int,
+	leef bool,
+) []siemField {
+	name := func(cef, leefKey string) string {
+		if leef {
+			return leefKey
+		}
+
+		return cef
+	}
+
+	fields := []siemField{
+		{name("act", "action"), action},
+		{name("suser", "usrName"), username(tty.Stat.Uid)},
+		{name("suid", "accountId"), strconv.Itoa(int(tty.Stat.Uid))},
+	}
+
+	// The session's origin is an address when it came over the network.
+	if tty.Host != "" {
+		if net.ParseIP(tty.Host) != nil {
+			fields = append(fields, siemField{"src", tty.Host})
+		} else {
+			fields = append(fields, siemField{name("shost", "srcHostName"), tty.Host})
+		}
+	}
+
+	if len(tty.Processes) > 0 && tty.Processes[0].PID != 0 {
+		fields = append(fields,
+			siemField{name("spid", "pid"), strconv.Itoa(tty.Processes[0].PID)},
+			siemField{name("sproc", "proc"), tty.Processes[0].Comm})
+	}
+
+	if host, err := os.Hostname(); err == nil {
+		fields = append(fields, siemField{name("dvchost", "identHostName"), host})
+	}
+
+	if leef {
+		return append(fields,
+			siemField{"devTime", strconv.FormatInt(at.UnixMilli(), 10)},
+			siemField{"sev", strconv.Itoa(severity)},
+			siemField{"tty", tty.Name},
+			siemField{"command", sessionCommand(tty)})
+	}
+
+	return append(fields,
+		siemField{"rt", strconv.FormatInt(at.UnixMilli(), 10)},
+		siemField{"cs1Label", "tty"}, siemField{"cs1", tty.Name},
+		siemField{"cs2Label", "command"}, siemField{"cs2", sessionCommand(tty)})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// cefLine formats an event in ArcSight's Common Event Format.
+func cefLine(event what.SessionEvent) string {
+	header := fmt.Sprintf("CEF:0|go-what|go-what|%s|session-%s|Session %s|%d|",
+		cefHeaderEscaper.Replace(readBuildInfo().Version), event.Type, event.Type, siemSeverity)
+
+	return cefRecord(header,
+		siemFields(event.Type.String(), event.Time, event.TTY, siemSeverity, false))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// cefAlertLine formats an alert in CEF, with its message as msg.
+func cefAlertLine(a alert) string {
+	header := fmt.Sprintf("CEF:0|go-what|go-what|%s|alert-%s|Alert %s|%d|",
+		cefHeaderEscaper.Replace(readBuildInfo().Version), a.Rule, a.Rule, siemAlertSeverity)
+
+	return cefRecord(header, append(siemFields("alert", a.Time, a.TTY, siemAlertSeverity, false),
+		siemField{"msg", a.Message}))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// cefRecord follows a CEF header with the fields as its extension.
+func cefRecord(header string, fields []siemField) string {
+	var b strings.Builder
+
+	b.WriteString(header)
+
+	for i, field := range fields {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+
+		b.WriteString(field.Key + "=" + cefValueEscaper.Replace(field.Value))
+	}
+
+	return b.String()
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// leefLine formats an event in QRadar's Log Event Extended Format, version 1.0, whose devTime
+// may be milliseconds since the epoch.
+func leefLine(event what.SessionEvent) string {
+	header := fmt.Sprintf("LEEF:1.0|go-what|go-what|%s|session-%s|",
+		cefHeaderEscaper.Replace(readBuildInfo().Version), event.Type)
+
+	return leefRecord(header,
+		siemFields(event.Type.String(), event.Time, event.TTY, siemSeverity, true))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// leefAlertLine formats an alert in LEEF, with its message as msg.
+func leefAlertLine(a alert) string {
+	header := fmt.Sprintf("LEEF:1.0|go-what|go-what|%s|alert-%s|",
+		cefHeaderEscaper.Replace(readBuildInfo().Version), a.Rule)
+
+	return leefRecord(header, append(siemFields("alert", a.Time, a.TTY, siemAlertSeverity, true),
+		siemField{"msg", a.Message}))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// leefRecord follows a LEEF header with the fields as its tab-separated attributes.
+func leefRecord(header string, fields []siemField) string {
+	var b strings.Builder
+
+	b.WriteString(header)
+
+	for i, field := range fields {
+		if i > 0 {
+			b.WriteByte('\t')
+		}
+
+		b.WriteString(field.Key + "=" + leefValueEscaper.Replace(field.Value))
+	}
+
+	return b.String()
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,121 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - siem_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 0d019e38-c7b4-11f1-a87f-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestSIEMEscaping(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		replacer *strings.Replacer
+		in, want string
+	}{
+		{"a CEF header", cefHeaderEscaper, `1.0|beta\2`, `1.0\|beta\\2`},
+		{"a CEF header's newlines", cefHeaderEscaper, "one\ntwo\r", "one two "},
+		{"a CEF header's equals", cefHeaderEscaper, "a=b", "a=b"},
+		{"a CEF value", cefValueEscaper, `a=b\c`, `a\=b\\c`},
+		{"a CEF value's newlines", cefValueEscaper, "one\r\ntwo", `one\r\ntwo`},
+		{"a CEF value's pipes", cefValueEscaper, "a|b", "a|b"},
+		{"a LEEF value", leefValueEscaper, "a\tb\nc\rd", "a b c d"},
+		{"a LEEF value's specials", leefValueEscaper, `a=|\b`, `a=|\b`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.replacer.Replace(tc.in); got != tc.want {
+				t.Errorf("escaping %q gave %q, want %q",
+					tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestSIEMRecords(t *testing.T) {
+	fields := []siemField{
+		{"act", "added"},
+		{"cs2", "grep a=b | tail\n"},
+		{"msg", `C:\temp`},
+	}
+
+	if got, want := cefRecord("CEF:0|h|", fields),
+		`CEF:0|h|act=added cs2=grep a\=b | tail\n msg=C:\\temp`; got != want {
+		t.Errorf("cefRecord = %q, want %q",
+			got, want)
+	}
+
+	if got, want := leefRecord("LEEF:1.0|h|", fields),
+		"LEEF:1.0|h|act=added\tcs2=grep a=b | tail \tmsg=C:\\temp"; got != want {
+		t.Errorf("leefRecord = %q, want %q",
+			got, want)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestSIEMLines(t *testing.T) {
+	tty := remoteTTY("pts/3", 1000, "bastion.example")
+	tty.Processes = []*what.Process{{PID: 77, Comm: "sh", Cmdline: "sh\x00-c\x00x=1\nls | wc\x00"}}
+	event := what.SessionEvent{
+		Type: what.SessionAdded,
+		Time: time.UnixMilli(1767323045000),
+		TTY:  tty,
+	}
+
+	for _, tc := range []struct {
+		name string
+		line string
+		want []string
+	}{
+		{"cef", cefLine(event), []string{
+			"|session-added|Session added|3|act=added suser=alice suid=1000 ",
+			" shost=bastion.example spid=77 sproc=sh ",
+			" rt=1767323045000 cs1Label=tty cs1=pts/3 cs2Label=command cs2=sh -c x\\=1\\nls | wc",
+		}},
+		{"leef", leefLine(event), []string{
+			"LEEF:1.0|go-what|go-what|", "|session-added|action=added\tusrName=alice\t",
+			"\tsrcHostName=bastion.example\tpid=77\tproc=sh\t",
+			"\tdevTime=1767323045000\tsev=3\ttty=pts/3\tcommand=sh -c x=1 ls | wc",
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if strings.ContainsAny(tc.line, "\n\r") {
+				t.Errorf("%q is more than a line",
+					tc.line)
+			}
+
+			for _, want := range tc.want {
+				if !strings.Contains(tc.line, want) {
+					t.Errorf("%q does not contain %q",
+						tc.line, want)
+				}
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
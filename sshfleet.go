@@ -0,0 +1,198 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - sshfleet.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 89f0451e-c7a5-11f1-9293-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnsonjh/go-what/whatjson"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sshConfigPath is the user's ssh_config, or "" if the home directory is unknown.
+func sshConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".ssh", "config")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sshConfigAliases returns the Host aliases of the ssh_config at name and of the files it
+// Includes, in order of appearance.  Patterns (anything with *, ?, or !) are left out, since
+// they name no host of their own.  Relative Include paths are taken from ~/.ssh, as ssh does
+// for the user's configuration.
+func sshConfigAliases(name string) []string {
+	var aliases []string
+
+	var read func(name string, depth int)
+
+	read = func(name string, depth int) {
+		f, err := os.Open(name)
+		if err != nil || depth > 16 {
+			return
+		}
+
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			// The keyword is separated from its arguments by whitespace, an "=", or both.
+			keyword, args := line, ""
+			if i := strings.IndexAny(line, " \t="); i >= 0 {
+				keyword, args = line[:i], line[i:]
+			}
+
+			args = strings.TrimSpace(args)
+			args = strings.TrimSpace(strings.TrimPrefix(args, "="))
+
+			switch strings.ToLower(keyword) {
+			case "host":
+				for _, alias := range strings.Fields(args) {
+					alias = strings.Trim(alias, `"`)
+					if !strings.ContainsAny(alias, "*?!") && !slices.Contains(aliases, alias) {
+						aliases = append(aliases, alias)
+					}
+				}
+
+			case "include":
+				for _, pattern := range strings.Fields(args) {
+					if strings.HasPrefix(pattern, "~/") {
+						home, _ := os.UserHomeDir()
+						pattern = filepath.Join(home, pattern[2:])
+					} else if !filepath.IsAbs(pattern) {
+						pattern = filepath.Join(filepath.Dir(sshConfigPath()), pattern)
+					}
+
+					matches, _ := filepath.Glob(pattern)
+					for _, match := range matches {
+						read(match, depth+1)
+					}
+				}
+			}
+		}
+	}
+
+	read(name, 0)
+
+	return aliases
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// expandHosts turns the --hosts arguments into host names: a pattern (with * or ?) stands for
+// every alias it matches, in the order of the configuration; anything else is a host name as
+// ssh would take it.  A host named twice is kept where it first appears.
+func expandHosts(patterns, aliases []string) []string {
+	var hosts []string
+
+	seen := make(map[string]bool)
+	add := func(host string) {
+		if !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, "*?[") {
+			add(pattern)
+
+			continue
+		}
+
+		for _, alias := range aliases {
+			if ok, _ := path.Match(pattern, alias); ok {
+				add(alias)
+			}
+		}
+	}
+
+	return hosts
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fetchSSH runs go-what on host through the ssh client, so that the connection is made exactly
+// as an interactive ssh would make it (ProxyJump, User, IdentityFile, agent, known hosts).  The
+// client is run in batch mode: a host that would prompt for a password fails instead.  Any
+// options are passed to ssh before the host.
+func fetchSSH(ctx context.Context, options []string, host, remoteCommand string,
+	connectTimeout time.Duration,
+) (*whatjson.Snapshot, error) {
+	var stdout, stderr bytes.Buffer
+
+	// ssh takes its ConnectTimeout in whole seconds.
+	seconds := max(int((connectTimeout+time.Second-1)/time.Second), 1)
+
+	args := append([]string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=" + strconv.Itoa(seconds)},
+		options...)
+	args = append(args, "--", host, remoteCommand+" --format json")
+
+	cmd := exec.CommandContext(ctx, "ssh", args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	// A ProxyJump or ControlMaster child can hold the output open after ssh itself is killed.
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if err != nil && stdout.Len() == 0 {
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		if message := lines[len(lines)-1]; message != "" {
+			return nil, errors.New(message)
+		}
+
+		return nil, err
+	}
+
+	var doc whatjson.Snapshot
+
+	if err := json.Unmarshal(stdout.Bytes(), &doc); err != nil {
+		return nil, fmt.Errorf("%s printed something other than a snapshot: %w",
+			remoteCommand, err)
+	}
+
+	return &doc, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
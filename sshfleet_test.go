@@ -0,0 +1,98 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - sshfleet_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: fd17f09b-c7b1-11f1-96e6-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestSSHConfigAliases(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(filepath.Join(dir, "config.d"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range map[string]string{
+		"config": "# fleet\n" +
+			"Host web1 web2\n" +
+			"\tUser ops\n" +
+			"Host\tdb1\n" +
+			"host=db2\n" +
+			"HOST = \"db3\" web1\n" +
+			"Host *.example !bastion web?\n" +
+			"Include config.d/*.conf ~/.ssh/extra\n" +
+			"Include " + filepath.Join(dir, "missing") + "\n" +
+			"Host last\n",
+		"config.d/a.conf": "Host included-a\n",
+		"config.d/b.conf": "Include ~/.ssh/config.d/b.conf\nHost included-b\n",
+		"extra":           "Host=extra\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// config.d/b.conf includes itself, until the depth limit; each alias is still listed once.
+	want := []string{
+		"web1", "web2", "db1", "db2", "db3", "included-a", "included-b", "extra", "last",
+	}
+	if got := sshConfigAliases(filepath.Join(dir, "config")); !slices.Equal(got, want) {
+		t.Errorf("sshConfigAliases = %q, want %q",
+			got, want)
+	}
+
+	if got := sshConfigAliases(filepath.Join(dir, "absent")); got != nil {
+		t.Errorf("sshConfigAliases of a missing file = %q",
+			got)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestExpandHosts(t *testing.T) {
+	aliases := []string{"web2", "db1", "web1", "web10"}
+
+	for _, tc := range []struct {
+		patterns []string
+		want     []string
+	}{
+		{[]string{"web*"}, []string{"web2", "web1", "web10"}},
+		{[]string{"web?"}, []string{"web2", "web1"}},
+		{[]string{"web[12]", "db1"}, []string{"web2", "web1", "db1"}},
+		{[]string{"db1", "*"}, []string{"db1", "web2", "web1", "web10"}},
+		{[]string{"user@other.example", "web1", "web1"}, []string{"user@other.example", "web1"}},
+		{[]string{"cache*"}, nil},
+	} {
+		if got := expandHosts(tc.patterns, aliases); !slices.Equal(got, tc.want) {
+			t.Errorf("expandHosts(%q) = %q, want %q",
+				tc.patterns, got, tc.want)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,239 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - statsd.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: dfa31bb9-c7a6-11f1-bb3a-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// statsdPacket is the largest datagram sent to StatsD, to stay within a typical MTU.
+const statsdPacket = 1432
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// statsdConfig says where and how go-what serve pushes gauges to StatsD.
+type statsdConfig struct {
+	Addr     string
+	Interval time.Duration
+	Prefix   string
+
+	// Tags sends DogStatsD tags (host, tty_class, and user); without them, the tag values of
+	// a gauge are appended to its name instead, as plain StatsD has no tags.
+	Tags bool
+
+	// PerUser adds gauges per user, as for --metrics-per-user.
+	PerUser bool
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// statsdGauge is one gauge of a push, with its tags as key:value pairs.
+type statsdGauge struct {
+	Name  string
+	Value float64
+	Tags  []string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// statsdGauges summarizes the snapshot as gauges: sessions and their longest idle time by tty
+// class, users, processes without a terminal, and, if perUser, sessions and longest idle time
+// by user.
+func statsdGauges(snap *what.Snapshot, perUser bool) []statsdGauge {
+	sessions := make(map[string]int)
+	idle := make(map[string]float64)
+	userSessions := make(map[string]int)
+	userIdle := make(map[string]float64)
+
+	for _, tty := range snap.TTYs {
+		if len(tty.Processes) == 0 {
+			continue
+		}
+
+		// A graphical session counts, but its idle time is not known.
+		idleFor, known := tty.Idle(snap.Time)
+		seconds := max(idleFor.Seconds(), 0)
+
+		sessions[tty.Type]++
+		if known {
+			idle[tty.Type] = max(idle[tty.Type], seconds)
+		}
+
+		if perUser {
+			name := username(tty.Stat.Uid)
+			userSessions[name]++
+
+			if known {
+				userIdle[name] = max(userIdle[name], seconds)
+			}
+		}
+	}
+
+	noTTY := 0
+	for _, n := range snap.NoTTY {
+		noTTY += n
+	}
+
+	gauges := []statsdGauge{
+		{Name: "users", Value: float64(snap.Users)},
+		{Name: "notty_processes", Value: float64(noTTY)},
+	}
+
+	for _, class := range slices.Sorted(maps.Keys(sessions)) {
+		tags := []string{"tty_class:" + class}
+		gauges = append(gauges,
+			statsdGauge{Name: "sessions", Value: float64(sessions[class]), Tags: tags})
+
+		if seconds, ok := idle[class]; ok {
+			gauges = append(gauges,
+				statsdGauge{Name: "session_idle_max", Value: seconds, Tags: tags})
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(userSessions)) {
+		tags := []string{"user:" + name}
+		gauges = append(gauges,
+			statsdGauge{Name: "user.sessions", Value: float64(userSessions[name]), Tags: tags})
+
+		if seconds, ok := userIdle[name]; ok {
+			gauges = append(gauges,
+				statsdGauge{Name: "user.session_idle_max", Value: seconds, Tags: tags})
+		}
+	}
+
+	return gauges
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// statsdName makes s safe in a StatsD name or tag, which cannot hold the protocol's separators.
+func statsdName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(":|@#,\n ", r) {
+			return '_'
+		}
+
+		return r
+	}, s)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// statsdLine formats a gauge as a line of the StatsD protocol, tagged with the host.
+func statsdLine(config statsdConfig, host string, g statsdGauge) string {
+	value := strconv.FormatFloat(g.Value, 'f', -1, 64)
+
+	if !config.Tags {
+		name := config.Prefix + g.Name
+		for _, tag := range g.Tags {
+			_, v, _ := strings.Cut(tag, ":")
+			name += "." + strings.ReplaceAll(statsdName(v), ".", "_")
+		}
+
+		return statsdName(name) + ":" + value + "|g"
+	}
+
+	tags := make([]string, 0, len(g.Tags)+1)
+	if host != "" {
+		tags = append(tags, "host:"+statsdName(host))
+	}
+
+	for _, tag := range g.Tags {
+		k, v, _ := strings.Cut(tag, ":")
+		tags = append(tags, k+":"+statsdName(v))
+	}
+
+	line := statsdName(config.Prefix+g.Name) + ":" + value + "|g"
+	if len(tags) > 0 {
+		line += "|#" + strings.Join(tags, ",")
+	}
+
+	return line
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// pushStatsD collects with opts at every interval and sends the gauges to StatsD over UDP, as
+// few datagrams as fit, until ctx is done.  Failures are reported and the push retried at the
+// next interval.
+func pushStatsD(ctx context.Context, opts what.Options, config statsdConfig, host string) {
+	conn, err := net.Dial("udp", config.Addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-what: serve: --statsd: %v\n",
+			err)
+
+		return
+	}
+
+	defer conn.Close()
+
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if snap, err := what.Collect(ctx, opts); err == nil {
+			var packet bytes.Buffer
+
+			for _, g := range statsdGauges(snap, config.PerUser) {
+				line := statsdLine(config, host, g)
+
+				if packet.Len() > 0 && packet.Len()+1+len(line) > statsdPacket {
+					_, _ = conn.Write(packet.Bytes())
+					packet.Reset()
+				}
+
+				if packet.Len() > 0 {
+					packet.WriteByte('\n')
+				}
+
+				packet.WriteString(line)
+			}
+
+			if _, err := conn.Write(packet.Bytes()); err != nil {
+				fmt.Fprintf(os.Stderr, "go-what: serve: --statsd: %v\n",
+					err)
+			}
+		} else if ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "go-what: serve: --statsd: %v\n",
+				err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,152 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - statsd_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: a9924e35-c7b4-11f1-98ff-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestStatsdGauges(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	bash := []*what.Process{{PID: 1, Comm: "bash"}}
+
+	root := idleTTY("pts/0", 0, now, 2*time.Hour)
+	alice := idleTTY("pts/1", 1000, now, 30*time.Second)
+	console := idleTTY("tty1", 1000, now, time.Minute)
+	graphical := idleTTY("tty2", 1000, now, 0)
+	graphical.Graphical = true
+	empty := idleTTY("pts/2", 1001, now, 0)
+
+	root.Type, alice.Type, empty.Type = "pty", "pty", "pty"
+	console.Type, graphical.Type = "vt", "vt"
+
+	for _, tty := range []*what.TTY{root, alice, console, graphical} {
+		tty.Processes = bash
+	}
+
+	snap := &what.Snapshot{
+		Time: now, Users: 2, NoTTY: map[uint32]int{0: 40, 1000: 2},
+		TTYs: []*what.TTY{root, alice, console, graphical, empty},
+	}
+
+	want := []statsdGauge{
+		{Name: "users", Value: 2},
+		{Name: "notty_processes", Value: 42},
+		{Name: "sessions", Value: 2, Tags: []string{"tty_class:pty"}},
+		{Name: "session_idle_max", Value: 7200, Tags: []string{"tty_class:pty"}},
+		{Name: "sessions", Value: 2, Tags: []string{"tty_class:vt"}},
+		{Name: "session_idle_max", Value: 60, Tags: []string{"tty_class:vt"}},
+	}
+
+	if got := statsdGauges(snap, false); !reflect.DeepEqual(got, want) {
+		t.Errorf("statsdGauges = %+v, want %+v",
+			got, want)
+	}
+
+	want = append(want,
+		statsdGauge{Name: "user.sessions", Value: 3, Tags: []string{"user:alice"}},
+		statsdGauge{Name: "user.session_idle_max", Value: 60, Tags: []string{"user:alice"}},
+		statsdGauge{Name: "user.sessions", Value: 1, Tags: []string{"user:root"}},
+		statsdGauge{Name: "user.session_idle_max", Value: 7200, Tags: []string{"user:root"}})
+
+	if got := statsdGauges(snap, true); !reflect.DeepEqual(got, want) {
+		t.Errorf("statsdGauges per user = %+v, want %+v",
+			got, want)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestStatsdLine(t *testing.T) {
+	idle := statsdGauge{
+		Name: "user.session_idle_max", Value: 1.5, Tags: []string{"user:j.doe@corp"},
+	}
+	users := statsdGauge{Name: "users", Value: 3}
+
+	for _, tc := range []struct {
+		name   string
+		config statsdConfig
+		host   string
+		gauge  statsdGauge
+		want   string
+	}{
+		{
+			"tagged",
+			statsdConfig{Prefix: "what.", Tags: true},
+			"web 1", idle,
+			"what.user.session_idle_max:1.5|g|#host:web_1,user:j.doe_corp",
+		},
+		{"tagged without a host", statsdConfig{Tags: true}, "", users, "users:3|g"},
+		{
+			"tagged with only a host",
+			statsdConfig{Tags: true},
+			"web1", users,
+			"users:3|g|#host:web1",
+		},
+		{
+			// A tag value's dots would add levels to the name.
+			"plain",
+			statsdConfig{Prefix: "what."},
+			"web1", idle,
+			"what.user.session_idle_max.j_doe_corp:1.5|g",
+		},
+		{
+			"plain with separators",
+			statsdConfig{Prefix: "a|b:"},
+			"",
+			statsdGauge{
+				Name: "sessions", Value: 1, Tags: []string{"tty_class:x,y#z"},
+			},
+			"a_b_sessions.x_y_z:1|g",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := statsdLine(tc.config, tc.host, tc.gauge); got != tc.want {
+				t.Errorf("statsdLine = %q, want %q",
+					got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestStatsdName(t *testing.T) {
+	for s, want := range map[string]string{
+		"alice":          "alice",
+		"j.doe":          "j.doe",
+		"a:b|c@d#e,f g":  "a_b_c_d_e_f_g",
+		"line\nbreak":    "line_break",
+		"ünïcode-user_1": "ünïcode-user_1",
+	} {
+		if got := statsdName(s); got != want {
+			t.Errorf("statsdName(%q) = %q, want %q",
+				s, got, want)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,282 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - syslog.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 3d2ac964-c7a7-11f1-9f6a-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"cmp"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// syslogSDID is the SD-ID of the structured data element of go-what's syslog messages, under
+// the private enterprise number that RFC 5612 reserves for documentation and examples.
+const syslogSDID = "what@32473"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// syslogFacilities are the facility names of --syslog-facility, in order of their codes.
+var syslogFacilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron",
+	"authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// syslogSeverities are the severity names of --syslog-severity, in order of their codes.
+var syslogSeverities = []string{
+	"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug",
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sdEscaper escapes an SD-PARAM value.
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// syslogSink sends every event as an RFC 5424 message with a structured data element, over
+// UDP (a message per datagram), TCP, or TLS (RFC 5425 octet counting), or to a local socket.
+// A stream that breaks is dialed again for the next message.
+type syslogSink struct {
+	network string
+	addr    string
+	tls     *tls.Config
+	pri     int
+
+	hostname string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// newSyslogSink returns a sink for target, one of udp://host[:port], tcp://host[:port],
+// tls://host[:port] (ports 514, 601, and 6514 by default), or unix:///path (a datagram socket
+// such as /dev/log), which sends at the named facility and severity.  tlsConfig verifies TLS
+// servers.
+func newSyslogSink(target, facility, severity string, tlsConfig *tls.Config) (*syslogSink, error) {
+	f := slices.Index(syslogFacilities, facility)
+	s := slices.Index(syslogSeverities, severity)
+
+	if f < 0 {
+		return nil, fmt.Errorf("unknown facility %q",
+			facility)
+	}
+
+	if s < 0 {
+		return nil, fmt.Errorf("unknown severity %q",
+			severity)
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+
+	sink := &syslogSink{pri: f*8 + s}
+	sink.hostname, _ = os.Hostname()
+
+	defaultPort := map[string]string{"udp": "514", "tcp": "601", "tls": "6514"}
+
+	switch u.Scheme {
+	case "udp", "tcp", "tls":
+		sink.network, sink.addr = u.Scheme, u.Host
+		if u.Port() == "" {
+			sink.addr = net.JoinHostPort(u.Hostname(), defaultPort[u.Scheme])
+		}
+
+		if u.Scheme == "tls" {
+			sink.network, sink.tls = "tcp", tlsConfig.Clone()
+			sink.tls.ServerName = u.Hostname()
+		}
+
+	case "unix":
+		sink.network, sink.addr = "unixgram", u.Path
+
+	default:
+		return nil, fmt.Errorf("%q is not udp://, tcp://, tls://, or unix://",
+			target)
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	if err := sink.dial(); err != nil {
+		return nil, err
+	}
+
+	return sink, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// dial connects to the server; s.mu is held.  s.conn is left nil if it cannot: a failed TLS
+// dial returns a nil *tls.Conn, which as a net.Conn would not be.
+func (s *syslogSink) dial() error {
+	var conn net.Conn
+
+	var err error
+
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+
+	if s.tls != nil {
+		conn, err = tls.DialWithDialer(&dialer, s.network, s.addr, s.tls)
+	} else {
+		conn, err = dialer.Dial(s.network, s.addr)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	s.conn = conn
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// syslogMessage formats an event as an RFC 5424 message, with the event type as its MSGID.
+func (s *syslogSink) syslogMessage(event what.SessionEvent) string {
+	tty := event.TTY
+	user := username(tty.Stat.Uid)
+
+	params := [][2]string{
+		{"event", event.Type.String()},
+		{"user", user},
+		{"uid", strconv.Itoa(int(tty.Stat.Uid))},
+		{"tty", tty.Name},
+		{"from", tty.Host},
+	}
+
+	if len(tty.Processes) > 0 && tty.Processes[0].PID != 0 {
+		params = append(params, [2]string{"pid", strconv.Itoa(tty.Processes[0].PID)})
+	}
+
+	return s.format(s.pri, event.Time, event.Type.String(), params,
+		user+" "+tty.Name+": "+sessionCommand(tty))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// alertMessage formats an alert as an RFC 5424 message with the MSGID alert, at warning if the
+// --syslog-severity is any lower.
+func (s *syslogSink) alertMessage(a alert) string {
+	params := [][2]string{
+		{"alert", a.Rule},
+		{"user", username(a.TTY.Stat.Uid)},
+		{"uid", strconv.Itoa(int(a.TTY.Stat.Uid))},
+		{"tty", a.TTY.Name},
+		{"from", a.TTY.Host},
+	}
+
+	warning := slices.Index(syslogSeverities, "warning")
+
+	return s.format(s.pri&^7|min(s.pri&7, warning), a.Time, "alert", params, a.Message)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// format assembles an RFC 5424 message, leaving out the params with no value.
+func (s *syslogSink) format(pri int, at time.Time, msgID string, params [][2]string,
+	msg string,
+) string {
+	var sd strings.Builder
+
+	sd.WriteString("[" + syslogSDID)
+
+	for _, param := range params {
+		if param[1] != "" {
+			fmt.Fprintf(&sd, ` %s="%s"`,
+				param[0], sdEscaper.Replace(param[1]))
+		}
+	}
+
+	sd.WriteString("]")
+
+	return fmt.Sprintf("<%d>1 %s %s go-what %d %s %s %s",
+		pri, at.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		cmp.Or(s.hostname, "-"), os.Getpid(), msgID, sd.String(), msg)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Send implements eventSink.
+func (s *syslogSink) Send(event what.SessionEvent) error {
+	return s.write(s.syslogMessage(event))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Alert implements eventSink.
+func (s *syslogSink) Alert(a alert) error {
+	return s.write(s.alertMessage(a))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// write sends a message, dialing again once if a stream has broken.
+func (s *syslogSink) write(message string) error {
+	// Streams frame each message with its length; datagrams are a message each.
+	if s.network == "tcp" {
+		message = strconv.Itoa(len(message)) + " " + message
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if s.conn == nil {
+			if err := s.dial(); err != nil {
+				return fmt.Errorf("syslog: %w",
+					err)
+			}
+		}
+
+		_, err := s.conn.Write([]byte(message))
+		if err == nil {
+			return nil
+		}
+
+		_ = s.conn.Close()
+		s.conn = nil
+
+		if attempt > 0 || s.network != "tcp" {
+			return fmt.Errorf("syslog: %w",
+				err)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
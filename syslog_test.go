@@ -0,0 +1,157 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - syslog_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 117db54a-c7b3-11f1-8b6a-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestSyslogFormat(t *testing.T) {
+	sink := &syslogSink{pri: 10*8 + 6, hostname: "host1"}
+	at := time.Date(2026, 1, 2, 3, 4, 5, 678901000, time.FixedZone("EST", -5*3600))
+	header := func(pri int, hostname string) string {
+		return fmt.Sprintf("<%d>1 2026-01-02T08:04:05.678901Z %s go-what %d ",
+			pri, hostname, os.Getpid())
+	}
+
+	tty := remoteTTY("pts/1", 1000, `bad"host]\x`)
+	tty.Processes = []*what.Process{{PID: 4242, Cmdline: "vim\x00notes.txt\x00"}}
+
+	for _, tc := range []struct {
+		name string
+		got  string
+		want string
+	}{
+		{
+			"an event", sink.syslogMessage(what.SessionEvent{
+				Type: what.SessionAdded, Time: at, TTY: tty,
+			}),
+			header(86, "host1") +
+				`added [what@32473 event="added" user="alice" uid="1000" tty="pts/1" ` +
+				`from="bad\"host\]\\x" pid="4242"] alice pts/1: vim notes.txt`,
+		},
+		{
+			// Empty params are left out, and the pid of a session without processes.
+			"a local event", sink.syslogMessage(what.SessionEvent{
+				Type: what.SessionRemoved, Time: at, TTY: remoteTTY("tty1", 0, ""),
+			}),
+			header(86, "host1") +
+				`removed [what@32473 event="removed" user="root" uid="0" tty="tty1"] root tty1: `,
+		},
+		{
+			"no hostname", (&syslogSink{pri: 13}).format(13, at, "alert", nil, "hi"),
+			header(13, "-") + "alert [what@32473] hi",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.got != tc.want {
+				t.Errorf("got  %q\nwant %q",
+					tc.got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestSyslogStream(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer ln.Close()
+
+	received := make(chan string, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+
+		length, _ := r.ReadString(' ')
+		n, _ := strconv.Atoi(strings.TrimSpace(length))
+		message := make([]byte, n)
+		_, _ = r.Read(message)
+		received <- length + string(message)
+	}()
+
+	sink, err := newSyslogSink("tcp://"+ln.Addr().String(), "user", "notice", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sink.Alert(testAlert); err != nil {
+		t.Fatal(err)
+	}
+
+	// RFC 6587 octet counting: the length, a space, and the message.
+	message := sink.alertMessage(testAlert)
+	if got, want := <-received, strconv.Itoa(len(message))+" "+message; got != want {
+		t.Errorf("the server received %q, want %q",
+			got, want)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestSyslogRedialFails(t *testing.T) {
+	// A port nothing listens on any more.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	sink := &syslogSink{network: "tcp", addr: addr, tls: &tls.Config{ServerName: "127.0.0.1"}}
+
+	for i := range 2 {
+		if err := sink.Alert(testAlert); err == nil {
+			t.Errorf("write %d to a closed port succeeded",
+				i+1)
+		}
+
+		if sink.conn != nil {
+			t.Fatalf("after write %d failed to dial, the connection is %#v",
+				i+1, sink.conn)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,855 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - table.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 9cb13156-c7a1-11f1-a627-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/johnsonjh/go-what/what"
+	"golang.org/x/term"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// tableOptions controls the table renderer.
+type tableOptions struct {
+	// Type shows whether each terminal is a console, a VT, a pty, or a serial line.
+	Type bool
+
+	Origin     bool
+	Container  bool
+	Namespaces bool
+	Audit      bool
+	SSHKey     bool
+	AuthMethod bool
+	SetUID     bool
+	Group      bool
+	IdleRatio  bool
+
+	// Rate adds a column with the bytes per second written to each terminal, from
+	// TTY.OutputRate; the snapshot must have been measured with Snapshot.MeasureOutput.
+	Rate bool
+
+	// FullNames follows each user name with the user's display name, in parentheses.
+	FullNames bool
+	PID       bool
+	PPID      bool
+	Time      bool
+
+	// Comm shows each process's executable name, from its stat, rather than its command line.
+	Comm bool
+
+	// StripPaths shows commands by name, leaving out the directory they were run from.
+	StripPaths bool
+
+	// Scripts shows the script (or jar, and so on) interpreters run, from Process.Script; with
+	// Comm, that alone rather than the interpreter's name.
+	Scripts bool
+
+	// Sample shows the %CPU measured over Options.Sample.
+	Sample bool
+
+	// Procs, if set, adds a column with the number of processes each session's user has in
+	// all, from Snapshot.Processes.
+	Procs map[uint32]int
+
+	// Wtmp, if set, adds a column with each user's login before the current session.
+	Wtmp []what.Utmp
+
+	// Geo, if set, adds a column with where each session's remote address is, from its
+	// databases.
+	Geo *geoDB
+
+	// Ancestry shows the chain of commands leading to each foreground process.
+	Ancestry bool
+	Color    bool
+	Width    int // 0 means rows are never truncated
+
+	// FixedWidth keeps every column at its classic width whatever the data, truncating values
+	// that do not fit, so that each column always starts at the same offset.
+	FixedWidth bool
+
+	// Collapse joins a terminal's foreground processes onto one line, as a pipeline.
+	Collapse bool
+
+	// MergeDuplicates shows the sessions of one user running the same command as one row, the
+	// first of them, with their number after its terminal (as "pts/3 ×14").
+	MergeDuplicates bool
+
+	// Sort is the sortKeys entry the rows are ordered by, for underlining; "" means input.
+	Sort string
+
+	// HideNoTTY leaves out the summary of processes without a terminal.
+	HideNoTTY bool
+
+	// Link, if set, is a URL template each session's row links to, as an OSC 8 hyperlink; see
+	// linkURL.
+	Link string
+
+	// Selected, if set, is the name of a terminal whose rows are shown in reverse video.
+	Selected string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// useColor resolves a --color mode against standard output and the NO_COLOR convention.
+func useColor(mode string) bool {
+	switch mode {
+	case "always":
+		return true
+
+	case "never":
+		return false
+	}
+
+	return os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// useHyperlinks resolves a --color mode for hyperlinks, which NO_COLOR does not turn off: they
+// are sent to a terminal, or anywhere with always.  Terminals that do not support them ignore
+// them.
+func useHyperlinks(mode string) bool {
+	return mode == "always" || (mode == "auto" && term.IsTerminal(int(os.Stdout.Fd())))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// linkURL fills in the placeholders of a --link template for a row: {user}, {uid}, {tty},
+// {host} (the remote host the session is from, if any), and {hostname} (the host's own name).
+func linkURL(template string, snap *what.Snapshot, r *row) string {
+	return strings.NewReplacer(
+		"{user}", url.PathEscape(username(r.tty.Stat.Uid)),
+		"{uid}", strconv.Itoa(int(r.tty.Stat.Uid)),
+		"{tty}", url.PathEscape(r.tty.Name),
+		"{host}", url.PathEscape(r.tty.Host),
+		"{hostname}", url.PathEscape(snap.Hostname),
+	).Replace(template)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// outputWidth returns the width rows are truncated to: the terminal's, or none at all when
+// standard output is not a terminal.
+func outputWidth() int {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+
+	w, _, err := term.GetSize(fd)
+	if err != nil {
+		return 80
+	}
+
+	return w
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// row is one line of the table: a terminal and the foreground processes shown on that line, or
+// (when tty is nil) a user's summary of processes without a terminal.
+type row struct {
+	user    string
+	ttyName string
+	tty     *what.TTY
+	procs   []*what.Process
+	command string
+	summary string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// column is one column of the table.
+type column struct {
+	Header  string
+	Right   bool // right-aligned, for durations and counts
+	Sorted  bool // the column the rows are ordered by, underlined when in color
+	Summary bool // also shown on the no-terminal summary lines
+	Width   int  // the classic width of the column
+	Max     int  // the widest the column grows to fit its data; 0 means it is always Width
+	Value   func(r *row) string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// tableColumns returns the columns selected by opts, in order.  The last column is never padded
+// or capped, only cut off at the width of the terminal along with the rest of the line.
+func tableColumns(opts tableOptions) []column {
+	columns := []column{
+		{
+			Header: "USER", Summary: true, Sorted: opts.Sort == "user", Width: 8, Max: 32,
+			Value: func(r *row) string { return r.user },
+		},
+		{
+			Header: "TTY", Summary: true, Sorted: opts.Sort == "tty", Width: 7, Max: 20,
+			Value: func(r *row) string { return r.ttyName },
+		},
+	}
+
+	if opts.Type {
+		columns = append(columns, column{
+			Header: "TYPE", Width: 7, Max: 7,
+			Value: func(r *row) string { return r.tty.Type },
+		})
+	}
+
+	columns = append(columns, []column{
+		{
+			Header: "LOGIN", Right: true, Width: 6,
+			Value: func(r *row) string { return prettyTime(r.tty.Stat.Ctim.Sec) },
+		},
+		{
+			Header: "INPUT", Right: true, Sorted: cmp.Or(opts.Sort, "input") == "input", Width: 6,
+			Value: func(r *row) string { return idleTime(r.tty, r.tty.Stat.Atim.Sec) },
+		},
+		{
+			Header: "OUTPUT", Right: true, Width: 6,
+			Value: func(r *row) string { return idleTime(r.tty, r.tty.Stat.Mtim.Sec) },
+		},
+	}...)
+
+	// How much of the session's life it has been idle for, a better sign that it has been
+	// abandoned than either time alone.
+	if opts.IdleRatio {
+		columns = append(columns, column{
+			Header: "IDLE%", Right: true, Width: 5, Max: 5,
+			Value: func(r *row) string {
+				now := time.Now().Unix()
+				age, idle := now-r.tty.Stat.Ctim.Sec, now-r.tty.Stat.Atim.Sec
+
+				switch {
+				case r.tty.Graphical:
+					return "?"
+
+				case age <= 0:
+					return "0%"
+				}
+
+				return strconv.FormatInt(min(max(idle, 0)*100/age, 100), 10) + "%"
+			},
+		})
+	}
+
+	// How busy the terminal's output is, which tells a tail -f scrolling away from a session
+	// that is truly quiet, though both have just had output.
+	if opts.Rate {
+		columns = append(columns, column{
+			Header: "RATE", Right: true, Width: 5, Max: 6,
+			Value: func(r *row) string {
+				if r.tty.Graphical {
+					return "?"
+				}
+
+				return byteRate(r.tty.OutputRate)
+			},
+		})
+	}
+
+	if opts.Group {
+		columns = append(columns, column{
+			Header: "GROUP", Width: 8, Max: 32,
+			Value: func(r *row) string {
+				if groups := groupsOf(r.tty.Stat.Uid); groups != nil {
+					return groups[0]
+				}
+
+				return "-"
+			},
+		})
+	}
+
+	if opts.Procs != nil {
+		columns = append(columns, column{
+			Header: "PROCS", Right: true, Width: 5, Max: 7,
+			Value: func(r *row) string { return strconv.Itoa(opts.Procs[r.tty.Stat.Uid]) },
+		})
+	}
+
+	if opts.Origin {
+		columns = append(columns, column{
+			Header: "ORIGIN", Width: 20, Max: 40,
+			Value: func(r *row) string {
+				switch {
+				case r.tty.Host != "":
+					return cmp.Or(r.tty.Origin, "?") + " from " + r.tty.Host
+
+				case r.tty.Container != nil && r.tty.Container.ExecBy != nil:
+					return r.tty.Origin + " by " + username(r.tty.Container.ExecBy.UID)
+				}
+
+				return cmp.Or(r.tty.Origin, "-")
+			},
+		})
+	}
+
+	if opts.Container {
+		columns = append(columns, column{
+			Header: "CONTAINER", Width: 12, Max: 24,
+			Value: func(r *row) string {
+				if r.tty.Container == nil {
+					return "-"
+				}
+
+				return r.tty.Container.Name
+			},
+		})
+	}
+
+	// One letter per namespace not shared with init, e.g. "m n p" for a sandboxed shell.
+	if opts.Namespaces {
+		columns = append(columns, column{
+			Header: "NS", Width: 7, Max: 7,
+			Value: func(r *row) string {
+				letters := []string{}
+				for _, kind := range r.procs[0].Namespaces {
+					letters = append(letters, kind[:1])
+				}
+
+				return cmp.Or(strings.Join(letters, " "), "-")
+			},
+		})
+	}
+
+	// The audit session ID, flagged with "!" when the audit log does not know it.
+	if opts.Audit {
+		columns = append(columns, column{
+			Header: "AUDIT", Right: true, Width: 5, Max: 11,
+			Value: func(r *row) string {
+				p := r.procs[0]
+				if p.AuditSession == 0 {
+					return "-"
+				}
+
+				id := strconv.FormatUint(uint64(p.AuditSession), 10)
+				if p.AuditUnknown {
+					id += "!"
+				}
+
+				return id
+			},
+		})
+	}
+
+	// The key that authenticated an ssh login: a certificate by its ID, a plain key by its
+	// fingerprint.
+	if opts.SSHKey {
+		columns = append(columns, column{
+			Header: "KEY", Width: 20, Max: 52,
+			Value: func(r *row) string {
+				a := r.tty.SSHAuth
+
+				switch {
+				case a == nil || a.Fingerprint == "":
+					return "-"
+
+				case a.CertID != "":
+					return a.CertID + " (serial " + a.CertSerial + ")"
+				}
+
+				return a.Fingerprint
+			},
+		})
+	}
+
+	if opts.AuthMethod {
+		columns = append(columns, column{
+			Header: "AUTH", Width: 9, Max: 24,
+			Value: func(r *row) string {
+				if r.tty.SSHAuth == nil {
+					return "-"
+				}
+
+				return r.tty.SSHAuth.Method
+			},
+		})
+	}
+
+	// Blank for local sessions, names, and addresses no database has (or with no database).
+	if opts.Geo != nil {
+		columns = append(columns, column{
+			Header: "GEO", Width: 12, Max: 40,
+			Value: func(r *row) string { return opts.Geo.Describe(r.tty.Host) },
+		})
+	}
+
+	// Foreground processes whose real and effective UIDs differ, as real→effective.
+	if opts.SetUID {
+		columns = append(columns, column{
+			Header: "SETUID", Width: 6, Max: 32,
+			Value: func(r *row) string {
+				p := r.procs[0]
+				if p.RealUID == p.EffectiveUID {
+					return "-"
+				}
+
+				return username(p.RealUID) + "→" + username(p.EffectiveUID)
+			},
+		})
+	}
+
+	if opts.PID {
+		columns = append(columns, column{
+			Header: "PID", Right: true, Width: 7, Max: 7,
+			Value: func(r *row) string { return processID(r.procs[0].PID) },
+		})
+	}
+
+	if opts.PPID {
+		columns = append(columns, column{
+			Header: "PPID", Right: true, Width: 7, Max: 7,
+			Value: func(r *row) string { return processID(r.procs[0].PPID) },
+		})
+	}
+
+	if opts.Time {
+		columns = append(columns, column{
+			Header: "TIME", Right: true, Width: 7, Max: 9,
+			Value: func(r *row) string {
+				if r.procs[0].PID == 0 {
+					return "?"
+				}
+
+				var total time.Duration
+				for _, p := range r.procs {
+					total += p.CPU + p.ChildCPU
+				}
+
+				return cpuTime(total)
+			},
+		})
+	}
+
+	if opts.Sample {
+		columns = append(columns, column{
+			Header: "%CPU", Right: true, Sorted: opts.Sort == "cpu", Width: 5, Max: 6,
+			Value: func(r *row) string {
+				if r.procs[0].PID == 0 {
+					return "?"
+				}
+
+				var total float64
+				for _, p := range r.procs {
+					total += p.CPUPercent
+				}
+
+				return strconv.FormatFloat(total, 'f', 1, 64)
+			},
+		})
+	}
+
+	if opts.Wtmp != nil {
+		columns = append(columns, column{
+			Header: "PREVIOUS", Width: 24, Max: 40,
+			Value: func(r *row) string {
+				// r.user is for display, and with --full-names is not the name wtmp has.
+				prev, ok := what.PreviousLogin(opts.Wtmp, username(r.tty.Stat.Uid), r.tty.Name,
+					time.Unix(r.tty.Stat.Ctim.Unix()))
+				if !ok {
+					return "none"
+				}
+
+				return strings.TrimSpace(prettyTime(prev.Time.Unix())) + " ago " +
+					cmp.Or(prev.Host, prev.Line)
+			},
+		})
+	}
+
+	if opts.Ancestry {
+		columns = append(columns, column{
+			Header: "ANCESTRY", Width: 30, Max: 60,
+			Value: func(r *row) string {
+				return cmp.Or(strings.Join(r.procs[0].Ancestry, "→"), "?")
+			},
+		})
+	}
+
+	return append(columns, column{
+		Header: "WHAT",
+		Value: func(r *row) string {
+			command := r.command
+			if root := r.procs[0].Root; root != "" {
+				command = "[chroot " + root + "] " + command
+			}
+
+			if r.tty.Label != "" {
+				return "[" + r.tty.Label + "] " + command
+			}
+
+			return command
+		},
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// idleTime formats the time since a terminal's last input or output, which is unknown for
+// graphical sessions.
+func idleTime(tty *what.TTY, ts int64) string {
+	if tty.Graphical {
+		return "?"
+	}
+
+	return prettyTime(ts)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// processID formats a PID for the table; placeholder processes, whose PID is unknown, have none.
+func processID(pid int) string {
+	if pid == 0 {
+		return "?"
+	}
+
+	return strconv.Itoa(pid)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// cpuTime formats CPU time the way w formats JCPU and PCPU: seconds and hundredths under a minute,
+// minutes and seconds beyond.
+func cpuTime(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%d.%02ds",
+			d/time.Second, d%time.Second/(10*time.Millisecond))
+	}
+
+	return fmt.Sprintf("%d:%02d",
+		d/time.Minute, d%time.Minute/time.Second)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// byteRate formats a rate in bytes per second for the RATE column, as 0, 850, 4.2K, or 12M.
+func byteRate(rate float64) string {
+	if rate < 1024 {
+		return strconv.FormatFloat(rate, 'f', 0, 64)
+	}
+
+	for _, unit := range "KMG" {
+		rate /= 1024
+
+		switch {
+		case rate < 10:
+			return fmt.Sprintf("%.1f%c",
+				rate, unit)
+
+		case rate < 1024 || unit == 'G':
+			return fmt.Sprintf("%.0f%c",
+				rate, unit)
+		}
+	}
+
+	return "?"
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// username is the USER of uid's rows: its name, followed with opts.FullNames by its full name.
+func (opts tableOptions) username(uid uint32) string {
+	if !opts.FullNames {
+		return username(uid)
+	}
+
+	if full := fullName(uid); full != "" {
+		return username(uid) + " (" + full + ")"
+	}
+
+	return username(uid)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// mergeDuplicates replaces the rows with the same user, type, and command with the first of them,
+// counting how many there were on its terminal.
+func mergeDuplicates(rows []*row) []*row {
+	type key struct{ user, kind, command string }
+
+	var (
+		merged []*row
+		counts = make(map[key]int)
+		first  = make(map[key]*row)
+	)
+
+	for _, r := range rows {
+		k := key{r.user, r.tty.Type, r.command}
+		if counts[k]++; counts[k] == 1 {
+			first[k] = r
+			merged = append(merged, r)
+		}
+	}
+
+	for k, r := range first {
+		if counts[k] > 1 {
+			r.ttyName += " ×" + strconv.Itoa(counts[k])
+		}
+	}
+
+	return merged
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// usernsName labels a UID as seen inside a user namespace, whose names go-what does not know,
+// except that 0 is always root.
+func usernsName(uid uint32) string {
+	if uid == 0 {
+		return "root(userns)"
+	}
+
+	return strconv.Itoa(int(uid)) + "(userns)"
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// tableRows lays the snapshot out as table rows: one per foreground process (or one per terminal
+// with opts.Collapse), then the summary of processes without a terminal.
+func tableRows(snap *what.Snapshot, opts tableOptions) []*row {
+	var rows []*row
+
+	for _, tty := range snap.TTYs {
+		if len(tty.Processes) == 0 {
+			continue
+		}
+
+		name := opts.username(tty.Stat.Uid)
+
+		var lines []*row
+
+		for _, p := range tty.Processes {
+			command := p.Command()
+			switch {
+			case opts.Comm:
+				command = p.Comm
+
+			case opts.StripPaths && len(p.Argv) > 0 && strings.Contains(p.Argv[0], "/"):
+				command = strings.Join(append([]string{path.Base(p.Argv[0])}, p.Argv[1:]...),
+					" ")
+			}
+
+			if script := p.Script(); opts.Scripts && script != nil {
+				command = strings.Join(script, " ")
+				if opts.Comm {
+					command = script[1]
+				}
+			}
+
+			user := name
+			if uid, ok := p.InsideUID(tty.Stat.Uid); ok {
+				user = usernsName(uid)
+			}
+
+			switch {
+			case tty.LoginPrompt:
+				command = "LOGIN"
+
+			case tty.AtPrompt:
+				command = "(shell)"
+			}
+
+			if opts.Collapse && len(lines) > 0 {
+				lines[0].procs = append(lines[0].procs, p)
+				lines[0].command += " | " + command
+
+				continue
+			}
+
+			lines = append(lines, &row{
+				user: user, ttyName: tty.Name, tty: tty,
+				procs: []*what.Process{p}, command: command,
+			})
+		}
+
+		rows = append(rows, lines...)
+	}
+
+	if opts.MergeDuplicates {
+		rows = mergeDuplicates(rows)
+	}
+
+	if opts.HideNoTTY {
+		return rows
+	}
+
+	for _, uid := range snap.NoTTYUIDs() {
+		count := snap.NoTTY[uid]
+
+		name := opts.username(uid)
+
+		processString := "processes"
+
+		if count == 1 {
+			processString = "process"
+		}
+
+		rows = append(rows, &row{
+			user: name, ttyName: "none",
+			summary: fmt.Sprintf("%d more %s",
+				count, processString),
+		})
+	}
+
+	return rows
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// columnWidths sizes each column to its widest value (or header), up to the column's cap, unless
+// fixed is set.
+func columnWidths(columns []column, rows []*row, fixed bool) []int {
+	widths := make([]int, len(columns))
+
+	for i, c := range columns {
+		widths[i] = c.Width
+		if fixed || c.Max == 0 {
+			continue
+		}
+
+		widths[i] = utf8.RuneCountInString(c.Header)
+
+		for _, r := range rows {
+			if r.tty == nil && !c.Summary {
+				continue
+			}
+
+			widths[i] = max(widths[i], utf8.RuneCountInString(c.Value(r)))
+		}
+
+		widths[i] = min(widths[i], c.Max)
+	}
+
+	return widths
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// pad truncates or pads s to exactly width runes.
+func pad(s string, width int, right bool) string {
+	runes := []rune(s)
+	if len(runes) >= width {
+		return string(runes[:width])
+	}
+
+	if right {
+		return strings.Repeat(" ", width-len(runes)) + s
+	}
+
+	return s + strings.Repeat(" ", width-len(runes))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// printTable writes the classic w-style report to w.
+func printTable(w io.Writer, snap *what.Snapshot, opts tableOptions) {
+	fmt.Fprintf(w, " up %s  %2d users  load %s %s %s  procs %s\n",
+		strings.TrimSpace(prettyTime(time.Now().Unix()-int64(snap.Uptime))), snap.Users,
+		snap.Loadavg[0], snap.Loadavg[1], snap.Loadavg[2], snap.Loadavg[3])
+
+	columns := tableColumns(opts)
+	rows := tableRows(snap, opts)
+	widths := columnWidths(columns, rows, opts.FixedWidth)
+	last := len(columns) - 1
+
+	headers := make([]string, len(columns))
+
+	for i, c := range columns {
+		header := c.Header
+		if i < last {
+			header = pad(header, widths[i], c.Right)
+		}
+
+		if c.Sorted && opts.Color {
+			trimmed := strings.TrimSpace(header)
+			header = strings.Replace(header, trimmed, "\x1b[4m"+trimmed+"\x1b[0m", 1)
+		}
+
+		headers[i] = header
+	}
+
+	fmt.Fprintln(w, strings.Join(headers, " "))
+
+	uidColors := make(map[uint32]int)
+	colors := []int{32, 33, 35, 36}
+
+	for _, r := range rows {
+		if r.tty == nil {
+			var cells []string
+
+			for i, c := range columns {
+				if !c.Summary {
+					break
+				}
+
+				cells = append(cells, pad(c.Value(r), widths[i], c.Right))
+			}
+
+			fmt.Fprintln(w, strings.Join(append(cells, r.summary), " "))
+
+			continue
+		}
+
+		uid := r.tty.Stat.Uid
+		if _, ok := uidColors[uid]; !ok {
+			uidColors[uid] = len(uidColors) % len(colors)
+		}
+
+		color, reset := "", ""
+		if opts.Color {
+			color, reset = fmt.Sprintf("\x1b[%dm",
+				colors[uidColors[uid]]), "\x1b[0m"
+		}
+
+		if opts.Selected != "" && r.tty.Name == opts.Selected {
+			color, reset = "\x1b[7m"+color, "\x1b[0m"
+		}
+
+		cells := make([]string, len(columns))
+
+		for i, c := range columns {
+			cells[i] = c.Value(r)
+			if i < last {
+				cells[i] = pad(cells[i], widths[i], c.Right)
+			}
+		}
+
+		line := strings.Join(cells, " ")
+		if runes := []rune(line); opts.Width > 0 && len(runes) > opts.Width {
+			line = string(runes[:opts.Width])
+		}
+
+		if opts.Link != "" {
+			line = "\x1b]8;;" + linkURL(opts.Link, snap, r) + "\x1b\\" + line + "\x1b]8;;\x1b\\"
+		}
+
+		fmt.Fprintln(w, color+line+reset)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,156 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - table_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: d2262002-c7b4-11f1-8271-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestPad(t *testing.T) {
+	for _, tc := range []struct {
+		s     string
+		width int
+		right bool
+		want  string
+	}{
+		{"bob", 6, false, "bob   "},
+		{"bob", 6, true, "   bob"},
+		{"alice", 5, false, "alice"},
+		{"carol-admin", 5, false, "carol"},
+		{"carol-admin", 5, true, "carol"},
+		{"", 3, true, "   "},
+		{"x", 0, false, ""},
+		// Widths are in runes, not bytes.
+		{"日本語", 5, false, "日本語  "},
+		{"日本語のユーザー", 4, false, "日本語の"},
+		{"pts/3 ×14", 10, true, " pts/3 ×14"},
+	} {
+		if got := pad(tc.s, tc.width, tc.right); got != tc.want {
+			t.Errorf("pad(%q, %d, %v) = %q, want %q",
+				tc.s, tc.width, tc.right, got, tc.want)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestColumnWidths(t *testing.T) {
+	columns := []column{
+		{
+			Header: "USER", Summary: true, Width: 8, Max: 12,
+			Value: func(r *row) string { return r.user },
+		},
+		{Header: "TTY", Width: 7, Max: 20, Value: func(r *row) string { return r.ttyName }},
+		{Header: "LOGIN", Width: 6, Value: func(*row) string { return "a long time ago" }},
+		{Header: "COMMAND", Width: 3, Max: 3, Value: func(r *row) string { return r.command }},
+	}
+
+	tty := &what.TTY{}
+
+	for _, tc := range []struct {
+		name  string
+		rows  []*row
+		fixed bool
+		want  []int
+	}{
+		{"no rows", nil, false, []int{4, 3, 6, 3}},
+		{
+			"short values",
+			[]*row{{user: "bob", ttyName: "tty1", tty: tty, command: "vi"}},
+			false,
+			[]int{4, 4, 6, 3},
+		},
+		{
+			"wide runes",
+			[]*row{
+				{user: "日本語のユーザー", ttyName: "pts/3 ×14", tty: tty, command: "vi"},
+			},
+			false,
+			[]int{8, 9, 6, 3},
+		},
+		{
+			"capped",
+			[]*row{{user: "a-very-long-user-name", ttyName: "pts/0", tty: tty, command: "top"}},
+			false,
+			[]int{12, 5, 6, 3},
+		},
+		{
+			// A summary row only counts for the columns it is shown in.
+			"summary",
+			[]*row{{user: "postgres", ttyName: "a-summary-line-name", summary: "3 procs"}},
+			false,
+			[]int{8, 3, 6, 3},
+		},
+		{
+			"fixed",
+			[]*row{{user: "a-very-long-user-name", ttyName: "pts/0", tty: tty, command: "top"}},
+			true,
+			[]int{8, 7, 6, 3},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := columnWidths(columns, tc.rows, tc.fixed); !slices.Equal(got, tc.want) {
+				t.Errorf("columnWidths = %v, want %v",
+					got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestMergeDuplicates(t *testing.T) {
+	pty, vt := &what.TTY{Type: "pty"}, &what.TTY{Type: "vt"}
+	rows := []*row{
+		{user: "alice", ttyName: "pts/0", tty: pty, command: "vim"},
+		{user: "bob", ttyName: "pts/1", tty: pty, command: "top"},
+		{user: "alice", ttyName: "pts/2", tty: pty, command: "vim"},
+		// The same command on another kind of terminal, or by another user, is not merged.
+		{user: "alice", ttyName: "tty1", tty: vt, command: "vim"},
+		{user: "bob", ttyName: "pts/3", tty: pty, command: "vim"},
+		{user: "alice", ttyName: "pts/4", tty: pty, command: "vim"},
+		{user: "bob", ttyName: "pts/5", tty: pty, command: "top"},
+	}
+
+	var got []string
+	for _, r := range mergeDuplicates(rows) {
+		got = append(got, r.user+" "+r.ttyName+" "+r.command)
+	}
+
+	want := []string{
+		"alice pts/0 ×3 vim", "bob pts/1 ×2 top", "alice tty1 vim", "bob pts/3 vim",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("mergeDuplicates = %q, want %q",
+			got, want)
+	}
+
+	if mergeDuplicates(nil) != nil {
+		t.Error("mergeDuplicates of no rows is not nil")
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,870 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - top.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 41ea5f39-c7a4-11f1-89af-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+//go:build !minimal
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+	"github.com/johnsonjh/go-what/whatjson"
+	"golang.org/x/term"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// haveTop reports that the "top" mode is built in; see builtFeatures.
+const haveTop = true
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// topColumns are the optional columns the TUI can show, toggled by the column- actions.
+var topColumns = []string{
+	"origin", "container", "ns", "pid", "ppid", "time", "ancestry", "geo", "rate", "type",
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// topState is what the TUI is showing, and how.
+type topState struct {
+	sort    string
+	columns map[string]bool
+	noTTY   bool
+	sampled bool // %CPU is being measured, so the column and the cpu order are available
+	geo     *geoDB
+
+	// selected is the name of the highlighted terminal, kept across collections.
+	selected string
+
+	// query narrows the view to sessions whose user, terminal, or command contains it;
+	// typing is set while it is being edited after a "/".
+	query  string
+	typing bool
+
+	// detail, when set, is shown in a pane below the table for the selected terminal; it is
+	// fetched through details, and refreshed with every collection.
+	detail  *what.Detail
+	details func(tty *what.TTY) (*what.Detail, error)
+
+	// keys maps each bound key to its topActions name; help shows the overlay listing them.
+	keys map[byte]string
+	help bool
+
+	// screenshot is the file the export action writes to ("" for a new, timestamped one), and
+	// message the outcome, shown in the status bar until the next key.
+	screenshot string
+	message    string
+
+	snap *what.Snapshot
+	err  error
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// collected is the result of one collection run in the background.
+type collected struct {
+	snap *what.Snapshot
+	err  error
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// inputEvent is a keypress, an arrow key, or a mouse event, decoded from the terminal's input.
+type inputEvent struct {
+	key      byte
+	up, down bool // the arrow keys or the mouse wheel
+	click    bool // a left button press at x, y (counted from 1, as the terminal does)
+	x, y     int
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sortHeaders maps the headers that sort the table when clicked to their sortKeys entries.
+var sortHeaders = map[string]string{"USER": "user", "TTY": "tty", "INPUT": "input", "%CPU": "cpu"}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// parseInput decodes what one read of the terminal returned.  Mouse reports use the SGR
+// encoding (ESC [ < button ; x ; y M), which is not limited to 223 columns.
+func parseInput(in []byte) []inputEvent {
+	var events []inputEvent
+
+	for len(in) > 0 {
+		switch {
+		case bytes.HasPrefix(in, []byte("\x1b[<")):
+			end := bytes.IndexAny(in, "Mm")
+			if end < 0 {
+				return events
+			}
+
+			var button, x, y int
+
+			_, err := fmt.Sscanf(string(in[3:end]), "%d;%d;%d",
+				&button, &x, &y)
+
+			switch {
+			case err != nil || in[end] == 'm':
+
+			case button == 0:
+				events = append(events, inputEvent{click: true, x: x, y: y})
+
+			case button == 64:
+				events = append(events, inputEvent{up: true})
+
+			case button == 65:
+				events = append(events, inputEvent{down: true})
+			}
+
+			in = in[end+1:]
+
+		case bytes.HasPrefix(in, []byte("\x1b[A")):
+			events = append(events, inputEvent{up: true})
+			in = in[3:]
+
+		case bytes.HasPrefix(in, []byte("\x1b[B")):
+			events = append(events, inputEvent{down: true})
+			in = in[3:]
+
+		default:
+			events = append(events, inputEvent{key: in[0]})
+			in = in[1:]
+		}
+	}
+
+	return events
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// tableOptions returns the table layout for the current state on a screen width columns wide.
+func (s *topState) tableOptions(width int) tableOptions {
+	var geo *geoDB
+	if s.columns["geo"] {
+		geo = s.geo
+	}
+
+	return tableOptions{
+		Origin:     s.columns["origin"],
+		Container:  s.columns["container"],
+		Namespaces: s.columns["ns"],
+		Type:       s.columns["type"],
+		Rate:       s.columns["rate"] && s.snap != nil && s.snap.OutputInterval > 0,
+		PID:        s.columns["pid"],
+		PPID:       s.columns["ppid"],
+		Time:       s.columns["time"],
+		Ancestry:   s.columns["ancestry"],
+		Geo:        geo,
+		Sample:     s.sampled,
+		Color:      os.Getenv("NO_COLOR") == "",
+		Width:      width,
+		Sort:       s.sort,
+		HideNoTTY:  !s.noTTY,
+		Selected:   s.selected,
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// view returns the snapshot narrowed by the query.  As in less, the match ignores case unless
+// the query has capitals in it.
+func (s *topState) view() *what.Snapshot {
+	if s.snap == nil || s.query == "" {
+		return s.snap
+	}
+
+	fold := strings.ToLower(s.query) == s.query
+
+	matches := func(text string) bool {
+		if fold {
+			text = strings.ToLower(text)
+		}
+
+		return strings.Contains(text, s.query)
+	}
+
+	view := *s.snap
+	view.TTYs = slices.DeleteFunc(slices.Clone(s.snap.TTYs), func(tty *what.TTY) bool {
+		if matches(username(tty.Stat.Uid)) || matches(tty.Name) {
+			return false
+		}
+
+		return !slices.ContainsFunc(tty.Processes, func(p *what.Process) bool {
+			return matches(p.Command())
+		})
+	})
+
+	return &view
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// edit applies a keypress to the query being typed: Enter keeps it, Escape drops it.
+func (s *topState) edit(b byte) {
+	switch {
+	case b == '\r' || b == '\n':
+		s.typing = false
+
+	case b == 0x1b || b == 3:
+		s.typing, s.query = false, ""
+
+	case b == 0x7f || b == '\b':
+		if runes := []rune(s.query); len(runes) > 0 {
+			s.query = string(runes[:len(runes)-1])
+		}
+
+	case b >= ' ':
+		s.query += string(b)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// setSnapshot shows a new collection, in the current order.
+func (s *topState) setSnapshot(snap *what.Snapshot, err error) {
+	s.snap, s.err = snap, err
+	if snap != nil {
+		sortSessions(snap, s.sort)
+	}
+
+	if s.detail != nil {
+		s.openDetail()
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// openDetail fetches the detail of the selected terminal, closing the pane if it has gone.
+func (s *topState) openDetail() {
+	s.detail = nil
+
+	if s.snap == nil {
+		return
+	}
+
+	i := slices.IndexFunc(s.snap.TTYs, func(tty *what.TTY) bool {
+		return tty.Name == s.selected && len(tty.Processes) > 0
+	})
+	if i == -1 {
+		return
+	}
+
+	detail, err := s.details(s.snap.TTYs[i])
+	if err == nil {
+		s.detail = detail
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// detailLines lays out the detail pane, wrapping rather than truncating long values.
+func (s *topState) detailLines(width int) []string {
+	d := s.detail
+
+	var lines []string
+
+	add := func(label, value string) {
+		runes := []rune(fmt.Sprintf(" %-12s %s",
+			label, value))
+		for width > 0 && len(runes) > width {
+			lines = append(lines, string(runes[:width]))
+			runes = append([]rune(strings.Repeat(" ", 14)), runes[width:]...)
+		}
+
+		lines = append(lines, string(runes))
+	}
+
+	// Only the first line of each list carries its label.
+	first := func(i int, label string) string {
+		if i == 0 {
+			return label
+		}
+
+		return ""
+	}
+
+	add("terminal", s.selected)
+	add("command", d.Command)
+	add("cwd", cmp.Or(d.Cwd, "?"))
+	add("open files", strconv.Itoa(d.OpenFiles))
+
+	for i, variable := range d.Environment {
+		add(first(i, "environment"), variable)
+	}
+
+	for i, node := range d.Tree {
+		add(first(i, "processes"),
+			fmt.Sprintf("%s%d %s",
+				strings.Repeat("  ", node.Depth), node.Process.PID, node.Process.Command()))
+	}
+
+	for i, c := range d.Connections {
+		add(first(i, "connections"),
+			fmt.Sprintf("%d %s → %s",
+				c.PID, c.Local, c.Remote))
+	}
+
+	return lines
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// setSort changes the order, ignoring cpu when %CPU is not being measured.
+func (s *topState) setSort(by string) {
+	if by == "cpu" && !s.sampled {
+		return
+	}
+
+	s.sort = by
+	if s.snap != nil {
+		sortSessions(s.snap, by)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// move moves the selection by delta terminals, starting from the first when none is selected.
+func (s *topState) move(delta int) {
+	view := s.view()
+	if view == nil {
+		return
+	}
+
+	var names []string
+
+	for _, tty := range view.TTYs {
+		if len(tty.Processes) > 0 {
+			names = append(names, tty.Name)
+		}
+	}
+
+	if len(names) == 0 {
+		return
+	}
+
+	i := slices.Index(names, s.selected)
+	if i == -1 {
+		s.selected = names[0]
+
+		return
+	}
+
+	s.selected = names[min(max(i+delta, 0), len(names)-1)]
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// click sorts by the column whose header was clicked, or selects the terminal whose row was.
+// The screen is the uptime line, the header, and then one line per table row.
+func (s *topState) click(x, y, width int) {
+	view := s.view()
+	if view == nil {
+		return
+	}
+
+	opts := s.tableOptions(width)
+	columns := tableColumns(opts)
+	rows := tableRows(view, opts)
+
+	switch {
+	case y == 2:
+		widths := columnWidths(columns, rows, false)
+
+		for i, c := range columns {
+			if x <= widths[i] || i == len(columns)-1 {
+				if by, ok := sortHeaders[c.Header]; ok {
+					s.setSort(by)
+				}
+
+				return
+			}
+
+			x -= widths[i] + 1
+		}
+
+	case y > 2 && y-3 < len(rows):
+		r := rows[y-3]
+		if r.tty == nil {
+			return
+		}
+
+		// A second click on the selected row opens it, as Enter does.
+		if r.tty.Name == s.selected {
+			s.openDetail()
+		}
+
+		s.selected = r.tty.Name
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// statusBar describes the current sort, columns, and notty section, and the keys that change
+// them.
+func (s *topState) statusBar() string {
+	if s.message != "" {
+		return " " + s.message
+	}
+
+	if s.typing {
+		return " /" + s.query + "▏   Enter keeps the filter, Escape clears it"
+	}
+
+	var shown []string
+
+	for _, name := range topColumns {
+		if s.columns[name] {
+			shown = append(shown, name)
+		}
+	}
+
+	notty := "hidden"
+	if s.noTTY {
+		notty = "shown"
+	}
+
+	filter := ""
+	if s.query != "" {
+		filter = " │ filter " + s.query
+	}
+
+	return fmt.Sprintf(" sort %s │ columns %s │ notty %s%s │ %s help  %s quit",
+		s.sort, cmp.Or(strings.Join(shown, " "), "-"), notty, filter,
+		cmp.Or(keyFor(s.keys, "help"), "-"), cmp.Or(keyFor(s.keys, "quit"), "^C"))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// handle applies one input event.  It reports whether to quit, and whether the change needs a
+// fresh collection (for the columns that are only collected when shown).
+func (s *topState) handle(ev inputEvent, width int) (quit, recollect bool) {
+	if s.typing && !ev.click && !ev.up && !ev.down {
+		s.edit(ev.key)
+
+		return false, false
+	}
+
+	if ev.key != 0 {
+		s.message = ""
+	}
+
+	// Any key closes the help.
+	if s.help {
+		if ev.key != 0 {
+			s.help = false
+		}
+
+		return false, false
+	}
+
+	switch {
+	case ev.click:
+		s.click(ev.x, ev.y, width)
+
+	case ev.up:
+		s.move(-1)
+
+	case ev.down:
+		s.move(1)
+	}
+
+	switch ev.key {
+	case 3, 4: // ^C and ^D, which arrive as bytes in raw mode
+		return true, false
+
+	case '\r', '\n':
+		s.openDetail()
+
+		return false, false
+
+	case 0x1b, 0x7f, '\b':
+		s.detail = nil
+
+		return false, false
+	}
+
+	switch action := s.keys[ev.key]; action {
+	case "help":
+		s.help = true
+
+	case "quit":
+		return true, false
+
+	case "sort-user", "sort-tty", "sort-input", "sort-cpu":
+		s.setSort(strings.TrimPrefix(action, "sort-"))
+
+	case "notty":
+		s.noTTY = !s.noTTY
+
+	case "filter":
+		s.typing, s.query = true, ""
+
+	case "refresh":
+		return false, true
+
+	case "export":
+		name := cmp.Or(s.screenshot, fmt.Sprintf("go-what-%s.txt",
+			time.Now().Format("20060102-150405")))
+
+		s.message = "wrote " + name
+		if err := s.export(name); err != nil {
+			s.message = "export failed: " + err.Error()
+		}
+
+	default:
+		if name, ok := strings.CutPrefix(action, "column-"); ok {
+			s.columns[name] = !s.columns[name]
+
+			return false, name == "origin" || name == "ancestry"
+		}
+	}
+
+	return false, false
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// lines lays out the table, and the detail pane if it is open, for a screen height lines high;
+// with a height of 0, the table is not cut short to make room for the pane.
+func (s *topState) lines(opts tableOptions, height int) []string {
+	var table bytes.Buffer
+
+	switch {
+	case s.err != nil:
+		fmt.Fprintf(&table, "go-what: %v\n",
+			s.err)
+
+	case s.snap == nil:
+		table.WriteString("collecting...\n")
+
+	default:
+		printTable(&table, s.view(), opts)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+
+	// With the detail pane open, the table gets the top half of the screen.
+	if s.detail != nil {
+		if height > 0 {
+			lines = lines[:min(len(lines), max(height/2, 0))]
+		}
+
+		lines = append(lines, strings.Repeat("─", cmp.Or(opts.Width, 80)))
+		lines = append(lines, s.detailLines(opts.Width)...)
+	}
+
+	return lines
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// export writes the view as shown, without the terminal's escapes or truncation, to name: as a
+// whatjson.Snapshot for a .json file, as an HTML page for .html and .htm, and as plain text
+// otherwise.
+func (s *topState) export(name string) error {
+	view := s.view()
+	if view == nil {
+		return errors.New("nothing has been collected yet")
+	}
+
+	opts := s.tableOptions(0)
+	opts.Color, opts.Selected = false, ""
+
+	text := strings.Join(s.lines(opts, 0), "\n") + "\n"
+
+	var out bytes.Buffer
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		doc := jsonSnapshot(view, s.sampled)
+		if !s.noTTY {
+			doc.NoTTY = []whatjson.NoTTY{}
+		}
+
+		if err := json.NewEncoder(&out).Encode(doc); err != nil {
+			return err
+		}
+
+	case ".html", ".htm":
+		fmt.Fprintf(&out, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n"+
+			"<title>go-what %s</title>\n</head>\n<body>\n<pre>\n%s</pre>\n</body>\n</html>\n",
+			view.Time.Format(time.RFC3339), html.EscapeString(text))
+
+	default:
+		out.WriteString(text)
+	}
+
+	return os.WriteFile(name, out.Bytes(), 0o644)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// render redraws the screen, width by height, with the table above the status bar.
+func (s *topState) render(w io.Writer, width, height int) {
+	lines := s.lines(s.tableOptions(width), height-1)
+	if s.help {
+		lines = helpLines(s.keys)
+	}
+
+	lines = lines[:min(len(lines), max(height-1, 0))]
+
+	var screen strings.Builder
+
+	screen.WriteString("\x1b[H")
+
+	// Raw mode turns off the terminal's own newline translation.
+	for _, line := range lines {
+		screen.WriteString(line + "\x1b[K\r\n")
+	}
+
+	fmt.Fprintf(&screen, "\x1b[J\x1b[%dH\x1b[7m%s\x1b[0m",
+		height, pad(s.statusBar(), width, false))
+
+	_, _ = io.WriteString(w, screen.String())
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// topMain implements "go-what top": the table, redrawn every interval in a full-screen view
+// whose order and columns are changed from the keyboard.
+func topMain(args []string) int {
+	flags := flag.NewFlagSet("go-what top", flag.ExitOnError)
+
+	interval := flags.Duration("interval", 2*time.Second,
+		"time between collections")
+	sample := flags.Duration("sample", time.Second,
+		"read CPU times this far apart to measure %CPU; 0 leaves out %CPU and the cpu order")
+	sortBy := flags.String("sort", "input",
+		"initial order: input, user, tty, or cpu")
+	users := flags.String("u", "",
+		"only show sessions of these users (comma-separated)")
+	humansOnly := flags.Bool("humans-only", false,
+		"hide sessions spawned by automation")
+	screenshot := flags.String("screenshot", "",
+		"where the export key writes the view: .json, .html, or text (default go-what-TIME.txt)")
+	geoPaths := flags.String("geoip", "",
+		"the .mmdb files the geo column reads (default those in ~/.config/go-what/geoip)")
+	keysFile := flags.String("keys", "",
+		"read key bindings from this file instead of ~/.config/go-what/keys")
+
+	_ = flags.Parse(args)
+
+	if *interval <= 0 {
+		fmt.Fprintf(os.Stderr, "go-what: top: --interval must be positive\n")
+
+		return 2
+	}
+
+	if !slices.Contains(sortKeys, *sortBy) || (*sortBy == "cpu" && *sample <= 0) {
+		fmt.Fprintf(os.Stderr, "go-what: top: cannot sort by %q\n",
+			*sortBy)
+
+		return 2
+	}
+
+	bindings, err := loadBindings(cmp.Or(*keysFile, keysPath()), *keysFile != "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-what: top: %v\n",
+			err)
+
+		return 2
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Fprintf(os.Stderr, "go-what: top: standard input and output must be a terminal\n")
+
+		return 2
+	}
+
+	var filter sessionFilter
+	if *users != "" {
+		filter.Users = strings.Split(*users, ",")
+	}
+
+	saved, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-what: top: %v\n",
+			err)
+
+		return 1
+	}
+
+	defer func() { _ = term.Restore(fd, saved) }()
+
+	// Use the alternate screen, so the shell's scrollback is left as it was, and ask for mouse
+	// button reports.
+	fmt.Print("\x1b[?1049h\x1b[?25l\x1b[?1000h\x1b[?1006h")
+	defer fmt.Print("\x1b[?1006l\x1b[?1000l\x1b[?25h\x1b[?1049l")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, syscall.SIGWINCH)
+
+	defer signal.Stop(resized)
+
+	keys := make(chan []byte)
+
+	go func() {
+		defer close(keys)
+
+		buf := make([]byte, 64)
+
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				return
+			}
+
+			select {
+			case keys <- slices.Clone(buf[:n]):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	state := &topState{
+		sort: *sortBy, columns: make(map[string]bool), noTTY: true, sampled: *sample > 0,
+		keys: bindings, screenshot: *screenshot, geo: newGeoDB(*geoPaths),
+		details: func(tty *what.TTY) (*what.Detail, error) {
+			return what.Details(ctx, what.Options{}, tty)
+		},
+	}
+
+	// Collections run in the background, since sampling alone takes a second; at most one is
+	// in flight at a time.
+	results := make(chan collected, 1)
+	collecting := false
+
+	// The rates are measured against the collection before, taken before the filter narrows
+	// it; only one collection is in flight, so previous is never used by two at once.
+	var previous *what.Snapshot
+
+	collect := func() {
+		if collecting {
+			return
+		}
+
+		collecting = true
+
+		opts := what.Options{
+			Origin:     state.columns["origin"],
+			HumansOnly: *humansOnly,
+			Graphical:  true,
+			Ancestry:   state.columns["ancestry"],
+			Sample:     *sample,
+			Output:     state.columns["rate"],
+		}
+
+		go func() {
+			snap, err := what.Collect(ctx, opts)
+			if err == nil {
+				snap.MeasureOutput(previous)
+
+				unfiltered := *snap
+				unfiltered.TTYs = slices.Clone(snap.TTYs)
+				previous = &unfiltered
+
+				filter.apply(snap)
+			}
+
+			results <- collected{snap, err}
+		}()
+	}
+
+	size := func() (int, int) {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			return 80, 24
+		}
+
+		return width, height
+	}
+
+	draw := func() {
+		width, height := size()
+		state.render(os.Stdout, width, height)
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	collect()
+	draw()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return 0
+
+		case r := <-results:
+			collecting = false
+			state.setSnapshot(r.snap, r.err)
+
+		case <-ticker.C:
+			collect()
+
+			continue
+
+		case <-resized:
+
+		case in, ok := <-keys:
+			if !ok {
+				return 0
+			}
+
+			width, _ := size()
+
+			for _, ev := range parseInput(in) {
+				quit, recollect := state.handle(ev, width)
+				if quit {
+					return 0
+				}
+
+				if recollect {
+					collect()
+				}
+			}
+		}
+
+		draw()
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,42 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - top_minimal.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 9dbdc4d2-c7aa-11f1-ad6f-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+//go:build minimal
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"os"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// haveTop is false in minimal builds, which leave out the interactive "top" mode.
+const haveTop = false
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func topMain([]string) int {
+	fmt.Fprintf(os.Stderr, "go-what: top: not in this build (-tags minimal)\n")
+
+	return 2
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,178 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - topkeys.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: b091b29c-c7a4-11f1-a577-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+//go:build !minimal
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// topAction is something a key can be bound to in top.
+type topAction struct {
+	Name        string
+	Key         byte // the default binding
+	Description string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// topActions are the remappable actions, in the order the help lists them.  The arrow keys,
+// Enter, Escape, ^C, and the mouse are fixed.
+var topActions = []topAction{
+	{"help", '?', "show or hide this help"},
+	{"quit", 'q', "quit"},
+	{"sort-user", 'u', "sort by user"},
+	{"sort-tty", 't', "sort by terminal"},
+	{"sort-input", 'i', "sort by last input"},
+	{"sort-cpu", 'c', "sort by %CPU, busiest first"},
+	{"filter", '/', "filter by user, terminal, or command"},
+	{"notty", 'n', "show or hide the processes without a terminal"},
+	{"refresh", 'r', "collect again now"},
+	{"export", 's', "write the view to the --screenshot file (text, .json, or .html)"},
+	{"column-origin", '1', "show or hide ORIGIN"},
+	{"column-container", '2', "show or hide CONTAINER"},
+	{"column-ns", '3', "show or hide NS"},
+	{"column-pid", '4', "show or hide PID"},
+	{"column-ppid", '5', "show or hide PPID"},
+	{"column-time", '6', "show or hide TIME"},
+	{"column-ancestry", '7', "show or hide ANCESTRY"},
+	{"column-geo", '8', "show or hide GEO"},
+	{"column-rate", '9', "show or hide RATE"},
+	{"column-type", '0', "show or hide TYPE"},
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// keysPath is the default key binding file, in the user's configuration directory.
+func keysPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "go-what", "keys")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// loadBindings returns the key bound to each action: the defaults, as remapped by the file at
+// name.  Each line of the file is an action and the single character to bind it to, such as
+// "sort-cpu C"; blank lines and lines starting with # are ignored.  A remapped action loses its
+// default key.  If the file does not exist and required is false, the defaults are returned.
+func loadBindings(name string, required bool) (map[byte]string, error) {
+	bindings := make(map[byte]string)
+
+	for _, a := range topActions {
+		bindings[a.Key] = a.Name
+	}
+
+	f, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return bindings, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 || len(fields[1]) != 1 {
+			return nil, fmt.Errorf("%s:%d: want an action and a single character",
+				name, line)
+		}
+
+		action, key := fields[0], fields[1][0]
+
+		if !slices.ContainsFunc(topActions, func(a topAction) bool { return a.Name == action }) {
+			return nil, fmt.Errorf("%s:%d: unknown action %q",
+				name, line, action)
+		}
+
+		for k, bound := range bindings {
+			if bound == action {
+				delete(bindings, k)
+			}
+		}
+
+		bindings[key] = action
+	}
+
+	return bindings, scanner.Err()
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// keyFor returns the key bound to action, for display, or "" if it has none.
+func keyFor(bindings map[byte]string, action string) string {
+	for k, bound := range bindings {
+		if bound == action {
+			return string(k)
+		}
+	}
+
+	return ""
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// helpLines lists every key, as bound, for the help overlay.
+func helpLines(bindings map[byte]string) []string {
+	lines := []string{" go-what top keys", ""}
+
+	for _, a := range topActions {
+		if key := keyFor(bindings, a.Name); key != "" {
+			lines = append(lines, fmt.Sprintf("   %-7s %s",
+				key, a.Description))
+		}
+	}
+
+	return append(lines,
+		"   ↑ ↓     select a terminal (or the mouse wheel, or a click on its row)",
+		"   Enter   open the detail pane of the selected terminal (or click it again)",
+		"   Escape  close the detail pane",
+		"   ^C      quit",
+		"",
+		" Click USER, TTY, INPUT, or %CPU to sort by it.  Keys are remapped in "+
+			"~/.config/go-what/keys,",
+		` one "action key" pair per line, e.g. "sort-cpu C".  Press any key to close this help.`,
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,147 @@
+// go-what - ui/what.js
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: acac9f8c-c7a6-11f1-9227-80ee73e9b8e7
+
+// The dashboard of go-what serve --ui: the session table, refreshed from /v1/snapshot, sorted
+// by clicking a header (again to reverse) and filtered as go-what top's / does.
+
+"use strict";
+
+const refreshInterval = 5000;
+
+let snapshot = null;
+let sortKey = "input";
+let reversed = false;
+
+// pretty renders the time since an RFC 3339 timestamp as go-what's table does.
+function pretty(timestamp) {
+  let diff = Math.max(0, Math.floor((Date.now() - Date.parse(timestamp)) / 1000));
+  const days = Math.floor(diff / 86400);
+  diff %= 86400;
+  const hours = Math.floor(diff / 3600);
+  diff %= 3600;
+  const mins = Math.floor(diff / 60);
+  const secs = diff % 60;
+  const two = (n) => String(n).padStart(2, "0");
+
+  if (days > 99) {
+    return days + "d";
+  }
+  if (days > 0) {
+    return days + "d" + two(hours) + "h";
+  }
+  if (hours > 0) {
+    return hours + "h" + two(mins) + "m";
+  }
+  if (mins > 0) {
+    return mins + "m" + two(secs) + "s";
+  }
+  return secs + "s";
+}
+
+// what renders a session's WHAT cell, with its label in front.
+function what(session) {
+  return session.label ? "[" + session.label + "] " + session.command : session.command;
+}
+
+// compare orders sessions by the sort key; times sort most recent first.
+function compare(a, b) {
+  switch (sortKey) {
+    case "login":
+    case "input":
+      return Date.parse(b[sortKey]) - Date.parse(a[sortKey]);
+    case "command":
+      return what(a).localeCompare(what(b));
+    default:
+      return String(a[sortKey]).localeCompare(String(b[sortKey]));
+  }
+}
+
+// matches reports whether a session matches the filter: case-insensitively unless the filter
+// has capitals, against the user, the tty, or the command.
+function matches(session, query) {
+  if (query === "") {
+    return true;
+  }
+  const fold = query.toLowerCase() === query;
+  return [session.user, session.tty, what(session)].some((text) =>
+    (fold ? text.toLowerCase() : text).includes(query));
+}
+
+function render() {
+  for (const th of document.querySelectorAll("th")) {
+    th.classList.toggle("sorted", th.dataset.key === sortKey);
+  }
+
+  if (snapshot === null) {
+    return;
+  }
+
+  const query = document.getElementById("filter").value;
+  const sessions = snapshot.sessions.filter((s) => matches(s, query)).sort(compare);
+  if (reversed) {
+    sessions.reverse();
+  }
+
+  const rows = sessions.map((s) => {
+    const tr = document.createElement("tr");
+    if (s.automated) {
+      tr.className = "automated";
+    }
+    for (const [text, className] of [
+      [s.user, ""], [s.tty, ""], [pretty(s.login), "num"], [pretty(s.input), "num"],
+      [what(s), "what"],
+    ]) {
+      const td = document.createElement("td");
+      td.textContent = text;
+      td.className = className;
+      tr.append(td);
+    }
+    return tr;
+  });
+
+  document.getElementById("sessions").replaceChildren(...rows);
+
+  let summary = snapshot.sessions.length + " sessions, " + snapshot.users + " users";
+  if (snapshot.load) {
+    summary += ", load " + snapshot.load.map((l) => l.toFixed(2)).join(" ");
+  }
+  document.getElementById("summary").textContent = summary;
+  if (snapshot.node) {
+    document.getElementById("title").textContent = "go-what: " + snapshot.node;
+    document.title = "go-what: " + snapshot.node;
+  }
+}
+
+async function refresh() {
+  const status = document.getElementById("status");
+  try {
+    const resp = await fetch("v1/snapshot", { cache: "no-store" });
+    if (!resp.ok) {
+      throw new Error(resp.status + " " + (await resp.text()).trim());
+    }
+    snapshot = await resp.json();
+    status.textContent = "";
+  } catch (err) {
+    status.textContent = "refresh failed: " + err.message;
+  }
+  render();
+}
+
+for (const th of document.querySelectorAll("th")) {
+  th.addEventListener("click", () => {
+    reversed = th.dataset.key === sortKey && !reversed;
+    sortKey = th.dataset.key;
+    render();
+  });
+}
+
+document.getElementById("filter").addEventListener("input", render);
+
+refresh();
+setInterval(refresh, refreshInterval);
+
+// vim: set ft=javascript expandtab tabstop=2 cc=100 :
+// EOF
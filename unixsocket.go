@@ -0,0 +1,106 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - unixsocket.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 96d16b80-c7ac-11f1-b538-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/johnsonjh/go-what/whatjson"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// listenUnix listens on a unix socket at path, which only the users that mode (a permission,
+// as octal digits) lets write to it, of the socket's owner and, if set, group, can connect to.
+// A socket left behind by a process that is no longer listening is replaced.
+func listenUnix(path, mode, group string) (net.Listener, error) {
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || perm&^0o777 != 0 {
+		return nil, fmt.Errorf("bad socket mode %q",
+			mode)
+	}
+
+	gid := -1
+
+	if group != "" {
+		gid, err = strconv.Atoi(group)
+		if err != nil {
+			g, err := user.LookupGroup(group)
+			if err != nil {
+				return nil, err
+			}
+
+			gid, _ = strconv.Atoi(g.Gid)
+		}
+	}
+
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("something is already listening on %s",
+			path)
+	}
+
+	_ = os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.Chown(path, -1, gid)
+	if err == nil {
+		err = os.Chmod(path, os.FileMode(perm))
+	}
+
+	if err != nil {
+		listener.Close()
+
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fetchSocket asks go-what serve, listening on the unix socket at path, for its snapshot.
+func fetchSocket(ctx context.Context, path string) (*whatjson.Snapshot, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+
+	// The host is not used, but is required.
+	return fetchSnapshot(ctx, client, "http://go-what"+agentSnapshotPath)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,149 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - usercache.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: a83561ca-c7a8-11f1-ba04-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"os/user"
+	"strconv"
+	"sync"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+const (
+	// lookupTimeout bounds how long username waits for the user database (NSS, and so perhaps
+	// sssd or LDAP) to answer one lookup.
+	lookupTimeout = 2 * time.Second
+
+	// userCacheTTL is how long a lookup's answer is used before it is looked up again; until the
+	// new answer comes, the old one still is.
+	userCacheTTL = 5 * time.Minute
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// userNames caches the names username resolves.
+var userNames userCache
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// cachedName is a lookup's answer, "" for a UID with no name.
+type cachedName struct {
+	name    string
+	expires time.Time
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// userCache remembers user names, looking each up in the background so that a lookup which
+// hangs only costs its caller lookupTimeout.  Once one has, the others do not wait at all while
+// it is outstanding, since the user database is evidently wedged.
+type userCache struct {
+	mu      sync.Mutex
+	names   map[uint32]cachedName
+	pending map[uint32]chan struct{}
+	stalled map[uint32]bool // pending lookups that have outlasted lookupTimeout
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// name returns the name lookup gives uid, or "" if it has none or is not answering.
+func (c *userCache) name(uid uint32, lookup func(uint32) string) string {
+	c.mu.Lock()
+
+	if c.names == nil {
+		c.names = make(map[uint32]cachedName)
+		c.pending = make(map[uint32]chan struct{})
+		c.stalled = make(map[uint32]bool)
+	}
+
+	cached, known := c.names[uid]
+	if known && time.Now().Before(cached.expires) {
+		c.mu.Unlock()
+
+		return cached.name
+	}
+
+	done, running := c.pending[uid]
+	if !running {
+		done = make(chan struct{})
+		c.pending[uid] = done
+
+		go func() {
+			name := lookup(uid)
+
+			c.mu.Lock()
+			c.names[uid] = cachedName{name, time.Now().Add(userCacheTTL)}
+			delete(c.pending, uid)
+			delete(c.stalled, uid)
+			c.mu.Unlock()
+
+			close(done)
+		}()
+	}
+
+	wait := lookupTimeout
+	if known || len(c.stalled) > 0 {
+		wait = 0
+	}
+
+	c.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		return c.names[uid].name
+
+	case <-timer.C:
+		if !known {
+			c.mu.Lock()
+			if c.pending[uid] == done {
+				c.stalled[uid] = true
+			}
+			c.mu.Unlock()
+		}
+
+		return cached.name
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// lookupUser returns the name of uid in passwdFile, if set, or the system's user database.
+func lookupUser(uid uint32) string {
+	if passwdFile != "" {
+		return passwdName(uid)
+	}
+
+	u, err := user.LookupId(strconv.Itoa(int(uid)))
+	if err != nil || u == nil {
+		return ""
+	}
+
+	return u.Username
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
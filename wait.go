@@ -0,0 +1,176 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - wait.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: b198299f-c7a2-11f1-a421-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// waitCondition reports whether the condition waited for holds in snap, for the sessions that
+// filter selects.
+type waitCondition func(snap *what.Snapshot, filter sessionFilter) bool
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// parseUntil turns the --until argument into a condition.
+func parseUntil(until string) (waitCondition, error) {
+	sessions := func(snap *what.Snapshot, filter sessionFilter) []*what.TTY {
+		filter.apply(snap)
+
+		return snap.TTYs
+	}
+
+	name, arg, hasArg := strings.Cut(until, "=")
+	if hasArg && name != "idle" {
+		return nil, fmt.Errorf("--until %s takes no duration",
+			name)
+	}
+
+	switch name {
+	case "logout":
+		return func(snap *what.Snapshot, filter sessionFilter) bool {
+			return len(sessions(snap, filter)) == 0
+		}, nil
+
+	case "login":
+		return func(snap *what.Snapshot, filter sessionFilter) bool {
+			return len(sessions(snap, filter)) > 0
+		}, nil
+
+	case "idle":
+		idle, err := time.ParseDuration(arg)
+		if err != nil {
+			return nil, fmt.Errorf("--until idle needs a duration (e.g. idle=1h): %w",
+				err)
+		}
+
+		// With no sessions there is no one to have gone idle: that is --until logout.
+		return func(snap *what.Snapshot, filter sessionFilter) bool {
+			filter.IdleOver = 0
+			all := len(sessions(snap, filter))
+
+			filter.IdleOver = idle
+
+			return all > 0 && len(sessions(snap, filter)) == all
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown --until %q (want logout, login, or idle=DURATION)",
+		until)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// waitMain implements "go-what wait", which blocks until the selected users have logged out,
+// logged in, or gone idle, and returns the exit status.
+func waitMain(args []string) int {
+	flags := flag.NewFlagSet("go-what wait", flag.ExitOnError)
+
+	users := flags.String("user", "",
+		"the users to wait for (comma-separated); everyone if empty")
+	until := flags.String("until", "logout",
+		"what to wait for: logout, login, or idle=DURATION (all their sessions idle that long)")
+	humansOnly := flags.Bool("humans-only", false,
+		"ignore sessions spawned by automation")
+	interval := flags.Duration("interval", 5*time.Second,
+		"time between checks")
+	timeout := flags.Duration("timeout", 0,
+		"give up, with exit status 1, after waiting this long")
+
+	_ = flags.Parse(args)
+
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "go-what: wait: unexpected argument %q\n",
+			flags.Arg(0))
+
+		return 2
+	}
+
+	if *interval <= 0 {
+		fmt.Fprintf(os.Stderr, "go-what: wait: --interval must be positive\n")
+
+		return 2
+	}
+
+	cond, err := parseUntil(*until)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-what: %v\n",
+			err)
+
+		return 2
+	}
+
+	var filter sessionFilter
+	if *users != "" {
+		filter.Users = strings.Split(*users, ",")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	// A user sitting at a shell prompt is still logged in.
+	opts := what.Options{HumansOnly: *humansOnly, IdleShells: true}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	for {
+		snap, err := what.Collect(ctx, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-what: %v\n",
+				err)
+
+			return 1
+		}
+
+		if cond(snap, filter) {
+			return 0
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			fmt.Fprintf(os.Stderr, "go-what: gave up waiting: %v\n",
+				ctx.Err())
+
+			return 1
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,167 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - wait_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: a9df2690-c7b1-11f1-b1e0-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// idleTTY returns a terminal of the user with this UID, idle for this long before now.
+func idleTTY(name string, uid uint32, now time.Time, idle time.Duration) *what.TTY {
+	tty := &what.TTY{Name: name, Stat: syscall.Stat_t{Uid: uid}}
+	tty.Stat.Atim = syscall.NsecToTimespec(now.Add(-idle).UnixNano())
+
+	return tty
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestParseUntil(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	graphical := idleTTY("tty2", 1000, now, 3*time.Hour)
+	graphical.Graphical = true
+
+	for _, tc := range []struct {
+		name   string
+		until  string
+		filter sessionFilter
+		ttys   []*what.TTY
+		want   bool
+	}{
+		{"logout, with no sessions", "logout", sessionFilter{}, nil, true},
+		{
+			"logout, with a session", "logout",
+			sessionFilter{},
+			[]*what.TTY{idleTTY("pts/0", 1000, now, 0)},
+			false,
+		},
+		{
+			"logout, for another user", "logout",
+			sessionFilter{Users: []string{"bob"}},
+			[]*what.TTY{idleTTY("pts/0", 1000, now, 0)},
+			true,
+		},
+		{"login, with no sessions", "login", sessionFilter{}, nil, false},
+		{
+			"login, with a session", "login",
+			sessionFilter{Users: []string{"alice"}},
+			[]*what.TTY{idleTTY("pts/0", 1000, now, 0)},
+			true,
+		},
+		{"idle, with no sessions", "idle=1h", sessionFilter{}, nil, false},
+		{
+			"idle, with every session idle", "idle=1h",
+			sessionFilter{},
+			[]*what.TTY{
+				idleTTY("pts/0", 1000, now, 2*time.Hour),
+				idleTTY("pts/1", 1001, now, time.Hour),
+			},
+			true,
+		},
+		{
+			"idle, with one session active", "idle=1h",
+			sessionFilter{},
+			[]*what.TTY{
+				idleTTY("pts/0", 1000, now, 2*time.Hour),
+				idleTTY("pts/1", 1001, now, time.Minute),
+			},
+			false,
+		},
+		{
+			"idle, with the active session another user's", "idle=1h",
+			sessionFilter{Users: []string{"alice"}},
+			[]*what.TTY{
+				idleTTY("pts/0", 1000, now, 2*time.Hour),
+				idleTTY("pts/1", 1001, now, time.Minute),
+			},
+			true,
+		},
+		{
+			// A graphical session's idle time is not known, so it is never idle.
+			"idle, with a graphical session", "idle=1h",
+			sessionFilter{},
+			[]*what.TTY{idleTTY("pts/0", 1000, now, 2*time.Hour), graphical},
+			false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cond, err := parseUntil(tc.until)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			snap := &what.Snapshot{Time: now, TTYs: tc.ttys}
+			if got := cond(snap, tc.filter); got != tc.want {
+				t.Errorf("--until %s = %v, want %v",
+					tc.until, got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestParseUntilErrors(t *testing.T) {
+	for _, tc := range []struct {
+		until string
+		want  string
+	}{
+		{"logout=1h", "--until logout takes no duration"},
+		{"login=5m", "--until login takes no duration"},
+		{"idle", "--until idle needs a duration"},
+		{"idle=soon", "--until idle needs a duration"},
+		{"reboot", `unknown --until "reboot"`},
+	} {
+		t.Run(tc.until, func(t *testing.T) {
+			_, err := parseUntil(tc.until)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("parseUntil(%q) = %v, want an error with %q",
+					tc.until, err, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestWaitArguments(t *testing.T) {
+	for _, args := range [][]string{
+		{"alice"},
+		{"--until", "login", "alice"},
+		{"--until", "sometime"},
+		{"--interval", "0"},
+		{"--interval", "-1s"},
+	} {
+		if status := waitMain(args); status != 2 {
+			t.Errorf("wait %q = %d, want 2",
+				args, status)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
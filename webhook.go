@@ -0,0 +1,116 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - webhook.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: a6fd7e5a-c7af-11f1-a72e-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/johnsonjh/go-what/what"
+	"github.com/johnsonjh/go-what/whatjson"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// webhookTimeout bounds each POST of a --webhook, so a stalled receiver cannot hold up --follow
+// for longer.
+const webhookTimeout = 10 * time.Second
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// webhookSink POSTs every alert to a URL as a whatjson.Alert.  Session events are not sent: a
+// webhook is for what calls for someone to look.
+type webhookSink struct {
+	url      string
+	hostname string
+	client   *http.Client
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// newWebhookSink returns a sink for an http:// or https:// URL.
+func newWebhookSink(target string) (*webhookSink, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%q is not an http:// or https:// URL",
+			target)
+	}
+
+	hostname, _ := os.Hostname()
+
+	return &webhookSink{
+		url:      target,
+		hostname: hostname,
+		client:   &http.Client{Timeout: webhookTimeout},
+	}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Send implements eventSink, sending nothing.
+func (*webhookSink) Send(what.SessionEvent) error {
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Alert implements eventSink.  Any 2xx status is success.
+func (w *webhookSink) Alert(a alert) error {
+	body, err := json.Marshal(whatjson.Alert{
+		SchemaVersion: whatjson.SchemaVersion,
+		Rule:          a.Rule,
+		Time:          a.Time,
+		Hostname:      w.hostname,
+		Message:       a.Message,
+		Sessions:      ttySessions(a.TTY, false),
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: %w",
+			err)
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook: %s: %s",
+			w.url, resp.Status)
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
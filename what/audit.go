@@ -0,0 +1,199 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/audit.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 6f4dbe5a-c7a7-11f1-8721-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// AuditLogPath is where auditd writes its log by default.
+const AuditLogPath = "/var/log/audit/audit.log"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// auditUnset is the kernel's audit session ID (and login UID) of a process that pam_loginuid
+// never set one for.
+const auditUnset = 4294967295
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// AuditSession is what the audit trail records of one audit session: the login UID that owns it,
+// where it came from, and whether the kernel's tty auditing logged its keystrokes.
+type AuditSession struct {
+	ID         uint32
+	AUID       uint32
+	Terminal   string
+	Addr       string
+	Start      time.Time
+	TTYAudited bool
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// AuditTrail is the audit sessions found in an audit log, by ID.
+type AuditTrail map[uint32]*AuditSession
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ReadAuditLog reads an audit log (as auditd writes it, raw or enriched) for the sessions its
+// USER_LOGIN, USER_START, and TTY records name.  Other records are skipped.
+func ReadAuditLog(path string) (AuditTrail, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return parseAuditLog(f)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// auditFields splits an audit record into its key=value fields, including those of the quoted
+// msg='...' of user-space records.  The enriched format's interpretations, after a GS
+// character, are left out.
+func auditFields(line string) map[string]string {
+	line, _, _ = strings.Cut(line, "\x1d")
+	fields := make(map[string]string)
+
+	for _, word := range strings.Fields(line) {
+		key, value, ok := strings.Cut(strings.TrimPrefix(word, "msg='"), "=")
+		if ok {
+			fields[key] = strings.Trim(value, `"'`)
+		}
+	}
+
+	return fields
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// auditTime returns the time of an audit record, from its msg=audit(SECONDS.MILLIS:SERIAL):.
+func auditTime(line string) time.Time {
+	_, stamp, ok := strings.Cut(line, "msg=audit(")
+	if !ok {
+		return time.Time{}
+	}
+
+	stamp, _, _ = strings.Cut(stamp, ":")
+
+	seconds, err := strconv.ParseFloat(stamp, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.UnixMilli(int64(seconds * 1000))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func parseAuditLog(r io.Reader) (AuditTrail, error) {
+	trail := make(AuditTrail)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		kind, _, _ := strings.Cut(strings.TrimPrefix(line, "type="), " ")
+		if kind != "USER_LOGIN" && kind != "USER_START" && kind != "TTY" {
+			continue
+		}
+
+		fields := auditFields(line)
+
+		id, err := strconv.ParseUint(fields["ses"], 10, 32)
+		if err != nil || id == auditUnset {
+			continue
+		}
+
+		s := trail[uint32(id)]
+		if s == nil {
+			s = &AuditSession{ID: uint32(id), Start: auditTime(line)}
+			trail[s.ID] = s
+		}
+
+		if auid, err := strconv.ParseUint(fields["auid"], 10, 32); err == nil {
+			s.AUID = uint32(auid)
+		}
+
+		if kind == "TTY" {
+			s.TTYAudited = true
+
+			continue
+		}
+
+		if t := fields["terminal"]; t != "" && t != "?" {
+			s.Terminal = t
+		}
+
+		if a := fields["addr"]; a != "" && a != "?" {
+			s.Addr = a
+		}
+	}
+
+	return trail, scanner.Err()
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// auditSessionOf returns the audit session ID of a process, or 0 if it has none.
+func auditSessionOf(fsys fs.FS, pid int) uint32 {
+	data, err := fs.ReadFile(fsys, fmt.Sprintf("proc/%d/sessionid",
+		pid))
+	if err != nil {
+		return 0
+	}
+
+	id, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 32)
+	if err != nil || id == auditUnset {
+		return 0
+	}
+
+	return uint32(id)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// CorrelateAudit sets Process.AuditUnknown on every reported process whose audit session the
+// trail has no record of, such as one started while auditd was not running, or by something
+// that bypassed PAM.
+func CorrelateAudit(snap *Snapshot, trail AuditTrail) {
+	for _, tty := range snap.TTYs {
+		for _, p := range tty.Processes {
+			p.AuditUnknown = p.AuditSession != 0 && trail[p.AuditSession] == nil
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
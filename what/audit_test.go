@@ -0,0 +1,163 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/audit_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 37e8e888-c7b4-11f1-bb22-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestAuditFields(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		line string
+		want map[string]string
+	}{
+		{
+			"a kernel record",
+			`type=TTY msg=audit(1767323045.120:88): tty pid=900 uid=1000 auid=1000 ses=3 major=136`,
+			map[string]string{
+				"pid": "900", "uid": "1000", "auid": "1000", "ses": "3", "major": "136",
+			},
+		},
+		{
+			"a user-space record",
+			`type=USER_LOGIN msg=audit(1767323045.120:89): pid=812 uid=0 auid=1000 ses=3 ` +
+				`msg='op=login id=1000 exe="/usr/sbin/sshd" ` +
+				`addr=198.51.100.7 terminal=ssh res=success'`,
+			map[string]string{
+				"pid": "812", "uid": "0", "auid": "1000", "ses": "3",
+				"op": "login", "id": "1000", "exe": "/usr/sbin/sshd",
+				"addr": "198.51.100.7", "terminal": "ssh", "res": "success",
+			},
+		},
+		{
+			"an enriched record",
+			"type=USER_START msg=audit(1.0:1): ses=4 terminal=/dev/pts/1'" +
+				"\x1dUID=\"root\" AUID=\"alice\"",
+			map[string]string{"ses": "4", "terminal": "/dev/pts/1"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := auditFields(tc.line)
+			delete(got, "type")
+			delete(got, "msg")
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("auditFields = %v, want %v",
+					got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestAuditTime(t *testing.T) {
+	for line, want := range map[string]time.Time{
+		"type=TTY msg=audit(1767323045.120:88): ses=3": time.UnixMilli(1767323045120),
+		"type=TTY msg=audit(1767323045:88): ses=3":     time.Unix(1767323045, 0),
+		"type=TTY msg=audit(soon:88): ses=3":           {},
+		"type=TTY ses=3":                               {},
+	} {
+		if got := auditTime(line); !got.Equal(want) {
+			t.Errorf("auditTime(%q) = %v, want %v",
+				line, got, want)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestParseAuditLog(t *testing.T) {
+	log := strings.Join([]string{
+		`type=USER_LOGIN msg=audit(1767323045.120:89): pid=812 uid=0 auid=1000 ses=3 ` +
+			`msg='op=login id=1000 exe="/usr/sbin/sshd" addr=198.51.100.7 terminal=ssh ` +
+			`res=success'`,
+		`type=USER_START msg=audit(1767323045.200:90): pid=812 uid=0 auid=1000 ses=3 ` +
+			`msg='op=PAM:session_open acct="alice" exe="/usr/sbin/sshd" addr=? ` +
+			`terminal=/dev/pts/1'`,
+		`type=TTY msg=audit(1767323050.000:91): tty pid=900 uid=1000 auid=1000 ses=3 data=6C73`,
+		`type=SYSCALL msg=audit(1767323051.000:92): arch=c000003e syscall=59 ses=5 auid=1001`,
+		`type=USER_START msg=audit(1767323052.000:93): pid=830 uid=0 auid=1001 ses=5 ` +
+			`msg='op=PAM:session_open acct="bob" exe="/bin/login" addr=? terminal=tty1'`,
+		`type=USER_LOGIN msg=audit(1767323053.000:94): pid=40 uid=0 auid=4294967295 ` +
+			`ses=4294967295 msg='op=login acct="cron" terminal=cron res=failed'`,
+		`type=USER_LOGIN msg=audit(1767323054.000:95): pid=41 uid=0 ses=bad`,
+	}, "\n")
+
+	got, err := parseAuditLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := AuditTrail{
+		3: {
+			ID: 3, AUID: 1000, Terminal: "/dev/pts/1", Addr: "198.51.100.7",
+			Start: time.UnixMilli(1767323045120), TTYAudited: true,
+		},
+		5: {ID: 5, AUID: 1001, Terminal: "tty1", Start: time.Unix(1767323052, 0)},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		for id, s := range got {
+			t.Errorf("session %d: %+v",
+				id, *s)
+		}
+
+		t.Errorf("want %+v and %+v",
+			*want[3], *want[5])
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestAuditSessions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"proc/10/sessionid": {Data: []byte("3\n")},
+		"proc/11/sessionid": {Data: []byte("4294967295")},
+		"proc/12/sessionid": {Data: []byte("x")},
+		"proc/13/sessionid": {Data: []byte("7\n")},
+	}
+
+	for pid, want := range map[int]uint32{10: 3, 11: 0, 12: 0, 13: 7, 14: 0} {
+		if got := auditSessionOf(fsys, pid); got != want {
+			t.Errorf("auditSessionOf(%d) = %d, want %d",
+				pid, got, want)
+		}
+	}
+
+	known, unknown, none := &Process{AuditSession: 3}, &Process{AuditSession: 7}, &Process{}
+	snap := &Snapshot{TTYs: []*TTY{{Processes: []*Process{known, unknown, none}}}}
+
+	CorrelateAudit(snap, AuditTrail{3: {ID: 3}})
+
+	if known.AuditUnknown || !unknown.AuditUnknown || none.AuditUnknown {
+		t.Errorf("AuditUnknown is %v, %v, and %v, want only the second",
+			known.AuditUnknown, unknown.AuditUnknown, none.AuditUnknown)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,94 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/btmp.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 6a82ffbc-c7a2-11f1-b775-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// BtmpPath is where login programs record failed login attempts.
+const BtmpPath = "/var/log/btmp"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// FailedLogin summarizes the failed login attempts for one user from one source.
+type FailedLogin struct {
+	User   string
+	Source string // the remote host or address, or the terminal for local attempts
+	Count  int
+	First  time.Time
+	Last   time.Time
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// SummarizeFailures groups btmp records made at or after since by user and source, most
+// attempts first.
+func SummarizeFailures(entries []Utmp, since time.Time) []FailedLogin {
+	type key struct{ user, source string }
+
+	groups := make(map[key]*FailedLogin)
+
+	for _, e := range entries {
+		if e.Time.Before(since) {
+			continue
+		}
+
+		source := e.Host
+		if source == "" && e.Addr.IsValid() {
+			source = e.Addr.String()
+		}
+
+		k := key{e.User, cmp.Or(source, e.Line)}
+
+		f, ok := groups[k]
+		if !ok {
+			f = &FailedLogin{User: k.user, Source: k.source, First: e.Time}
+			groups[k] = f
+		}
+
+		f.Count++
+		if e.Time.Before(f.First) {
+			f.First = e.Time
+		}
+
+		if e.Time.After(f.Last) {
+			f.Last = e.Time
+		}
+	}
+
+	failures := make([]FailedLogin, 0, len(groups))
+	for _, f := range groups {
+		failures = append(failures, *f)
+	}
+
+	slices.SortFunc(failures, func(a, b FailedLogin) int {
+		return cmp.Or(b.Count-a.Count, b.Last.Compare(a.Last),
+			cmp.Compare(a.User, b.User), cmp.Compare(a.Source, b.Source))
+	})
+
+	return failures
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
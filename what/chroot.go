@@ -0,0 +1,43 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/chroot.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: a09a080b-c7a3-11f1-a18c-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// chrootOf returns the root directory of p when it differs from the host's, as during rescue
+// or maintenance work, or "" if it is the host's root or could not be read.  The link is
+// resolved against go-what's own root, so a process in another mount namespace reads as "/".
+func chrootOf(fsys fs.FS, p *Process) string {
+	root, err := fs.ReadLink(fsys, fmt.Sprintf("proc/%d/root",
+		p.PID))
+	if err != nil || root == "/" || !strings.HasPrefix(root, "/") {
+		return ""
+	}
+
+	return root
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
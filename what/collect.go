@@ -0,0 +1,754 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/collect.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: e0a9f75f-c7a0-11f1-b9da-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Package what collects the terminal sessions of a Linux host from /proc and /dev: which users
+// are running what, on which terminal, and how long each terminal has been idle.  It is the
+// library behind the go-what command.
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// TTY is a terminal device and the foreground processes found running on it.
+type TTY struct {
+	Name      string
+	Type      string
+	Origin    string
+	Label     string
+	Automated bool
+	Host      string
+
+	// Container is set for terminals inside a container, whose Name is the container's name
+	// (or short ID) followed by the terminal's name inside the container.
+	Container *Container
+
+	// Graphical is set for X11 and Wayland sessions, which have no terminal device: Name is
+	// the display (or the VT the session runs on), Stat carries only the owner and the login
+	// time, and Processes holds the compositor or window manager.
+	Graphical bool
+
+	// AtPrompt is set when Options.IdleShells found a login shell in the foreground, waiting
+	// for a command; the shell is then reported as its process.
+	AtPrompt bool
+
+	// LoginPrompt is set when Options.AllTTYs found a getty waiting on the terminal, which is
+	// then reported as its process.
+	LoginPrompt bool
+
+	// SSHAuth is set by AttributeSSHAuth for ssh logins that sshd's log records.
+	SSHAuth *SSHAuth
+
+	Attached  int
+	Stat      syscall.Stat_t
+	Processes []*Process
+
+	// OutputRate is how many bytes per second were written to the terminal between two
+	// collections, as measured by Snapshot.MeasureOutput.
+	OutputRate float64
+
+	// written is what each process writing to the terminal had written, by PID, with
+	// Options.Output.
+	written map[int]uint64
+
+	// sshdPIDs are the sshd processes the session descends from, for AttributeSSHAuth.
+	sshdPIDs []int
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Idle returns how long before now input last reached the terminal, and false if that is not
+// known: a Graphical session's Stat has only its login time.
+func (t *TTY) Idle(now time.Time) (time.Duration, bool) {
+	if t.Graphical {
+		return 0, false
+	}
+
+	return now.Sub(time.Unix(t.Stat.Atim.Unix())), true
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ttyType classifies a terminal device by its major/minor numbers (see the Linux kernel's
+// Documentation/admin-guide/devices.txt).
+func ttyType(rdev uint64) string {
+	major, minor := unix.Major(rdev), unix.Minor(rdev)
+
+	switch {
+	case major == 4 && minor == 0,
+		major == 5 && minor == 1,
+		major == 229:
+		return "console"
+
+	case major == 4 && minor < 64:
+		return "vt"
+
+	case major == 3,
+		major >= 136 && major <= 143:
+		return "pty"
+	}
+
+	// Everything else under /dev/tty* is a UART of some sort (ttyS, ttyUSB, ttyACM, ttyAMA, ...),
+	// most of which use dynamically allocated majors.
+	return "serial"
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ptyIndex returns the /dev/pts/N index of a UNIX98 pty slave device, or -1 for anything else.
+func ptyIndex(rdev uint64) int {
+	major := unix.Major(rdev)
+	if major < 136 || major > 143 {
+		return -1
+	}
+
+	return int((major-136)<<8 | unix.Minor(rdev))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// LevelTrace is the log level of per-process decisions, below slog.LevelDebug.
+const LevelTrace = slog.LevelDebug - 4
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// hiddenPrefixes are the command lines that never count as what a terminal is doing: login
+// prompts and terminal multiplexers.
+var hiddenPrefixes = []string{
+	"/sbin/getty",
+	"/sbin/agetty",
+	"tmux",
+	"screen",
+	"dtach",
+	"/sbin/mingetty",
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// loginPrompts are the commands of the gettys that Options.AllTTYs reports as login prompts.
+var loginPrompts = []string{
+	"getty",
+	"agetty",
+	"mingetty",
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// NoTTYRoot says when root's TTY-less processes are summarized.
+type NoTTYRoot int
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// When root is included in Snapshot.NoTTYUIDs.
+const (
+	NoTTYRootAlways  NoTTYRoot = iota // whether or not root has a session (or, with NoTTYZero, any)
+	NoTTYRootSession                  // only as any other user is
+	NoTTYRootNever
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Options selects the optional, more expensive parts of a collection.
+type Options struct {
+	// Origin resolves the process holding each pty master, which means reading the file
+	// descriptor table of every process.
+	Origin bool
+
+	// HumansOnly drops sessions labeled as automation.
+	HumansOnly bool
+
+	// AllTTYs also reports the terminals sitting at a login prompt, which are otherwise
+	// filtered out along with their gettys.
+	AllTTYs bool
+
+	// IdleShells reports terminals whose foreground process is a login shell sitting at its
+	// prompt, so that logged-in but idle users are listed rather than omitted.
+	IdleShells bool
+
+	// ProcessGroup attributes every member of a terminal's foreground process group (all the
+	// parts of a pipeline, say), not just the group leader.
+	ProcessGroup bool
+
+	// Graphical also reports X11 and Wayland sessions, from logind and from the X servers
+	// found running.
+	Graphical bool
+
+	// NoTTYAll has Snapshot.NoTTYUIDs summarize the TTY-less processes of every user, not only
+	// those with a session and root.
+	NoTTYAll bool
+
+	// NoTTYRoot says when Snapshot.NoTTYUIDs includes root.
+	NoTTYRoot NoTTYRoot
+
+	// NoTTYZero has Snapshot.NoTTYUIDs also include the users with a session but no TTY-less
+	// processes.
+	NoTTYZero bool
+
+	// Ancestry fills in Process.Ancestry for every reported process.
+	Ancestry bool
+
+	// Sample, if non-zero, reads every reported process's CPU time again after this long and
+	// fills in Process.CPUPercent; Collect takes at least this long to return.
+	Sample time.Duration
+
+	// Output reads how much every process writing to a terminal has written, so that
+	// Snapshot.MeasureOutput can tell a busy terminal from a quiet one.
+	Output bool
+
+	// FS is the filesystem /proc, /dev, and /run are read from, rooted at "/" (so paths look
+	// like "proc/1/stat").  Nil means the host's own root.  Directory and device entries must
+	// carry a *syscall.Stat_t as their fs.FileInfo Sys value, as os.DirFS provides, and the fd
+	// symlinks must be readable through fs.ReadLink; this makes fixture trees and alternate
+	// backends (remote, replay) possible.
+	FS fs.FS
+
+	// Logger, if set, receives a trace of the collection: which terminals were found, which
+	// processes were attributed to which terminal, and which were filtered out by which rule.
+	// Decisions about individual processes are logged at LevelTrace, the rest at Debug.
+	Logger *slog.Logger
+
+	// Timeout, if non-zero, bounds the whole collection in addition to any deadline of the
+	// context passed to Collect.
+	Timeout time.Duration
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Snapshot is everything known about the system at one point in time.
+type Snapshot struct {
+	Time    time.Time
+	Uptime  float64
+	Loadavg []string
+	Users   int
+	TTYs    []*TTY
+	NoTTY   map[uint32]int
+	Denied  Denials
+
+	// Hostname is the host's name, as the kernel has it, and Duration how long the collection
+	// took.
+	Hostname string
+	Duration time.Duration
+
+	// Processes counts every process each user has, by UID, terminal or not, as far as /proc
+	// shows them.
+	Processes map[uint32]int
+
+	// Skipped lists everything that could not be read or parsed, and so was left out or only
+	// partly filled in.  Each error names the path involved.  Processes that exited during
+	// the walk are not included.
+	Skipped []error
+
+	// Hidepid is the hidepid= option /proc is mounted with, if it hid other users' processes
+	// from this collection; their sessions are then reconstructed from utmp and logind.
+	Hidepid string
+
+	// OutputInterval is how long the terminals' OutputRate was measured over; zero means it
+	// was not.
+	OutputInterval time.Duration
+
+	// output is set if the snapshot was collected with Options.Output.
+	output bool
+
+	nottyAll  bool
+	nottyRoot NoTTYRoot
+	nottyZero bool
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Collect walks /dev and /proc, attributing foreground processes to the terminals they run on.
+// The terminals are returned in order of last input.  If ctx is canceled or its deadline (or
+// opts.Timeout) passes, the walk stops and Collect returns ctx.Err().
+func Collect(ctx context.Context, opts Options) (*Snapshot, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	fsys := opts.FS
+	if fsys == nil {
+		fsys = hostFS
+	}
+
+	log := opts.Logger
+	if log == nil {
+		log = slog.New(slog.DiscardHandler)
+	}
+
+	snap := &Snapshot{
+		Time: time.Now(), NoTTY: make(map[uint32]int), Processes: make(map[uint32]int),
+		nottyAll: opts.NoTTYAll, nottyRoot: opts.NoTTYRoot, nottyZero: opts.NoTTYZero,
+		output: opts.Output,
+	}
+
+	// With hidepid, other users' /proc entries are not merely unreadable but absent, so their
+	// sessions have to be reconstructed from the login databases instead.
+	var logins map[string]login
+
+	if hidepid := procHidepid(fsys); hidepid != "" && !canSeeAllProcesses() {
+		snap.Hidepid = hidepid
+		logins = fallbackLogins(fsys)
+
+		log.DebugContext(ctx, "hidepid fallback",
+			"hidepid", hidepid, "logins", len(logins))
+	}
+
+	ttys := make(map[uint64]*TTY)
+
+	// The device number of the host's devpts, which containers' own devpts instances differ
+	// from.
+	var hostPts uint64
+	ttyGlobs := []string{"dev/tty*", "dev/pts/*"}
+
+	for _, glob := range ttyGlobs {
+		files, err := fs.Glob(fsys, glob)
+		snap.skip(err)
+
+		for _, file := range files {
+			stat, err := rawStat(fsys, file)
+			if err != nil {
+				snap.skip(err)
+
+				continue
+			}
+
+			ttys[stat.Rdev] = &TTY{Name: file[4:], Type: ttyType(stat.Rdev), Stat: *stat}
+
+			if strings.HasPrefix(file, "dev/pts/") {
+				hostPts = stat.Dev
+			}
+
+			log.DebugContext(ctx, "tty",
+				"tty", file[4:], "type", ttys[stat.Rdev].Type, "rdev", stat.Rdev, "uid", stat.Uid)
+		}
+	}
+
+	uids := make(map[uint32]bool)
+
+	// The process holding a pty master open is the one that created the session; when several
+	// do (e.g. after a fork), the lowest PID is taken to be the original owner.
+	masters := make(map[int]int)
+
+	denied := &snap.Denied
+
+	procs := make(map[int]*Process)
+
+	containerTTYs := make(map[string]*TTY)
+	machines := readMachines(fsys)
+	initialNS := initialNamespaces(fsys)
+	shells := readShells(fsys)
+
+	procFiles, err := fs.ReadDir(fsys, "proc")
+	snap.skip(err)
+
+	for _, f := range procFiles {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("collecting processes: %w",
+				err)
+		}
+
+		pid, err := strconv.Atoi(f.Name())
+		if err != nil {
+			continue
+		}
+
+		p, err := readProcess(fsys, pid)
+		if p == nil {
+			denied.note(err, false)
+			snap.skip(err)
+
+			continue
+		}
+
+		if p.Partial {
+			denied.Details++
+			snap.skip(err)
+		}
+
+		procs[pid] = p
+		uids[p.UID] = true
+		snap.Processes[p.UID]++
+
+		if opts.Origin {
+			indexes, err := ptyMasters(fsys, pid, snap.skip)
+			denied.note(err, true)
+			snap.skip(err)
+
+			for _, index := range indexes {
+				if owner, ok := masters[index]; !ok || pid < owner {
+					masters[index] = pid
+				}
+			}
+		}
+
+		if p.TTY == 0 || p.TPGID == -1 {
+			snap.NoTTY[p.UID]++
+
+			log.Log(ctx, LevelTrace, "no tty",
+				"pid", pid, "comm", p.Comm)
+
+			continue
+		}
+
+		tty, ok := ttys[p.TTY]
+
+		// A pty numbered the same as one of the host's may belong to a container's own devpts.
+		if ptyIndex(p.TTY) >= 0 {
+			if c := containerOf(fsys, pid, machines); c != nil {
+				if ctty := containerTTY(fsys, containerTTYs, p, c, hostPts); ctty != nil {
+					tty, ok = ctty, true
+				}
+			}
+		}
+
+		if !ok {
+			log.DebugContext(ctx, "unknown tty",
+				"pid", pid, "comm", p.Comm, "tty_nr", p.TTY)
+
+			continue
+		}
+
+		tty.Attached++
+
+		// Background jobs and the shell itself write to the terminal too, so every process on it
+		// is counted, not only the reported ones.
+		if opts.Output {
+			if n, ok := readWritten(fsys, pid, p.TTY); ok {
+				if tty.written == nil {
+					tty.written = make(map[int]uint64)
+				}
+
+				tty.written[pid] = n
+			}
+		}
+
+		if opts.AllTTYs && slices.Contains(loginPrompts, p.Comm) {
+			tty.LoginPrompt = true
+			tty.Processes = append(tty.Processes, p)
+
+			log.DebugContext(ctx, "login prompt",
+				"pid", pid, "tty", tty.Name, "comm", p.Comm)
+
+			continue
+		}
+
+		hasPrefix := func(prefix string) bool {
+			return strings.HasPrefix(p.Cmdline, prefix)
+		}
+
+		// Login shells (see isLoginShell) are hidden too, unless Options.IdleShells asks for the
+		// ones sitting at a prompt.
+		if isLoginShell(p, shells) {
+			if opts.IdleShells && p.TPGID == pid {
+				tty.AtPrompt = true
+				tty.Processes = append(tty.Processes, p)
+
+				log.DebugContext(ctx, "at prompt",
+					"pid", pid, "tty", tty.Name, "command", p.Command())
+
+				continue
+			}
+
+			log.Log(ctx, LevelTrace, "filtered",
+				"pid", pid, "tty", tty.Name, "rule", "login shell")
+
+			continue
+		}
+
+		if i := slices.IndexFunc(hiddenPrefixes, hasPrefix); i >= 0 {
+			log.Log(ctx, LevelTrace, "filtered",
+				"pid", pid, "tty", tty.Name, "rule", hiddenPrefixes[i])
+
+			continue
+		}
+
+		if p.TPGID != pid && (!opts.ProcessGroup || p.PGRP != p.TPGID) {
+			log.Log(ctx, LevelTrace, "not foreground",
+				"pid", pid, "tty", tty.Name, "tpgid", p.TPGID)
+
+			continue
+		}
+
+		tty.Processes = append(tty.Processes, p)
+
+		log.DebugContext(ctx, "attributed",
+			"pid", pid, "tty", tty.Name, "command", p.Command())
+	}
+
+	snap.Users = len(uids)
+
+	for _, tty := range slices.Concat(slices.Collect(maps.Values(ttys)),
+		slices.Collect(maps.Values(containerTTYs))) {
+		// /proc is listed in lexical order; put pipelines back in the order they were started.
+		slices.SortFunc(tty.Processes, func(a, b *Process) int {
+			return a.PID - b.PID
+		})
+
+		// An open pty with no visible process at all is held by something we were not allowed
+		// to see; show it with a placeholder rather than letting the session vanish.
+		if tty.Attached == 0 {
+			l, registered := logins[tty.Name]
+			if registered ||
+				(tty.Type == "pty" && (denied.Processes > 0 || logins != nil)) {
+				tty.Processes = []*Process{{UID: tty.Stat.Uid, Comm: "?", Cmdline: "?"}}
+				tty.Host, tty.Origin = l.Host, l.Service
+
+				log.DebugContext(ctx, "placeholder",
+					"tty", tty.Name, "registered", registered)
+			}
+		}
+
+		if len(tty.Processes) > 0 {
+			if rule := sessionLabel(procs, tty.Processes[0]); rule != nil {
+				tty.Label, tty.Automated = rule.Label, rule.Automated
+
+				log.DebugContext(ctx, "labeled",
+					"tty", tty.Name, "label", rule.Label, "automated", rule.Automated)
+			}
+		}
+
+		if opts.Ancestry {
+			fillAncestry(procs, tty)
+		}
+
+		if len(tty.Processes) > 0 {
+			tty.sshdPIDs = sshdAncestors(procs, tty.Processes[0])
+		}
+
+		// A container's root is not a chroot; it is reported as the container instead.
+		for _, p := range tty.Processes {
+			if p.PID == 0 {
+				continue
+			}
+
+			if tty.Container == nil {
+				p.Root = chrootOf(fsys, p)
+			}
+
+			p.Cwd, _ = fs.ReadLink(fsys, fmt.Sprintf("proc/%d/cwd",
+				p.PID))
+			p.Namespaces = divergentNamespaces(fsys, p, initialNS)
+			if slices.Contains(p.Namespaces, "user") {
+				p.uidMap = readUIDMap(fsys, p.PID)
+			}
+
+			p.AuditSession = auditSessionOf(fsys, p.PID)
+			readUIDs(fsys, p)
+		}
+
+		if opts.HumansOnly && tty.Automated {
+			log.DebugContext(ctx, "filtered",
+				"tty", tty.Name, "rule", "humans-only")
+
+			continue
+		}
+
+		snap.TTYs = append(snap.TTYs, tty)
+
+		// The host's pty masters are numbered in the host's devpts, not the container's.
+		if tty.Container != nil {
+			classifyContainerTTY(fsys, procs, machines, tty)
+
+			log.DebugContext(ctx, "container",
+				"tty", tty.Name, "runtime", tty.Container.Runtime, "exec", tty.Container.Exec)
+
+			continue
+		}
+
+		index := ptyIndex(tty.Stat.Rdev)
+
+		owner, ok := masters[index]
+		if !ok {
+			if index >= 0 && (denied.Details > 0 || logins != nil) && tty.Origin == "" {
+				tty.Origin = "?"
+			}
+
+			continue
+		}
+
+		if p, ok := procs[owner]; ok {
+			tty.Origin = p.Comm
+
+			log.DebugContext(ctx, "origin",
+				"tty", tty.Name, "pid", owner, "comm", p.Comm)
+		}
+
+		// For ssh logins, follow any onward ssh clients started from the session.
+		if strings.HasPrefix(tty.Origin, "sshd") {
+			if chain := sshChain(procs, tty.Stat.Rdev); len(chain) > 0 {
+				tty.Origin = strings.Join(append([]string{tty.Origin}, chain...), "→")
+			}
+		}
+	}
+
+	if isWSL(fsys) {
+		for _, tty := range snap.TTYs {
+			labelWSLSession(fsys, procs, tty)
+		}
+	}
+
+	if opts.Graphical {
+		sessions, err := readLogindSessions(fsys)
+		snap.skip(err)
+
+		graphical := graphicalSessions(fsys, procs, sessions)
+		for _, tty := range graphical {
+			if opts.Ancestry {
+				fillAncestry(procs, tty)
+			}
+
+			log.DebugContext(ctx, "graphical",
+				"display", tty.Name, "type", tty.Type, "command", tty.Processes[0].Command())
+		}
+
+		snap.TTYs = append(snap.TTYs, graphical...)
+	}
+
+	if opts.Sample > 0 {
+		if err := sampleCPU(ctx, fsys, snap.TTYs, opts.Sample); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(snap.TTYs, func(i, j int) bool {
+		return snap.TTYs[i].Stat.Atim.Sec < snap.TTYs[j].Stat.Atim.Sec
+	})
+
+	uptimeContent, err := fs.ReadFile(fsys, "proc/uptime")
+	snap.skip(err)
+
+	uptimeParts := strings.Split(string(uptimeContent), " ")
+
+	snap.Uptime, err = strconv.ParseFloat(uptimeParts[0], 64)
+	if err != nil && uptimeContent != nil {
+		snap.skip(parseError("proc/uptime", ErrMalformed, err))
+	}
+
+	loadavgContent, err := fs.ReadFile(fsys, "proc/loadavg")
+	snap.skip(err)
+
+	snap.Loadavg = strings.Fields(string(loadavgContent))
+	if len(snap.Loadavg) < 4 {
+		if loadavgContent != nil {
+			snap.skip(parseError("proc/loadavg", ErrTruncated))
+		}
+
+		snap.Loadavg = append(snap.Loadavg, "?", "?", "?", "?")
+	}
+
+	hostname, err := fs.ReadFile(fsys, "proc/sys/kernel/hostname")
+	snap.skip(err)
+
+	snap.Hostname = strings.TrimSpace(string(hostname))
+	snap.Duration = time.Since(snap.Time)
+
+	return snap, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// skip records a read or parse failure, ignoring processes that have simply gone away.
+func (snap *Snapshot) skip(err error) {
+	if err != nil && !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, syscall.ESRCH) {
+		snap.Skipped = append(snap.Skipped, err)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fillAncestry sets the Ancestry of each of tty's processes.
+func fillAncestry(procs map[int]*Process, tty *TTY) {
+	for _, p := range tty.Processes {
+		for ancestor := range ancestors(procs, p) {
+			p.Ancestry = append(p.Ancestry, ancestor.Comm)
+		}
+
+		slices.Reverse(p.Ancestry)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// NoTTYUIDs returns the users whose TTY-less processes are summarized: everyone with a session
+// and such processes (or, with Options.NoTTYAll, everyone with such processes, and with
+// Options.NoTTYZero, everyone with a session), plus root as Options.NoTTYRoot says.  Even with
+// NoTTYRootAlways, root is left out if it has no such processes, unless Options.NoTTYZero is
+// set.  There are none if NoTTY has been set to nil.
+func (snap *Snapshot) NoTTYUIDs() []uint32 {
+	if snap.NoTTY == nil {
+		return nil
+	}
+
+	loggedInUids := make(map[uint32]bool)
+
+	for _, tty := range snap.TTYs {
+		if len(tty.Processes) > 0 {
+			loggedInUids[tty.Stat.Uid] = true
+		}
+	}
+
+	var nottyUids []uint32
+
+	if snap.nottyRoot == NoTTYRootAlways && (snap.NoTTY[0] > 0 || snap.nottyZero) {
+		nottyUids = append(nottyUids, 0)
+	}
+
+	// Root is otherwise taken like any other user, unless it is never taken at all.
+	skip := func(uid uint32) bool {
+		return uid == 0 && snap.nottyRoot != NoTTYRootSession
+	}
+
+	for uid := range snap.NoTTY {
+		_, ok := loggedInUids[uid]
+		if (ok || snap.nottyAll) && !skip(uid) {
+			nottyUids = append(nottyUids, uid)
+		}
+	}
+
+	if snap.nottyZero {
+		for uid := range loggedInUids {
+			if _, ok := snap.NoTTY[uid]; !ok && !skip(uid) {
+				nottyUids = append(nottyUids, uid)
+			}
+		}
+	}
+
+	slices.Sort(nottyUids)
+
+	return nottyUids
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,363 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/collect_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: bf774ca9-c7b0-11f1-9f7e-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// The device numbers of the fixture's terminals.
+var (
+	fixturePts = unix.Mkdev(136, 0)
+	fixtureVT  = unix.Mkdev(4, 1)
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fixtureFS returns a host with a pty owned by uid 1000 and a VT, and the uptime, load, and
+// hostname Collect reads, but no processes.
+func fixtureFS() fstest.MapFS {
+	return fstest.MapFS{
+		"dev/pts/0": {Mode: fs.ModeDevice | fs.ModeCharDevice, Sys: &syscall.Stat_t{
+			Rdev: fixturePts, Uid: 1000, Dev: 25,
+			Atim: syscall.Timespec{Sec: 1000}, Ctim: syscall.Timespec{Sec: 500},
+		}},
+		"dev/tty1": {Mode: fs.ModeDevice | fs.ModeCharDevice, Sys: &syscall.Stat_t{
+			Rdev: fixtureVT, Atim: syscall.Timespec{Sec: 2000},
+		}},
+		"etc/shells":               {Data: []byte("/bin/sh\n/bin/bash\n")},
+		"proc/uptime":              {Data: []byte("12345.67 23456.78\n")},
+		"proc/loadavg":             {Data: []byte("0.10 0.20 0.30 1/100 300\n")},
+		"proc/sys/kernel/hostname": {Data: []byte("fixture\n")},
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// addProcess adds a process to a fixture, in process group pgrp, on the terminal tty (0 for
+// none) whose foreground process group is tpgid.
+func addProcess(fsys fstest.MapFS, pid, pgrp int, uid uint32, comm, cmdline string, tty uint64,
+	tpgid int,
+) {
+	dir := fmt.Sprintf("proc/%d",
+		pid)
+
+	fsys[dir] = &fstest.MapFile{Mode: fs.ModeDir | 0o555, Sys: &syscall.Stat_t{Uid: uid}}
+	fsys[dir+"/stat"] = &fstest.MapFile{Data: fmt.Appendf(nil,
+		"%d (%s) S 1 %d %d %d %d 0 0 0 0 0 10 5 0 0 20 0 1 0 100\n",
+		pid, comm, pgrp, pgrp, tty, tpgid)}
+	fsys[dir+"/cmdline"] = &fstest.MapFile{Data: []byte(cmdline)}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ttyNamed returns the terminal of snap called name, failing the test if there is none.
+func ttyNamed(t *testing.T, snap *Snapshot, name string) *TTY {
+	t.Helper()
+
+	for _, tty := range snap.TTYs {
+		if tty.Name == name {
+			return tty
+		}
+	}
+
+	t.Fatalf("no terminal %s in the snapshot", name)
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// commands returns the command lines of a terminal's processes.
+func commands(tty *TTY) []string {
+	var cmds []string
+	for _, p := range tty.Processes {
+		cmds = append(cmds, p.Command())
+	}
+
+	return cmds
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestCollect(t *testing.T) {
+	fsys := fixtureFS()
+	addProcess(fsys, 1, 1, 0, "init", "/sbin/init\x00", 0, -1)
+	addProcess(fsys, 100, 100, 1000, "bash", "-bash\x00", fixturePts, 200)
+	addProcess(fsys, 200, 200, 1000, "vim", "vim\x00notes.txt\x00", fixturePts, 200)
+
+	snap, err := Collect(context.Background(), Options{FS: fsys})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(snap.Skipped) > 0 {
+		t.Errorf("Skipped = %v, want nothing",
+			snap.Skipped)
+	}
+
+	pts := ttyNamed(t, snap, "pts/0")
+	if got := commands(pts); len(got) != 1 || got[0] != "vim notes.txt" {
+		t.Errorf("pts/0 runs %q, want the foreground vim alone",
+			got)
+	}
+
+	if pts.Type != "pty" || pts.Stat.Uid != 1000 || pts.Attached != 2 {
+		t.Errorf("pts/0 is a %s of uid %d with %d processes, want a pty of 1000 with 2",
+			pts.Type, pts.Stat.Uid, pts.Attached)
+	}
+
+	if vt := ttyNamed(t, snap, "tty1"); vt.Type != "vt" || len(vt.Processes) != 0 {
+		t.Errorf("tty1 is a %s running %q, want an empty vt",
+			vt.Type, commands(vt))
+	}
+
+	if snap.Users != 2 || snap.NoTTY[0] != 1 || snap.NoTTY[1000] != 0 {
+		t.Errorf("Users = %d, NoTTY = %v; want 2 users and init as root's one TTY-less process",
+			snap.Users, snap.NoTTY)
+	}
+
+	if snap.Hostname != "fixture" || snap.Uptime != 12345.67 || len(snap.Loadavg) < 3 ||
+		snap.Loadavg[2] != "0.30" {
+		t.Errorf("Hostname = %q, Uptime = %g, Loadavg = %q",
+			snap.Hostname, snap.Uptime, snap.Loadavg)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestCollectLoginShells(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		cmdline    string
+		idleShells bool
+		want       []string
+	}{
+		{"dash-prefixed", "-bash\x00", false, nil},
+		{"listed in /etc/shells", "/bin/bash\x00--login\x00", false, nil},
+		{"at the prompt, with IdleShells", "-bash\x00", true, []string{"-bash"}},
+		{"not a shell", "/usr/bin/top\x00", false, []string{"/usr/bin/top"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fsys := fixtureFS()
+			addProcess(fsys, 100, 100, 1000, "bash", tc.cmdline, fixturePts, 100)
+
+			snap, err := Collect(context.Background(), Options{FS: fsys, IdleShells: tc.idleShells})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pts := ttyNamed(t, snap, "pts/0")
+			if got := commands(pts); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("pts/0 runs %q, want %q",
+					got, tc.want)
+			}
+
+			if pts.AtPrompt != tc.idleShells {
+				t.Errorf("AtPrompt = %v, want %v",
+					pts.AtPrompt, tc.idleShells)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestCollectSkipsUnreadable(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		stat string
+		want error
+	}{
+		{"no parentheses", "300 sleep S 1", ErrMalformed},
+		{"too few fields", "300 (sleep) S 1 300 300", ErrTruncated},
+		{"a field not a number", "300 (sleep) S x 300 300 0 -1 0 0 0 0 0 0 0 0 0 20", ErrMalformed},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fsys := fixtureFS()
+			addProcess(fsys, 300, 300, 0, "sleep", "sleep\x00", 0, -1)
+			fsys["proc/300/stat"].Data = []byte(tc.stat)
+
+			snap, err := Collect(context.Background(), Options{FS: fsys})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(snap.Skipped) != 1 {
+				t.Fatalf("Skipped = %v, want the one unreadable stat",
+					snap.Skipped)
+			}
+
+			var pathErr *fs.PathError
+			if err := snap.Skipped[0]; !errors.Is(err, tc.want) || !errors.As(err, &pathErr) ||
+				pathErr.Path != "proc/300/stat" {
+				t.Errorf("Skipped[0] = %v, want %v for proc/300/stat",
+					err, tc.want)
+			}
+
+			if snap.NoTTY[0] != 0 {
+				t.Errorf("the unreadable process was counted: NoTTY = %v",
+					snap.NoTTY)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestCollectCanceled(t *testing.T) {
+	fsys := fixtureFS()
+	addProcess(fsys, 1, 1, 0, "init", "/sbin/init\x00", 0, -1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := Collect(ctx, Options{FS: fsys}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Collect with a canceled context returned %v, want context.Canceled",
+			err)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestNoTTYUIDs(t *testing.T) {
+	// 1000 and 1001 are logged in, 2000 is not; only 1001 has no TTY-less processes.
+	sessions := []*TTY{session("pts/0", 1000, 1, "vim"), session("pts/1", 1001, 1, "top")}
+	rootSession := append([]*TTY{session("tty1", 0, 1, "htop")}, sessions...)
+	noTTY := map[uint32]int{0: 3, 1000: 2, 2000: 1}
+	noRoot := map[uint32]int{1000: 2, 2000: 1}
+
+	for _, tc := range []struct {
+		name string
+		snap Snapshot
+		want []uint32
+	}{
+		{"root always", Snapshot{TTYs: sessions, NoTTY: noTTY}, []uint32{0, 1000}},
+		{"root always, with none", Snapshot{TTYs: sessions, NoTTY: noRoot}, []uint32{1000}},
+		{
+			"root always, with none, and zero",
+			Snapshot{TTYs: sessions, NoTTY: noRoot, nottyZero: true},
+			[]uint32{0, 1000, 1001},
+		},
+		{
+			"root by session, not logged in",
+			Snapshot{TTYs: sessions, NoTTY: noTTY, nottyRoot: NoTTYRootSession},
+			[]uint32{1000},
+		},
+		{
+			"root by session, logged in",
+			Snapshot{TTYs: rootSession, NoTTY: noTTY, nottyRoot: NoTTYRootSession},
+			[]uint32{0, 1000},
+		},
+		{
+			"root never",
+			Snapshot{TTYs: rootSession, NoTTY: noTTY, nottyRoot: NoTTYRootNever},
+			[]uint32{1000},
+		},
+		{
+			"everyone",
+			Snapshot{TTYs: sessions, NoTTY: noTTY, nottyAll: true},
+			[]uint32{0, 1000, 2000},
+		},
+		{"hidden", Snapshot{TTYs: sessions}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.snap.NoTTYUIDs(); !slices.Equal(got, tc.want) {
+				t.Errorf("NoTTYUIDs() = %v, want %v",
+					got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestTTYIdle(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	tty := &TTY{Name: "pts/0"}
+	tty.Stat.Atim = syscall.NsecToTimespec(now.Add(-90 * time.Second).UnixNano())
+
+	if idle, known := tty.Idle(now); !known || idle != 90*time.Second {
+		t.Errorf("Idle = %v, %v, want 1m30s, true",
+			idle, known)
+	}
+
+	// A graphical session's terminal is not where its user types.
+	tty.Graphical = true
+
+	if idle, known := tty.Idle(now); known {
+		t.Errorf("Idle of a graphical session = %v, %v, want unknown",
+			idle, known)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestTTYType(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		major, minor uint32
+		want         string
+		index        int
+	}{
+		{"tty0", 4, 0, "console", -1},
+		{"console", 5, 1, "console", -1},
+		{"hvc0", 229, 0, "console", -1},
+		{"tty1", 4, 1, "vt", -1},
+		{"tty63", 4, 63, "vt", -1},
+		{"ttyS0", 4, 64, "serial", -1},
+		{"ttyUSB0", 188, 0, "serial", -1},
+		{"ttyp0", 3, 0, "pty", -1},
+		{"pts/0", 136, 0, "pty", 0},
+		{"pts/255", 136, 255, "pty", 255},
+		{"pts/256", 137, 0, "pty", 256},
+		{"pts/2047", 143, 255, "pty", 2047},
+		{"ttyACM0", 166, 0, "serial", -1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rdev := unix.Mkdev(tc.major, tc.minor)
+
+			if got := ttyType(rdev); got != tc.want {
+				t.Errorf("ttyType(%d, %d) = %q, want %q",
+					tc.major, tc.minor, got, tc.want)
+			}
+
+			if got := ptyIndex(rdev); got != tc.index {
+				t.Errorf("ptyIndex(%d, %d) = %d, want %d",
+					tc.major, tc.minor, got, tc.index)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
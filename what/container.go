@@ -0,0 +1,370 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/container.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 285e49cc-c7a3-11f1-ab0e-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Container identifies the container a terminal belongs to.  Containers have their own devpts
+// instance, so their terminals are kept apart from the host's even when the numbers coincide.
+type Container struct {
+	Runtime string // docker, podman, containerd, cri-o, lxc, nspawn, ...
+	ID      string // the full ID, for the runtimes that identify containers by one
+	Name    string // the machine or container name, or else the short ID
+
+	// Exec is set when the terminal was created for an exec (docker exec -t, podman exec -t,
+	// ...) rather than when the container started.
+	Exec bool
+
+	// ExecBy is the runtime client process that ran the exec, if it could be found on the
+	// host; its UID is the runtime user who initiated the session.
+	ExecBy *Process
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// containerScopes maps the prefixes of container cgroup names to their runtimes.
+var containerScopes = []struct{ prefix, runtime string }{
+	{"docker-", "docker"},
+	{"libpod-", "podman"},
+	{"cri-containerd-", "containerd"},
+	{"crio-", "cri-o"},
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// runtimeClients are the commands whose exec subcommand starts a session in a container.
+var runtimeClients = []string{"docker", "podman", "nerdctl", "crictl", "lxc"}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// isContainerID reports whether s looks like a full container ID (64 hexadecimal digits).
+func isContainerID(s string) bool {
+	return len(s) == 64 && isHex(s)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// machinesDir holds systemd-machined's per-machine state files, relative to "/".
+const machinesDir = "run/systemd/machines"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readMachines returns the machines registered with systemd-machined, keyed by their unit.
+func readMachines(fsys fs.FS) map[string]Container {
+	files, err := fs.ReadDir(fsys, machinesDir)
+	if err != nil {
+		return nil
+	}
+
+	machines := make(map[string]Container)
+
+	for _, f := range files {
+		content, err := fs.ReadFile(fsys, path.Join(machinesDir, f.Name()))
+		if err != nil {
+			continue
+		}
+
+		var unit string
+
+		m := Container{Runtime: "machined", Name: f.Name()}
+
+		for line := range strings.Lines(string(content)) {
+			key, value, _ := strings.Cut(strings.TrimSpace(line), "=")
+
+			switch key {
+			case "NAME":
+				m.Name = value
+
+			case "SERVICE":
+				m.Runtime = value
+
+			case "UNIT", "SCOPE":
+				unit = value
+			}
+		}
+
+		if unit != "" {
+			machines[unit] = m
+		}
+	}
+
+	return machines
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// containerOf returns the container pid runs in, judging by its cgroup, or nil if it runs on
+// the host.  Both the systemd (docker-ID.scope) and cgroupfs (/docker/ID) layouts are known,
+// as are LXC and LXD (lxc.payload.NAME) and systemd-nspawn; the names of containers registered
+// with machined are taken from machines.
+func containerOf(fsys fs.FS, pid int, machines map[string]Container) *Container {
+	cgroup, err := fs.ReadFile(fsys, fmt.Sprintf("proc/%d/cgroup",
+		pid))
+	if err != nil {
+		return nil
+	}
+
+	for line := range strings.Lines(string(cgroup)) {
+		segments := strings.Split(strings.TrimSpace(line[strings.LastIndex(line, ":")+1:]), "/")
+
+		for i, segment := range segments {
+			next := ""
+			if i+1 < len(segments) {
+				next = segments[i+1]
+			}
+
+			if m, ok := machines[segment]; ok {
+				return &m
+			}
+
+			switch {
+			case segment == "docker" && isContainerID(next):
+				return &Container{Runtime: "docker", ID: next, Name: next[:12]}
+
+			case segment == "lxc" && next != "":
+				return &Container{Runtime: "lxc", Name: next}
+
+			case strings.HasPrefix(segment, "lxc.payload."):
+				return &Container{Runtime: "lxc", Name: strings.TrimPrefix(segment, "lxc.payload.")}
+
+			case strings.HasPrefix(segment, "systemd-nspawn@"):
+				name, _ := strings.CutSuffix(segment[len("systemd-nspawn@"):], ".service")
+
+				return &Container{Runtime: "nspawn", Name: name}
+			}
+
+			for _, scope := range containerScopes {
+				id, ok := strings.CutPrefix(strings.TrimSuffix(segment, ".scope"), scope.prefix)
+				if ok && isContainerID(id) {
+					return &Container{Runtime: scope.runtime, ID: id, Name: id[:12]}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fdStat returns the Stat_t of the device rdev, found among pid's open file descriptors, or nil.
+func fdStat(fsys fs.FS, pid int, rdev uint64) *syscall.Stat_t {
+	fdPath := fmt.Sprintf("proc/%d/fd",
+		pid)
+
+	fds, err := fs.ReadDir(fsys, fdPath)
+	if err != nil {
+		return nil
+	}
+
+	for _, fd := range fds {
+		link, _ := fs.ReadLink(fsys, path.Join(fdPath, fd.Name()))
+		if !strings.HasPrefix(link, "/dev/") {
+			continue
+		}
+
+		stat, err := rawStat(fsys, path.Join(fdPath, fd.Name()))
+		if err == nil && stat.Rdev == rdev {
+			return stat
+		}
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// containerTTY returns the terminal that p, running in c, is attached to, creating it the first
+// time, or nil if it is one of the host's ptys after all: a container console (nspawn, LXC) is
+// a pty of the host's devpts, hostPts, passed in as /dev/console.  The device is stat'ed
+// through p's file descriptors or root directory; failing that, only the owner is known.
+func containerTTY(fsys fs.FS, ttys map[string]*TTY, p *Process, c *Container,
+	hostPts uint64,
+) *TTY {
+	stat := fdStat(fsys, p.PID, p.TTY)
+	if stat != nil && hostPts != 0 && stat.Dev == hostPts {
+		return nil
+	}
+
+	name := fmt.Sprintf("%s/pts/%d",
+		c.Name, ptyIndex(p.TTY))
+
+	if tty, ok := ttys[name]; ok {
+		return tty
+	}
+
+	tty := &TTY{Name: name, Type: "pty", Origin: c.Runtime, Container: c}
+
+	if stat == nil {
+		stat, _ = rawStat(fsys, fmt.Sprintf("proc/%d/root/dev/pts/%d",
+			p.PID, ptyIndex(p.TTY)))
+	}
+
+	if stat != nil && stat.Rdev == p.TTY {
+		tty.Stat = *stat
+	} else {
+		tty.Stat.Uid = p.UID
+		tty.Stat.Rdev = p.TTY
+	}
+
+	ttys[name] = tty
+
+	return tty
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// nsPID returns pid's PID in its innermost PID namespace, from the NSpid line of its status.
+func nsPID(fsys fs.FS, pid int) int {
+	status, err := fs.ReadFile(fsys, fmt.Sprintf("proc/%d/status",
+		pid))
+	if err != nil {
+		return 0
+	}
+
+	for line := range strings.Lines(string(status)) {
+		if value, ok := strings.CutPrefix(line, "NSpid:"); ok {
+			fields := strings.Fields(value)
+			if len(fields) > 0 {
+				n, _ := strconv.Atoi(fields[len(fields)-1])
+
+				return n
+			}
+		}
+	}
+
+	return 0
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// execArgv returns the container and command of a runtime client's exec subcommand
+// ("docker exec -it web bash" gives "web" and ["bash"]), or false.
+func execArgv(argv []string) (string, []string, bool) {
+	// Options of exec that take a separate value.
+	valued := []string{
+		"-e", "--env", "--env-file", "-u", "--user", "-w", "--workdir", "--detach-keys",
+		"--preserve-fds",
+	}
+
+	exec := false
+
+	for i := 1; i < len(argv); i++ {
+		arg := argv[i]
+
+		switch {
+		case !exec && (arg == "container" || strings.HasPrefix(arg, "-")):
+		case !exec && arg == "exec":
+			exec = true
+
+		case !exec:
+			return "", nil, false
+
+		case slices.Contains(valued, arg):
+			i++
+
+		case strings.HasPrefix(arg, "-"):
+		default:
+			command := argv[i+1:]
+			if len(command) > 0 && command[0] == "--" {
+				command = command[1:]
+			}
+
+			return arg, command, true
+		}
+	}
+
+	return "", nil, false
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// classifyContainerTTY fills in whether a container terminal was created by an exec, and by
+// whom.  The session's outermost process inside the container is found by walking up from its
+// foreground process: the container's init (PID 1 in its namespace) means the terminal dates
+// from container start; anything else was started by an exec, whose command is then matched
+// against the runtime clients running on the host.
+func classifyContainerTTY(fsys fs.FS, procs map[int]*Process, machines map[string]Container,
+	tty *TTY,
+) {
+	if len(tty.Processes) == 0 || tty.Processes[0].PID == 0 {
+		return
+	}
+
+	c, top := tty.Container, tty.Processes[0]
+
+	for ancestor := range ancestors(procs, tty.Processes[0]) {
+		a := containerOf(fsys, ancestor.PID, machines)
+		if a == nil || a.Runtime != c.Runtime || a.Name != c.Name {
+			break
+		}
+
+		top = ancestor
+	}
+
+	if n := nsPID(fsys, top.PID); n == 0 || n == 1 {
+		return
+	}
+
+	c.Exec = true
+	tty.Origin = c.Runtime + " exec"
+
+	for _, pid := range slices.Sorted(maps.Keys(procs)) {
+		p := procs[pid]
+		if !slices.Contains(runtimeClients, path.Base(p.Comm)) {
+			continue
+		}
+
+		name, command, ok := execArgv(p.Argv)
+		if !ok || !slices.Equal(command, top.Argv) {
+			continue
+		}
+
+		// Containers without an ID are named as machined or LXC knows them; with an ID, only a
+		// client naming the container by (a prefix of) its ID can be checked.
+		if c.ID == "" && name != c.Name || isHex(name) && !strings.HasPrefix(c.ID, name) {
+			continue
+		}
+
+		c.ExecBy = p
+
+		return
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// isHex reports whether s is a non-empty string of lowercase hexadecimal digits.
+func isHex(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789abcdef") == ""
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
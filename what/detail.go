@@ -0,0 +1,243 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/detail.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 8b9386fa-c7a4-11f1-bfb9-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// detailEnvironment are the environment variables worth showing in a session's Detail: where
+// it came from, what it runs under, and which credentials or contexts it has selected.
+var detailEnvironment = []string{
+	"SSH_CONNECTION",
+	"DISPLAY",
+	"WAYLAND_DISPLAY",
+	"TERM",
+	"LANG",
+	"SUDO_USER",
+	"TMUX",
+	"STY",
+	"VIRTUAL_ENV",
+	"CONDA_DEFAULT_ENV",
+	"KUBECONFIG",
+	"AWS_PROFILE",
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Detail is a closer look at one session than Collect takes: everything about the session's
+// processes that is too expensive, or too long, to gather for every terminal.
+type Detail struct {
+	// Command is the untruncated command line of the first foreground process, and Cwd its
+	// working directory ("" if unreadable).
+	Command string
+	Cwd     string
+
+	// Environment holds those of detailEnvironment that the foreground process has set, in
+	// that order, as "NAME=value".
+	Environment []string
+
+	// Tree is every process on the terminal (for a graphical session, every descendant of
+	// its foreground process), parents before their children.
+	Tree []TreeNode
+
+	// OpenFiles counts the file descriptors of the processes in Tree whose descriptor tables
+	// could be read.
+	OpenFiles int
+
+	// Connections are the established TCP connections those processes hold.
+	Connections []Connection
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// TreeNode is one process of a Detail's Tree, Depth levels below the top of the session.
+type TreeNode struct {
+	Depth   int
+	Process *Process
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Connection is an established TCP connection held by one of a session's processes.
+type Connection struct {
+	PID    int
+	Local  netip.AddrPort
+	Remote netip.AddrPort
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Details walks /proc again for the detail of tty, one of the terminals of a Snapshot.  What
+// could not be read is left out; an error is returned only when ctx is done.
+func Details(ctx context.Context, opts Options, tty *TTY) (*Detail, error) {
+	fsys := opts.FS
+	if fsys == nil {
+		fsys = hostFS
+	}
+
+	detail := &Detail{}
+
+	if len(tty.Processes) == 0 || tty.Processes[0].PID == 0 {
+		return detail, nil
+	}
+
+	fg := tty.Processes[0]
+	detail.Command = fg.Command()
+	detail.Cwd, _ = fs.ReadLink(fsys, fmt.Sprintf("proc/%d/cwd",
+		fg.PID))
+	detail.Environment = environment(fsys, fg.PID)
+
+	procs := make(map[int]*Process)
+
+	procFiles, _ := fs.ReadDir(fsys, "proc")
+
+	for _, f := range procFiles {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		pid, err := strconv.Atoi(f.Name())
+		if err != nil {
+			continue
+		}
+
+		if p, _ := readProcess(fsys, pid); p != nil {
+			procs[pid] = p
+		}
+	}
+
+	// A graphical session has no terminal of its own; its processes are those descended from
+	// the one shown for it.
+	inSession := func(p *Process) bool {
+		if !tty.Graphical {
+			return p.TTY == tty.Stat.Rdev
+		}
+
+		for ancestor := range ancestors(procs, p) {
+			if ancestor.PID == fg.PID {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	children := make(map[int][]*Process)
+
+	var roots []*Process
+
+	for _, p := range procs {
+		if !inSession(p) {
+			continue
+		}
+
+		if parent, ok := procs[p.PPID]; ok && inSession(parent) {
+			children[p.PPID] = append(children[p.PPID], p)
+		} else {
+			roots = append(roots, p)
+		}
+	}
+
+	byPID := func(a, b *Process) int { return a.PID - b.PID }
+
+	var walk func(p *Process, depth int)
+
+	walk = func(p *Process, depth int) {
+		detail.Tree = append(detail.Tree, TreeNode{Depth: depth, Process: p})
+
+		slices.SortFunc(children[p.PID], byPID)
+
+		for _, child := range children[p.PID] {
+			walk(child, depth+1)
+		}
+	}
+
+	slices.SortFunc(roots, byPID)
+
+	for _, p := range roots {
+		walk(p, 0)
+	}
+
+	conns, _ := readTCP(fsys)
+
+	for _, node := range detail.Tree {
+		pid := node.Process.PID
+
+		fds, err := fs.ReadDir(fsys, fmt.Sprintf("proc/%d/fd",
+			pid))
+		if err != nil {
+			continue
+		}
+
+		detail.OpenFiles += len(fds)
+
+		inodes, _ := socketInodes(fsys, pid)
+
+		for _, c := range conns {
+			if slices.Contains(inodes, c.Inode) {
+				detail.Connections = append(detail.Connections,
+					Connection{PID: pid, Local: c.Local, Remote: c.Remote})
+			}
+		}
+	}
+
+	return detail, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// environment returns the detailEnvironment variables set for pid, as "NAME=value".
+func environment(fsys fs.FS, pid int) []string {
+	environ, err := fs.ReadFile(fsys, fmt.Sprintf("proc/%d/environ",
+		pid))
+	if err != nil {
+		return nil
+	}
+
+	set := make(map[string]string)
+
+	for variable := range bytes.SplitSeq(environ, []byte{0}) {
+		if name, value, ok := strings.Cut(string(variable), "="); ok {
+			set[name] = value
+		}
+	}
+
+	var highlights []string
+
+	for _, name := range detailEnvironment {
+		if value, ok := set[name]; ok {
+			highlights = append(highlights, name+"="+value)
+		}
+	}
+
+	return highlights
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,76 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/fs.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 41bd5bea-c7a1-11f1-a117-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Errors wrapped (in an *fs.PathError with Op "parse") when a file's contents are not as
+// expected.
+var (
+	ErrMalformed = errors.New("malformed")
+	ErrTruncated = errors.New("truncated")
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// hostFS is the root filesystem of the running host, used when Options.FS is nil.
+var hostFS = os.DirFS("/")
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// rawStat returns the raw stat structure behind a file, which carries the device numbers,
+// ownership, and timestamps that fs.FileInfo does not.  os.DirFS provides it; fixture trees
+// (e.g. fstest.MapFS) must set it as the file's Sys value.
+func rawStat(fsys fs.FS, name string) (*syscall.Stat_t, error) {
+	fi, err := fs.Stat(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: errors.ErrUnsupported}
+	}
+
+	return stat, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// parseError reports unexpected contents in a file; err is ErrMalformed or ErrTruncated,
+// optionally followed by the underlying cause.
+func parseError(name string, err error, cause ...error) error {
+	if len(cause) > 0 {
+		err = fmt.Errorf("%w: %w",
+			err, errors.Join(cause...))
+	}
+
+	return &fs.PathError{Op: "parse", Path: name, Err: err}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,253 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/gob.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 42f72235-c7ac-11f1-812d-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io/fs"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// GobEncode encodes a Snapshot whole, for passing between go-what processes (as go-what's
+// collector does): unlike the whatjson documents, it keeps what the options it was collected
+// with left in it, and decodes to a Snapshot that behaves as the original.  Skipped is passed
+// as each error's message, with the operation and path of an *fs.PathError and the kind of
+// error (as gobErrorKinds has them) that errors.Is finds in it.
+func (s *Snapshot) GobEncode() ([]byte, error) {
+	type plain Snapshot
+
+	doc := struct {
+		Snapshot  plain
+		Skipped   []gobSkipped
+		NoTTYAll  bool
+		NoTTYRoot NoTTYRoot
+		NoTTYZero bool
+	}{
+		Snapshot: plain(*s), NoTTYAll: s.nottyAll, NoTTYRoot: s.nottyRoot, NoTTYZero: s.nottyZero,
+	}
+
+	doc.Snapshot.Skipped = nil
+	for _, err := range s.Skipped {
+		doc.Skipped = append(doc.Skipped, encodeSkipped(err))
+	}
+
+	var buf bytes.Buffer
+
+	err := gob.NewEncoder(&buf).Encode(doc)
+
+	return buf.Bytes(), err
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// GobDecode decodes a Snapshot encoded by GobEncode.
+func (s *Snapshot) GobDecode(data []byte) error {
+	type plain Snapshot
+
+	var doc struct {
+		Snapshot  plain
+		Skipped   []gobSkipped
+		NoTTYAll  bool
+		NoTTYRoot NoTTYRoot
+		NoTTYZero bool
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return err
+	}
+
+	*s = Snapshot(doc.Snapshot)
+	s.nottyAll, s.nottyRoot, s.nottyZero = doc.NoTTYAll, doc.NoTTYRoot, doc.NoTTYZero
+
+	for _, skipped := range doc.Skipped {
+		s.Skipped = append(s.Skipped, skipped.decode())
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// gobErrorKinds are the errors that a decoded error of Snapshot.Skipped is matched to by
+// errors.Is, as the original was, by the names they are encoded as.
+var gobErrorKinds = []struct {
+	Name string
+	Err  error
+}{
+	{"permission", fs.ErrPermission},
+	{"not-exist", fs.ErrNotExist},
+	{"truncated", ErrTruncated},
+	{"malformed", ErrMalformed},
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// gobSkipped is an error of Snapshot.Skipped as encoded.  For an *fs.PathError, Op and Path
+// are its own and Message that of the error it wraps.
+type gobSkipped struct {
+	Message   string
+	Kind      string
+	PathError bool
+	Op, Path  string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// encodeSkipped encodes an error of Snapshot.Skipped.
+func encodeSkipped(err error) gobSkipped {
+	skipped := gobSkipped{Message: err.Error()}
+
+	if pathErr, ok := err.(*fs.PathError); ok {
+		skipped = gobSkipped{
+			Message: pathErr.Err.Error(), PathError: true, Op: pathErr.Op, Path: pathErr.Path,
+		}
+	}
+
+	for _, kind := range gobErrorKinds {
+		if errors.Is(err, kind.Err) {
+			skipped.Kind = kind.Name
+
+			break
+		}
+	}
+
+	return skipped
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// decode returns an error with the message, path, and kind of the one encoded.
+func (g gobSkipped) decode() error {
+	err := &skippedError{msg: g.Message}
+
+	for _, kind := range gobErrorKinds {
+		if kind.Name == g.Kind {
+			err.kind = kind.Err
+		}
+	}
+
+	if g.PathError {
+		return &fs.PathError{Op: g.Op, Path: g.Path, Err: err}
+	}
+
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// skippedError is a decoded error of Snapshot.Skipped, with the original's message, which
+// errors.Is matches to the original's kind.
+type skippedError struct {
+	msg  string
+	kind error
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Error implements error.
+func (e *skippedError) Error() string {
+	return e.msg
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Is reports whether target is the error's kind.
+func (e *skippedError) Is(target error) bool {
+	return e.kind != nil && target == e.kind
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// GobEncode encodes a TTY with the sshd processes it descends from.
+func (t *TTY) GobEncode() ([]byte, error) {
+	type plain TTY
+
+	var buf bytes.Buffer
+
+	err := gob.NewEncoder(&buf).Encode(struct {
+		TTY      plain
+		SSHDPIDs []int
+	}{plain(*t), t.sshdPIDs})
+
+	return buf.Bytes(), err
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// GobDecode decodes a TTY encoded by GobEncode.
+func (t *TTY) GobDecode(data []byte) error {
+	type plain TTY
+
+	var doc struct {
+		TTY      plain
+		SSHDPIDs []int
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return err
+	}
+
+	*t = TTY(doc.TTY)
+	t.sshdPIDs = doc.SSHDPIDs
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// GobEncode encodes a Process with the mapping of its user namespace.
+func (p *Process) GobEncode() ([]byte, error) {
+	type plain Process
+
+	var buf bytes.Buffer
+
+	err := gob.NewEncoder(&buf).Encode(struct {
+		Process plain
+		UIDMap  []idRange
+	}{plain(*p), p.uidMap})
+
+	return buf.Bytes(), err
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// GobDecode decodes a Process encoded by GobEncode.
+func (p *Process) GobDecode(data []byte) error {
+	type plain Process
+
+	var doc struct {
+		Process plain
+		UIDMap  []idRange
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return err
+	}
+
+	*p = Process(doc.Process)
+	p.uidMap = doc.UIDMap
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
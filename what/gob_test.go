@@ -0,0 +1,148 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/gob_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 01f5bb02-c7b1-11f1-9cae-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// gobRoundTrip encodes and decodes a snapshot as go-what's collector and its clients do.
+func gobRoundTrip(t *testing.T, snap *Snapshot) *Snapshot {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snap); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Snapshot
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	return &decoded
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestGobRoundTrip(t *testing.T) {
+	tty := session("pts/0", 1000, 1, "vim")
+	tty.sshdPIDs = []int{42, 43}
+	tty.Processes[0].uidMap = []idRange{{Inside: 0, Outside: 100000, Count: 65536}}
+
+	snap := &Snapshot{
+		Time: time.Unix(1700000000, 0), Hostname: "fixture", TTYs: []*TTY{tty},
+		NoTTY:     map[uint32]int{1000: 2},
+		nottyAll:  true,
+		nottyRoot: NoTTYRootNever,
+		nottyZero: true,
+	}
+
+	decoded := gobRoundTrip(t, snap)
+
+	if !decoded.Time.Equal(snap.Time) || decoded.Hostname != snap.Hostname {
+		t.Errorf("decoded Time %v and Hostname %q, want %v and %q",
+			decoded.Time, decoded.Hostname, snap.Time, snap.Hostname)
+	}
+
+	if decoded.nottyAll != snap.nottyAll || decoded.nottyRoot != snap.nottyRoot ||
+		decoded.nottyZero != snap.nottyZero {
+		t.Errorf("the options the snapshot was collected with were lost")
+	}
+
+	if !slices.Equal(decoded.NoTTYUIDs(), snap.NoTTYUIDs()) {
+		t.Errorf("decoded NoTTYUIDs() = %v, want %v",
+			decoded.NoTTYUIDs(), snap.NoTTYUIDs())
+	}
+
+	got := decoded.TTYs[0]
+	if !slices.Equal(got.sshdPIDs, tty.sshdPIDs) ||
+		!slices.Equal(got.Processes[0].uidMap, tty.Processes[0].uidMap) {
+		t.Errorf("decoded sshdPIDs %v and uidMap %v, want %v and %v",
+			got.sshdPIDs, got.Processes[0].uidMap, tty.sshdPIDs, tty.Processes[0].uidMap)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestGobSkipped(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		kind error
+		path string
+	}{
+		{
+			"malformed", parseError("proc/1/stat", ErrMalformed, errors.New("bad pid")),
+			ErrMalformed, "proc/1/stat",
+		},
+		{"truncated", parseError("proc/loadavg", ErrTruncated), ErrTruncated, "proc/loadavg"},
+		{
+			"permission", &fs.PathError{Op: "open", Path: "proc/2/fd", Err: syscall.EACCES},
+			fs.ErrPermission, "proc/2/fd",
+		},
+		{
+			"not exist", &fs.PathError{Op: "open", Path: "run/utmp", Err: syscall.ENOENT},
+			fs.ErrNotExist, "run/utmp",
+		},
+		{"wrapped", fmt.Errorf("reading: %w", syscall.EACCES), fs.ErrPermission, ""},
+		{"plain", errors.New("something else"), nil, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			decoded := gobRoundTrip(t, &Snapshot{Skipped: []error{tc.err}})
+			if len(decoded.Skipped) != 1 {
+				t.Fatalf("decoded Skipped = %v, want one error",
+					decoded.Skipped)
+			}
+
+			err := decoded.Skipped[0]
+			if err.Error() != tc.err.Error() {
+				t.Errorf("decoded %q, want %q",
+					err, tc.err)
+			}
+
+			for _, kind := range gobErrorKinds {
+				if errors.Is(err, kind.Err) != (kind.Err == tc.kind) {
+					t.Errorf("errors.Is(%v, %v) = %v",
+						err, kind.Err, kind.Err != tc.kind)
+				}
+			}
+
+			var pathErr *fs.PathError
+			if errors.As(err, &pathErr) != (tc.path != "") ||
+				(tc.path != "" && pathErr.Path != tc.path) {
+				t.Errorf("decoded %v has the wrong path, want %q",
+					err, tc.path)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,188 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/graphical.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: cf0fdba2-c7a2-11f1-ad47-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// compositors are the Wayland compositors and X11 window managers that are reported as what a
+// graphical session is running.
+var compositors = []string{
+	"gnome-shell", "kwin_wayland", "kwin_x11", "mutter", "sway", "weston", "Hyprland", "river",
+	"labwc", "wayfire", "niri", "cage", "cinnamon", "marco", "xfwm4", "openbox", "i3", "awesome",
+	"bspwm", "dwm", "xmonad", "fluxbox", "icewm", "fvwm", "metacity", "enlightenment",
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// xServers are the X servers whose displays are reported even without a logind session.
+var xServers = []string{"Xorg", "X", "Xvnc", "Xtigervnc"}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// vncServers are the X servers that are also VNC servers.
+var vncServers = []string{"Xvnc", "Xtigervnc"}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// timespec converts a time to the form of the timestamps in a syscall.Stat_t.
+func timespec(t time.Time) syscall.Timespec {
+	return syscall.NsecToTimespec(t.UnixNano())
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// xDisplay returns the display an X server was started for (its ":N" argument), or "".
+func xDisplay(p *Process) string {
+	for _, arg := range p.Argv[min(1, len(p.Argv)):] {
+		if strings.HasPrefix(arg, ":") {
+			return arg
+		}
+	}
+
+	return ""
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// compositor returns uid's compositor or window manager, preferring one in the logind session
+// with the given ID (whose processes share a session-ID.scope cgroup), or nil.
+func compositor(fsys fs.FS, procs map[int]*Process, uid uint32, id string) *Process {
+	var found *Process
+
+	for _, pid := range slices.Sorted(maps.Keys(procs)) {
+		p := procs[pid]
+		if p.UID != uid || !slices.Contains(compositors, p.Comm) {
+			continue
+		}
+
+		if id != "" {
+			cgroup, _ := fs.ReadFile(fsys, fmt.Sprintf("proc/%d/cgroup",
+				pid))
+			if strings.Contains(string(cgroup), "/session-"+id+".scope") {
+				return p
+			}
+		}
+
+		found = cmp.Or(found, p)
+	}
+
+	return found
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// graphicalSessions returns a TTY for each X11 or Wayland session: those that logind knows
+// about, and the displays of any other X servers.  The session's compositor or window manager
+// is reported as its process, and VNC and xrdp sessions are identified as such in Origin.
+func graphicalSessions(fsys fs.FS, procs map[int]*Process, sessions []LogindSession) []*TTY {
+	var ttys []*TTY
+
+	for _, s := range sessions {
+		if (s.Type != "x11" && s.Type != "wayland") || s.Class != "user" ||
+			s.State == "closing" {
+			continue
+		}
+
+		tty := &TTY{
+			Name:      cmp.Or(s.Display, s.TTY, "session-"+s.ID),
+			Type:      s.Type,
+			Origin:    s.Service,
+			Host:      s.RemoteHost,
+			Graphical: true,
+		}
+
+		tty.Stat.Uid = s.UID
+		tty.Stat.Ctim = timespec(s.Time)
+
+		p := cmp.Or(compositor(fsys, procs, s.UID, s.ID), procs[s.Leader])
+		if p == nil {
+			p = &Process{UID: s.UID, Comm: "?", Cmdline: "?"}
+		}
+
+		tty.Processes = []*Process{p}
+		ttys = append(ttys, tty)
+	}
+
+	var (
+		conns  []tcpConn
+		loaded bool
+	)
+
+	for _, pid := range slices.Sorted(maps.Keys(procs)) {
+		p := procs[pid]
+		if !slices.Contains(xServers, p.Comm) {
+			continue
+		}
+
+		display := xDisplay(p)
+		if display == "" {
+			continue
+		}
+
+		i := slices.IndexFunc(ttys, func(tty *TTY) bool {
+			return tty.Name == display
+		})
+		if i < 0 {
+			tty := &TTY{Name: display, Type: "x11", Graphical: true}
+
+			// The X server's /proc entry dates from when it started.
+			if stat, err := rawStat(fsys, fmt.Sprintf("proc/%d",
+				pid)); err == nil {
+				tty.Stat.Ctim = stat.Ctim
+			}
+
+			tty.Stat.Uid = p.UID
+			tty.Processes = []*Process{cmp.Or(compositor(fsys, procs, p.UID, ""), p)}
+			ttys = append(ttys, tty)
+			i = len(ttys) - 1
+		}
+
+		// Remote desktop sessions get their protocol as the origin and the client as the host.
+		if !loaded {
+			conns, _ = readTCP(fsys)
+			loaded = true
+		}
+
+		if kind, host := remoteDesktop(fsys, procs, p, conns); kind != "" {
+			ttys[i].Origin = kind
+			ttys[i].Host = cmp.Or(host, ttys[i].Host)
+		}
+	}
+
+	// Idle times are not tracked for graphical sessions; they repeat the login time.
+	for _, tty := range ttys {
+		tty.Stat.Atim, tty.Stat.Mtim = tty.Stat.Ctim, tty.Stat.Ctim
+	}
+
+	return ttys
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,149 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/interp.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 3a50da12-c7ab-11f1-8119-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// interpreter describes how an interpreter's command line names what it runs.
+type interpreter struct {
+	// valued are the options that take the next argument as their value.
+	valued []string
+
+	// named are the options whose value is what is run: a module, a jar, and so on.
+	named []string
+
+	// inline are the options that run code given on the command line, leaving nothing to name.
+	inline []string
+
+	// classes is set if the first argument that is not an option names a class, not a file.
+	classes bool
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// nodeInterpreter is Node.js, installed as node or, on Debian until bookworm, nodejs.
+var nodeInterpreter = interpreter{
+	valued: []string{"-r", "--require", "--import", "--loader", "--title"},
+	inline: []string{"-e", "--eval", "-p", "--print"},
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// interpreters are keyed by base name, less any version (so python3.12 is python).
+var interpreters = map[string]interpreter{
+	"python": {valued: []string{"-W", "-X", "-Q"}, named: []string{"-m"}, inline: []string{"-c"}},
+	"node":   nodeInterpreter,
+	"nodejs": nodeInterpreter,
+	"ruby":   {valued: []string{"-I", "-r", "-C", "-E"}, inline: []string{"-e"}},
+	"perl":   {inline: []string{"-e", "-E"}},
+	"java": {
+		valued: []string{
+			"-cp", "-classpath", "--class-path", "-p", "--module-path", "--add-modules",
+		},
+		named:   []string{"-jar", "-m", "--module"},
+		classes: true,
+	},
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Script returns the command line of a process run by one of the interpreters, with the
+// interpreter's own options left out and the script, jar, module, or main class it runs in
+// their place: "python3 shop/manage.py runserver" rather than "python3 -u manage.py runserver".
+// A script is named with its directory, resolved against Cwd if relative, since main.py or
+// index.js alone says little.  It returns nil for anything else, or for code given inline.
+func (p *Process) Script() []string {
+	if len(p.Argv) < 2 {
+		return nil
+	}
+
+	name := path.Base(p.Argv[0])
+
+	interp, ok := interpreters[strings.TrimRight(name, "0123456789.")]
+	if !ok {
+		return nil
+	}
+
+	for i := 1; i < len(p.Argv); i++ {
+		arg := p.Argv[i]
+
+		switch {
+		case slices.Contains(interp.inline, arg):
+			return nil
+
+		case slices.Contains(interp.named, arg) && i+1 < len(p.Argv):
+			named := p.Argv[i+1]
+			// Java's --module takes module/class, which is not a path.
+			if strings.Contains(named, "/") && (!interp.classes || arg == "-jar") {
+				named = p.scriptPath(named)
+			}
+
+			return slices.Concat([]string{name, named}, p.Argv[i+2:])
+
+		case slices.Contains(interp.valued, arg):
+			i++
+
+			continue
+
+		case arg == "--":
+			i++
+
+		case strings.HasPrefix(arg, "-"):
+			continue
+		}
+
+		if i == len(p.Argv) {
+			break
+		}
+
+		script := p.Argv[i]
+		if !interp.classes {
+			script = p.scriptPath(script)
+		}
+
+		return slices.Concat([]string{name, script}, p.Argv[i+1:])
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// scriptPath shortens a script's path to its directory's name and its own.
+func (p *Process) scriptPath(script string) string {
+	if !path.IsAbs(script) && p.Cwd != "" {
+		script = path.Join(p.Cwd, script)
+	}
+
+	dir := path.Base(path.Dir(script))
+	if dir == "/" || dir == "." {
+		return path.Base(script)
+	}
+
+	return path.Join(dir, path.Base(script))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,68 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/interp_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 6f230ff9-c7b4-11f1-821e-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestScript(t *testing.T) {
+	for _, tc := range []struct {
+		argv string
+		cwd  string
+		want string
+	}{
+		{"python3 -u manage.py runserver", "/srv/shop", "python3 shop/manage.py runserver"},
+		{"python3 -W ignore /opt/app/main.py", "/", "python3 app/main.py"},
+		{"python3.12 -m http.server 8000", "/tmp", "python3.12 http.server 8000"},
+		{"python3 -c print(1)", "/tmp", ""},
+		{"python3 -u", "/tmp", ""},
+		{"python3", "/tmp", ""},
+		{"/usr/bin/node --require dotenv/config index.js", "/srv/api", "node api/index.js"},
+		{"nodejs -e 1", "/srv/api", ""},
+		{"node -- serve.js --port 80", "/", "node serve.js --port 80"},
+		{"ruby -I lib bin/rails server", "/srv/shop", "ruby bin/rails server"},
+		{"perl sort.pl", "", "perl sort.pl"},
+		{"java -cp lib/x.jar com.example.Main run", "/srv", "java com.example.Main run"},
+		{"java -Xmx1g -jar build/app.jar --port 1", "/srv/app", "java build/app.jar --port 1"},
+		{"java --module app/com.example.Main", "/srv", "java app/com.example.Main"},
+		{"bash deploy.sh", "/srv", ""},
+	} {
+		t.Run(tc.argv, func(t *testing.T) {
+			p := &Process{Argv: strings.Fields(tc.argv), Cwd: tc.cwd}
+
+			var want []string
+			if tc.want != "" {
+				want = strings.Fields(tc.want)
+			}
+
+			if got := p.Script(); !slices.Equal(got, want) {
+				t.Errorf("Script = %q, want %q",
+					got, want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
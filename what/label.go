@@ -0,0 +1,118 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/label.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 58aaf276-c7a0-11f1-8908-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"iter"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// labelRule tags a session when any process in its ancestry matches.  Automated sessions are
+// ones no human is typing into, which --humans-only hides.
+type labelRule struct {
+	Label     string
+	Automated bool
+	Match     func(p *Process) bool
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// labelRules are checked in order against each ancestor of a session's foreground process,
+// nearest first, so the innermost matching environment wins.
+var labelRules = []labelRule{
+	{"vscode-remote", false, argvContains(
+		"/.vscode-server/", "/.vscode-server-insiders/", "/.vscode-remote/",
+		"/.vscodium-server/", "/.cursor-server/", "/.windsurf-server/")},
+	{"jetbrains-remote", false, argvContains(
+		"/RemoteDev/", "remote-dev-server", "/JetBrains/Gateway", "com.jetbrains.gateway")},
+	{"ansible", true, argvContains("/.ansible/tmp/", "AnsiballZ_")},
+	{"ansible", true, commIs("ansible", "ansible-playbook", "ansible-pull", "ansible-runner")},
+	{"cloud-init", true, commIs("cloud-init")},
+	{"expect", true, commIs("expect")},
+	{"ci", true, commIs(
+		"gitlab-runner", "Runner.Listener", "Runner.Worker", "buildkite-agent",
+		"drone-runner-exec", "woodpecker-agent", "act_runner", "forgejo-runner")},
+	{"ci", true, argvContains("/jenkins/remoting", "remoting.jar", "/buildAgent/")},
+	{"cron", true, commIs("cron", "crond", "anacron", "fcron", "cronie")},
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func argvContains(substrings ...string) func(p *Process) bool {
+	return func(p *Process) bool {
+		for _, arg := range p.Argv {
+			for _, substring := range substrings {
+				if strings.Contains(arg, substring) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// commIs matches on the kernel command name or, since comm is truncated to 15 characters and can
+// be changed by the process, the basename of argv[0].
+func commIs(names ...string) func(p *Process) bool {
+	return func(p *Process) bool {
+		return slices.Contains(names, p.Comm) ||
+			(len(p.Argv) > 0 && slices.Contains(names, filepath.Base(p.Argv[0])))
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ancestors yields p and then each of its parents, stopping at init or a missing process.
+func ancestors(procs map[int]*Process, p *Process) iter.Seq[*Process] {
+	return func(yield func(*Process) bool) {
+		// Guard against PID reuse producing a cycle between snapshots of parent and child.
+		for depth := 0; p != nil && depth < 64; depth++ {
+			if !yield(p) || p.PPID <= 1 {
+				return
+			}
+
+			p = procs[p.PPID]
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sessionLabel returns the first rule matching p's ancestry, or nil.
+func sessionLabel(procs map[int]*Process, p *Process) *labelRule {
+	for ancestor := range ancestors(procs, p) {
+		for i := range labelRules {
+			if labelRules[i].Match(ancestor) {
+				return &labelRules[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
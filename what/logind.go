@@ -0,0 +1,134 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/logind.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: a7bd53c9-c7a0-11f1-9f49-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// logindSessionsDir holds systemd-logind's per-session state files, relative to "/".
+const logindSessionsDir = "run/systemd/sessions"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// LogindSession is the subset of a systemd-logind session state file that go-what uses.
+type LogindSession struct {
+	ID         string
+	UID        uint32
+	User       string
+	TTY        string
+	Display    string
+	RemoteHost string
+	Service    string
+	Type       string
+	Class      string
+	State      string
+	Leader     int
+	Time       time.Time
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ReadLogindSessions parses the session state files that logind keeps under /run, which are
+// world-readable and so remain available when /proc is mounted with hidepid.
+func ReadLogindSessions() ([]LogindSession, error) {
+	return readLogindSessions(hostFS)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func readLogindSessions(fsys fs.FS) ([]LogindSession, error) {
+	files, err := fs.ReadDir(fsys, logindSessionsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []LogindSession
+
+	for _, f := range files {
+		// Skip the FIFO references held by session leaders.
+		if !f.Type().IsRegular() || strings.HasSuffix(f.Name(), ".ref") {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(logindSessionsDir, f.Name()))
+		if err != nil {
+			continue
+		}
+
+		s := LogindSession{ID: f.Name()}
+
+		for line := range strings.Lines(string(content)) {
+			key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+			if !ok {
+				continue
+			}
+
+			switch key {
+			case "UID":
+				uid, _ := strconv.ParseUint(value, 10, 32)
+				s.UID = uint32(uid)
+
+			case "USER":
+				s.User = value
+
+			case "TTY":
+				s.TTY = strings.TrimPrefix(value, "/dev/")
+
+			case "DISPLAY":
+				s.Display = value
+
+			case "REMOTE_HOST":
+				s.RemoteHost = value
+
+			case "SERVICE":
+				s.Service = value
+
+			case "TYPE":
+				s.Type = value
+
+			case "CLASS":
+				s.Class = value
+
+			case "STATE":
+				s.State = value
+
+			case "LEADER":
+				s.Leader, _ = strconv.Atoi(value)
+
+			case "REALTIME":
+				usec, _ := strconv.ParseInt(value, 10, 64)
+				s.Time = time.UnixMicro(usec)
+			}
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	return sessions, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,146 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/namespace.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: b77ea285-c7a3-11f1-99ad-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// namespaceKinds are the namespaces compared against init's, in display order.
+var namespaceKinds = []string{"mnt", "net", "pid", "user"}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readNamespaces returns the namespace links of the process whose /proc directory is dir (e.g.
+// "net" → "net:[4026531840]"), omitting any that could not be read.
+func readNamespaces(fsys fs.FS, dir string) map[string]string {
+	links := make(map[string]string)
+
+	for _, kind := range namespaceKinds {
+		link, err := fs.ReadLink(fsys, path.Join(dir, "ns", kind))
+		if err == nil {
+			links[kind] = link
+		}
+	}
+
+	return links
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// initialNamespaces returns init's namespace links.  Reading them takes privilege, so any that
+// could not be read are taken from go-what's own, which is nearly always started from the host.
+func initialNamespaces(fsys fs.FS) map[string]string {
+	links := readNamespaces(fsys, "proc/1")
+
+	for kind, link := range readNamespaces(fsys, "proc/self") {
+		if links[kind] == "" {
+			links[kind] = link
+		}
+	}
+
+	return links
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// divergentNamespaces returns the kinds of namespace p is in that init is not.  A kind that
+// could not be read for either process is left out rather than guessed at.
+func divergentNamespaces(fsys fs.FS, p *Process, initial map[string]string) []string {
+	var kinds []string
+
+	links := readNamespaces(fsys, fmt.Sprintf("proc/%d",
+		p.PID))
+
+	for _, kind := range namespaceKinds {
+		if links[kind] != "" && initial[kind] != "" && links[kind] != initial[kind] {
+			kinds = append(kinds, kind)
+		}
+	}
+
+	return kinds
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// idRange is one line of a uid_map: Count IDs from Inside in the namespace are Outside on.
+type idRange struct {
+	Inside, Outside, Count uint64
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readUIDMap returns the UID mapping of the user namespace process pid is in, as seen from the
+// host, or nil if it cannot be read.
+func readUIDMap(fsys fs.FS, pid int) []idRange {
+	data, err := fs.ReadFile(fsys, fmt.Sprintf("proc/%d/uid_map",
+		pid))
+	if err != nil {
+		return nil
+	}
+
+	var ranges []idRange
+
+	for line := range strings.Lines(string(data)) {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+
+		var r idRange
+
+		var errs [3]error
+
+		r.Inside, errs[0] = strconv.ParseUint(fields[0], 10, 32)
+		r.Outside, errs[1] = strconv.ParseUint(fields[1], 10, 32)
+		r.Count, errs[2] = strconv.ParseUint(fields[2], 10, 33)
+
+		if errs[0] == nil && errs[1] == nil && errs[2] == nil {
+			ranges = append(ranges, r)
+		}
+	}
+
+	return ranges
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// InsideUID returns what the host's uid is in the user namespace of p, if p is in one other than
+// the host's and uid maps to something different there (as a rootless container's root is a
+// high UID on the host).
+func (p *Process) InsideUID(uid uint32) (uint32, bool) {
+	for _, r := range p.uidMap {
+		if uint64(uid) >= r.Outside && uint64(uid)-r.Outside < r.Count {
+			inside := uint32(r.Inside + uint64(uid) - r.Outside) //nolint:gosec
+
+			return inside, inside != uid
+		}
+	}
+
+	return 0, false
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,110 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/output.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 228f80fb-c7ad-11f1-b714-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readWritten returns how many bytes process pid has written, from the wchar line of its io
+// file, if its standard output or error is the terminal rdev.  A pty's size is always zero and
+// the kernel updates its mtime at most every eight seconds, so neither can give a rate.
+func readWritten(fsys fs.FS, pid int, rdev uint64) (uint64, bool) {
+	writes := false
+
+	for fd := 1; fd <= 2 && !writes; fd++ {
+		stat, err := rawStat(fsys, fmt.Sprintf("proc/%d/fd/%d",
+			pid, fd))
+		writes = err == nil && stat.Mode&syscall.S_IFMT == syscall.S_IFCHR && stat.Rdev == rdev
+	}
+
+	if !writes {
+		return 0, false
+	}
+
+	content, err := fs.ReadFile(fsys, fmt.Sprintf("proc/%d/io",
+		pid))
+	if err != nil {
+		return 0, false
+	}
+
+	for line := range strings.SplitSeq(string(content), "\n") {
+		if value, ok := strings.CutPrefix(line, "wchar: "); ok {
+			n, err := strconv.ParseUint(value, 10, 64)
+
+			return n, err == nil
+		}
+	}
+
+	return 0, false
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// MeasureOutput sets the OutputRate of every terminal from what its processes wrote since
+// previous, and OutputInterval to the time between the two collections.  Both snapshots must
+// have been collected with Options.Output, or nothing is measured.  A process that was not
+// writing to the terminal in previous (one started since, say) counts everything it has ever
+// written, and one that has exited since counts nothing; the rate counts every write of the
+// processes whose standard output or error is the terminal, files included.
+func (snap *Snapshot) MeasureOutput(previous *Snapshot) {
+	if previous == nil || !previous.output || !snap.output {
+		return
+	}
+
+	elapsed := snap.Time.Sub(previous.Time)
+	if elapsed <= 0 {
+		return
+	}
+
+	before := make(map[string]*TTY)
+	for _, tty := range previous.TTYs {
+		before[tty.Name] = tty
+	}
+
+	for _, tty := range snap.TTYs {
+		var old map[int]uint64
+		if b, ok := before[tty.Name]; ok && b.Stat.Ctim == tty.Stat.Ctim {
+			old = b.written
+		}
+
+		var written uint64
+
+		for pid, n := range tty.written {
+			if m, ok := old[pid]; ok && m <= n {
+				n -= m
+			}
+
+			written += n
+		}
+
+		tty.OutputRate = float64(written) / elapsed.Seconds()
+	}
+
+	snap.OutputInterval = elapsed
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,378 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/proc.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 30872238-c7a0-11f1-b173-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// clockTicks is USER_HZ, the unit of the times in /proc/<pid>/stat, which the kernel fixes at 100
+// on every architecture regardless of its internal tick rate.
+const clockTicks = 100
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Process is the subset of /proc/<pid> that go-what cares about.
+type Process struct {
+	PID     int
+	PPID    int
+	PGRP    int
+	TTY     uint64
+	TPGID   int
+	UID     uint32
+	Comm    string
+	Cmdline string
+	Argv    []string
+	Partial bool
+
+	// CPU is the time the process has spent running, in user and kernel mode; ChildCPU is the
+	// same for its children that have exited and been waited for.
+	CPU      time.Duration
+	ChildCPU time.Duration
+
+	// CPUPercent is the share of one CPU the process used over Options.Sample.
+	CPUPercent float64
+
+	// Ancestry is the comm of each of the process's ancestors, starting below init and ending
+	// with the process itself, when Options.Ancestry asked for it.
+	Ancestry []string
+
+	// Root is the process's root directory when it has been chrooted away from the host's.
+	Root string
+
+	// Cwd is the working directory of reported processes, as go-what sees it.
+	Cwd string
+
+	// Namespaces lists the kinds of namespace ("mnt", "net", "pid", "user") the process does not
+	// share with init (or, if init's are unreadable, with go-what itself).
+	Namespaces []string
+
+	// AuditSession is the kernel's audit session ID of the process (its ses= in audit records),
+	// or 0 if it has none; AuditUnknown is set by CorrelateAudit if the audit trail has no
+	// record of it.
+	AuditSession uint32
+	AuditUnknown bool
+
+	// RealUID and EffectiveUID are the process's real and effective UIDs, from its status, for
+	// reported processes; UID, the owner of its /proc directory, is the effective one.  A
+	// setuid shell, or one left behind by sudo -s, has them differ.
+	RealUID      uint32
+	EffectiveUID uint32
+
+	// uidMap is the mapping of the process's user namespace, when it is not the host's.
+	uidMap []idRange
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Command renders the command line for display, falling back to the bracketed comm (as ps does)
+// when the command line is empty or could not be read.
+func (p *Process) Command() string {
+	if p.Cmdline == "" {
+		return "[" + p.Comm + "]"
+	}
+
+	return strings.ReplaceAll(strings.TrimRight(p.Cmdline, "\x00"), "\x00", " ")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readProcess reads the stat, cmdline, and ownership of a single process.  If only the command
+// line was unreadable, it returns both a Partial process and the error.
+func readProcess(fsys fs.FS, pid int) (*Process, error) {
+	statPath := fmt.Sprintf("proc/%d/stat",
+		pid)
+
+	statContent, err := fs.ReadFile(fsys, statPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cmdlinePath := fmt.Sprintf("proc/%d/cmdline",
+		pid)
+
+	// A process whose command line cannot be read is still worth reporting by its comm.
+	cmdlineContent, err := fs.ReadFile(fsys, cmdlinePath)
+	if err != nil && !errors.Is(err, fs.ErrPermission) {
+		return nil, err
+	}
+
+	partialErr := err
+
+	procStat, err := rawStat(fsys, fmt.Sprintf("proc/%d",
+		pid))
+	if err != nil {
+		return nil, err
+	}
+
+	// The command name is parenthesized and may itself contain spaces or parentheses, so the
+	// remaining fields are found relative to the last closing parenthesis.
+	stat := string(statContent)
+
+	i, j := strings.Index(stat, "("), strings.LastIndex(stat, ")")
+	if i == -1 || j < i {
+		return nil, parseError(statPath, ErrMalformed)
+	}
+
+	parts := strings.Fields(stat[j+1:])
+	if len(parts) < 15 {
+		return nil, parseError(statPath, ErrTruncated)
+	}
+
+	p := &Process{
+		PID:     pid,
+		UID:     procStat.Uid,
+		Comm:    stat[i+1 : j],
+		Cmdline: string(cmdlineContent),
+		Argv:    strings.Split(strings.TrimRight(string(cmdlineContent), "\x00"), "\x00"),
+		Partial: partialErr != nil,
+	}
+
+	var (
+		errs  [8]error
+		ticks [4]uint64
+	)
+
+	p.PPID, errs[0] = strconv.Atoi(parts[1])
+	p.PGRP, errs[1] = strconv.Atoi(parts[2])
+	p.TTY, errs[2] = strconv.ParseUint(parts[4], 10, 64)
+	p.TPGID, errs[3] = strconv.Atoi(parts[5])
+
+	// utime, stime, cutime, and cstime, in clock ticks.
+	for k := range ticks {
+		ticks[k], errs[4+k] = strconv.ParseUint(parts[11+k], 10, 64)
+	}
+
+	if err := errors.Join(errs[:]...); err != nil {
+		return nil, parseError(statPath, ErrMalformed, err)
+	}
+
+	p.CPU = time.Duration(ticks[0]+ticks[1]) * time.Second / clockTicks
+	p.ChildCPU = time.Duration(ticks[2]+ticks[3]) * time.Second / clockTicks
+
+	return p, partialErr
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readUIDs sets p.RealUID and p.EffectiveUID from the Uid line of p's status, or to p.UID if it
+// cannot be read.
+func readUIDs(fsys fs.FS, p *Process) {
+	p.RealUID, p.EffectiveUID = p.UID, p.UID
+
+	status, err := fs.ReadFile(fsys, fmt.Sprintf("proc/%d/status",
+		p.PID))
+	if err != nil {
+		return
+	}
+
+	for line := range strings.Lines(string(status)) {
+		if value, ok := strings.CutPrefix(line, "Uid:"); ok {
+			fields := strings.Fields(value)
+			if len(fields) < 2 {
+				return
+			}
+
+			ruid, err1 := strconv.ParseUint(fields[0], 10, 32)
+			euid, err2 := strconv.ParseUint(fields[1], 10, 32)
+
+			if err1 == nil && err2 == nil {
+				p.RealUID, p.EffectiveUID = uint32(ruid), uint32(euid)
+			}
+
+			return
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ptyMasters returns the pts indexes of every /dev/ptmx master held open by pid, read from the
+// tty-index field of the corresponding /proc/<pid>/fdinfo entries.  Failures on individual
+// descriptors are passed to skip rather than failing the whole process.
+func ptyMasters(fsys fs.FS, pid int, skip func(error)) ([]int, error) {
+	fdPath := fmt.Sprintf("proc/%d/fd",
+		pid)
+
+	fds, err := fs.ReadDir(fsys, fdPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexes []int
+
+	for _, fd := range fds {
+		link, err := fs.ReadLink(fsys, path.Join(fdPath, fd.Name()))
+		if err != nil {
+			skip(err)
+
+			continue
+		}
+
+		if link != "/dev/ptmx" && link != "/dev/pts/ptmx" {
+			continue
+		}
+
+		fdinfoPath := fmt.Sprintf("proc/%d/fdinfo/%s",
+			pid, fd.Name())
+
+		fdinfo, err := fs.ReadFile(fsys, fdinfoPath)
+		if err != nil {
+			skip(err)
+
+			continue
+		}
+
+		for line := range strings.Lines(string(fdinfo)) {
+			value, ok := strings.CutPrefix(line, "tty-index:")
+			if !ok {
+				continue
+			}
+
+			index, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				skip(parseError(fdinfoPath, ErrMalformed, err))
+
+				continue
+			}
+
+			indexes = append(indexes, index)
+		}
+	}
+
+	return indexes, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// procHidepid returns the hidepid= mount option of /proc ("2", "invisible", ...), or "" if other
+// users' processes are visible.
+func procHidepid(fsys fs.FS) string {
+	mountinfo, err := fs.ReadFile(fsys, "proc/self/mountinfo")
+	if err != nil {
+		return ""
+	}
+
+	for line := range strings.Lines(string(mountinfo)) {
+		fields := strings.Fields(line)
+
+		sep := slices.Index(fields, "-")
+		if sep < 5 || len(fields) < sep+4 || fields[4] != "/proc" || fields[sep+1] != "proc" {
+			continue
+		}
+
+		for option := range strings.SplitSeq(fields[sep+3], ",") {
+			value, ok := strings.CutPrefix(option, "hidepid=")
+			if ok && value != "0" && value != "off" {
+				return value
+			}
+		}
+	}
+
+	return ""
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// canSeeAllProcesses reports whether hidepid is bypassed, which takes CAP_SYS_PTRACE.
+func canSeeAllProcesses() bool {
+	hdr := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+
+	var data [2]unix.CapUserData
+
+	err := unix.Capget(&hdr, &data[0])
+
+	return err == nil && data[0].Effective&(1<<unix.CAP_SYS_PTRACE) != 0
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// login is what the utmp and logind databases know about a terminal session.
+type login struct {
+	User    string
+	Host    string
+	Service string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fallbackLogins collects the sessions registered in utmp and logind, keyed by TTY name, for use
+// when the processes behind them cannot be seen.
+func fallbackLogins(fsys fs.FS) map[string]login {
+	logins := make(map[string]login)
+
+	entries, _ := readCurrentUtmp(fsys)
+	for _, u := range entries {
+		if u.Type == utUserProcess && u.Line != "" {
+			logins[u.Line] = login{User: u.User, Host: u.Host}
+		}
+	}
+
+	sessions, _ := readLogindSessions(fsys)
+	for _, s := range sessions {
+		if s.TTY == "" {
+			continue
+		}
+
+		l := logins[s.TTY]
+		l.User = cmp.Or(l.User, s.User)
+		l.Host = cmp.Or(l.Host, s.RemoteHost)
+		l.Service = s.Service
+		logins[s.TTY] = l
+	}
+
+	return logins
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Denials counts the /proc reads that failed for lack of privilege.
+type Denials struct {
+	Processes int
+	Details   int
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (d *Denials) note(err error, detail bool) {
+	if !errors.Is(err, fs.ErrPermission) {
+		return
+	}
+
+	if detail {
+		d.Details++
+	} else {
+		d.Processes++
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,239 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/remote.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: f2b3583d-c7a2-11f1-9735-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// rdpPort is the port xrdp accepts RDP clients on, unless configured otherwise.
+const rdpPort = 3389
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// tcpConn is an established TCP connection from /proc/net/tcp or tcp6.
+type tcpConn struct {
+	Local  netip.AddrPort
+	Remote netip.AddrPort
+	Inode  string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// parseHexAddrPort decodes an address of /proc/net/tcp: the address in hex, as 32-bit words in
+// host (little-endian) order, then a colon and the port in hex.
+func parseHexAddrPort(s string) (netip.AddrPort, error) {
+	addrHex, portHex, _ := strings.Cut(s, ":")
+
+	b, err := hex.DecodeString(addrHex)
+	if err != nil || (len(b) != 4 && len(b) != 16) {
+		return netip.AddrPort{}, ErrMalformed
+	}
+
+	for i := 0; i < len(b); i += 4 {
+		slices.Reverse(b[i : i+4])
+	}
+
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return netip.AddrPort{}, ErrMalformed
+	}
+
+	addr, _ := netip.AddrFromSlice(b)
+
+	return netip.AddrPortFrom(addr.Unmap(), uint16(port)), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readTCP returns the established TCP connections of the current network namespace.
+func readTCP(fsys fs.FS) ([]tcpConn, error) {
+	var conns []tcpConn
+
+	for _, name := range []string{"proc/net/tcp", "proc/net/tcp6"} {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return conns, err
+		}
+
+		for line := range strings.Lines(string(content)) {
+			fields := strings.Fields(line)
+			if len(fields) < 10 || fields[3] != "01" {
+				continue
+			}
+
+			local, err := parseHexAddrPort(fields[1])
+			if err != nil {
+				return conns, parseError(name, err)
+			}
+
+			remote, err := parseHexAddrPort(fields[2])
+			if err != nil {
+				return conns, parseError(name, err)
+			}
+
+			conns = append(conns, tcpConn{Local: local, Remote: remote, Inode: fields[9]})
+		}
+	}
+
+	return conns, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// socketInodes returns the inodes of the sockets pid has open.
+func socketInodes(fsys fs.FS, pid int) ([]string, error) {
+	fdPath := fmt.Sprintf("proc/%d/fd",
+		pid)
+
+	fds, err := fs.ReadDir(fsys, fdPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var inodes []string
+
+	for _, fd := range fds {
+		link, _ := fs.ReadLink(fsys, path.Join(fdPath, fd.Name()))
+		if inode, ok := strings.CutPrefix(link, "socket:["); ok {
+			inodes = append(inodes, strings.TrimSuffix(inode, "]"))
+		}
+	}
+
+	return inodes, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// vncPort returns the port an Xvnc server listens for viewers on: its -rfbport, or 5900 plus
+// the display number.
+func vncPort(p *Process) int {
+	for i, arg := range p.Argv {
+		if value, ok := strings.CutPrefix(arg, "-rfbport="); ok {
+			port, _ := strconv.Atoi(value)
+
+			return port
+		}
+
+		if arg == "-rfbport" && i+1 < len(p.Argv) {
+			port, _ := strconv.Atoi(p.Argv[i+1])
+
+			return port
+		}
+	}
+
+	display, _, _ := strings.Cut(strings.TrimPrefix(xDisplay(p), ":"), ".")
+
+	n, err := strconv.Atoi(display)
+	if err != nil {
+		return 0
+	}
+
+	return 5900 + n
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// remoteDesktop works out whether the X server p serves a VNC or xrdp session, returning
+// "vnc" or "xrdp" (or "" for neither) and, if connected and visible, the client's address.
+// An xrdp client is found by following xrdp's loopback VNC connection back to the xrdp process
+// holding it, whose file descriptors are readable only with privilege.
+func remoteDesktop(fsys fs.FS, procs map[int]*Process, p *Process,
+	conns []tcpConn,
+) (string, string) {
+	vnc := slices.Contains(vncServers, p.Comm)
+
+	kind := ""
+	if vnc {
+		kind = "vnc"
+	}
+
+	for ancestor := range ancestors(procs, p) {
+		if ancestor.Comm == "xrdp-sesman" {
+			kind = "xrdp"
+		}
+	}
+
+	// With xorgxrdp, xrdp talks to Xorg over a unix socket, which leads nowhere.
+	if !vnc {
+		return kind, ""
+	}
+
+	port := vncPort(p)
+
+	for _, c := range conns {
+		if int(c.Local.Port()) != port {
+			continue
+		}
+
+		if kind == "vnc" || !c.Remote.Addr().IsLoopback() {
+			return kind, c.Remote.Addr().String()
+		}
+
+		if host := xrdpClient(fsys, procs, c, conns); host != "" {
+			return kind, host
+		}
+	}
+
+	return kind, ""
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// xrdpClient returns the address of the RDP client whose xrdp process holds the other end of
+// the loopback connection c, or "".
+func xrdpClient(fsys fs.FS, procs map[int]*Process, c tcpConn, conns []tcpConn) string {
+	i := slices.IndexFunc(conns, func(peer tcpConn) bool {
+		return peer.Local == c.Remote && peer.Remote == c.Local
+	})
+	if i < 0 {
+		return ""
+	}
+
+	for pid, p := range procs {
+		if p.Comm != "xrdp" {
+			continue
+		}
+
+		inodes, _ := socketInodes(fsys, pid)
+		if !slices.Contains(inodes, conns[i].Inode) {
+			continue
+		}
+
+		for _, client := range conns {
+			if client.Local.Port() == rdpPort && slices.Contains(inodes, client.Inode) {
+				return client.Remote.Addr().String()
+			}
+		}
+	}
+
+	return ""
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,69 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/sample.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: dc7a204c-c7a3-11f1-99cf-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"io/fs"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sampleCPU reads the CPU time of every process on ttys twice, d apart, and sets CPUPercent
+// from the difference.  A process that exits in between is left at zero.
+func sampleCPU(ctx context.Context, fsys fs.FS, ttys []*TTY, d time.Duration) error {
+	before := make(map[*Process]time.Duration)
+
+	start := time.Now()
+
+	for _, tty := range ttys {
+		for _, p := range tty.Processes {
+			if p.PID == 0 {
+				continue
+			}
+
+			if q, err := readProcess(fsys, p.PID); err == nil {
+				before[p] = q.CPU
+			}
+		}
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	elapsed := time.Since(start)
+
+	for p, cpu := range before {
+		if q, err := readProcess(fsys, p.PID); err == nil && q.CPU >= cpu {
+			p.CPUPercent = 100 * float64(q.CPU-cpu) / float64(elapsed)
+		}
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
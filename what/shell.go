@@ -0,0 +1,83 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/shell.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: f2261510-c7aa-11f1-9c06-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// shellOptions are the arguments that still leave a shell sitting at an interactive prompt.
+var shellOptions = []string{"-l", "--login", "-i", "--interactive"}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readShells returns the shells listed in etc/shells, by path and by base name, so that a shell
+// started by name, or from another directory (say, inside a container), is still recognized.
+func readShells(fsys fs.FS) map[string]bool {
+	content, err := fs.ReadFile(fsys, "etc/shells")
+	if err != nil {
+		return nil
+	}
+
+	shells := make(map[string]bool)
+
+	for line := range strings.Lines(string(content)) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		shells[line] = true
+		shells[path.Base(line)] = true
+	}
+
+	return shells
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// isLoginShell reports whether p is a shell waiting for its user rather than doing anything:
+// one whose argv[0] starts with '-', as login(1), sshd, and terminal multiplexers start them,
+// whatever the shell; or one of shells run with no arguments but shellOptions.
+func isLoginShell(p *Process, shells map[string]bool) bool {
+	args := strings.Split(strings.TrimRight(p.Cmdline, "\x00"), "\x00")
+	if strings.HasPrefix(args[0], "-") {
+		return true
+	}
+
+	if !shells[args[0]] && !shells[path.Base(args[0])] {
+		return false
+	}
+
+	for _, arg := range args[1:] {
+		if !slices.Contains(shellOptions, arg) {
+			return false
+		}
+	}
+
+	return true
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,71 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/shell_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 75cce2e7-c7b4-11f1-a266-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestIsLoginShell(t *testing.T) {
+	shells := readShells(fstest.MapFS{"etc/shells": {Data: []byte(
+		"# /etc/shells: valid login shells\n/bin/sh\n/usr/bin/bash\n\n  /usr/bin/zsh  \n")}})
+
+	for _, tc := range []struct {
+		argv string
+		want bool
+	}{
+		{"-bash", true},
+		{"-fish", true},
+		{"bash", true},
+		{"/usr/bin/bash -l", true},
+		{"/bin/bash --login -i", true},
+		{"/usr/local/bin/zsh --interactive", true},
+		{"sh", true},
+		{"bash deploy.sh", false},
+		{"bash -c make", false},
+		{"bash -l -c make", false},
+		{"fish", false},
+		{"vim", false},
+	} {
+		t.Run(tc.argv, func(t *testing.T) {
+			p := &Process{Cmdline: strings.ReplaceAll(tc.argv, " ", "\x00") + "\x00"}
+
+			if got := isLoginShell(p, shells); got != tc.want {
+				t.Errorf("isLoginShell = %v, want %v",
+					got, tc.want)
+			}
+		})
+	}
+
+	if shells["# /etc/shells: valid login shells"] || shells[""] {
+		t.Errorf("readShells kept a comment or blank line: %v",
+			shells)
+	}
+
+	if readShells(fstest.MapFS{}) != nil {
+		t.Error("readShells without etc/shells is not nil")
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,180 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/ssh.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 376b0860-c7a0-11f1-a2ba-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sshArgOptions are the ssh(1) options that consume an argument.
+const sshArgOptions = "BbcDEeFIiJLlmOoPpQRSWw"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sshHops parses an ssh client command line, returning any -J jump hosts followed by the
+// destination, or nil if there is no destination (e.g. a -W proxy child).
+func sshHops(argv []string) []string {
+	var (
+		hops        []string
+		destination string
+		user        string
+		proxy       bool
+	)
+
+	for i := 1; i < len(argv) && destination == ""; i++ {
+		arg := argv[i]
+		if arg == "--" {
+			if i+1 < len(argv) {
+				destination = argv[i+1]
+			}
+
+			break
+		}
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			destination = arg
+
+			break
+		}
+
+		// Walk clustered flags (e.g. -tAJ host) until one that takes an argument.
+		for j := 1; j < len(arg); j++ {
+			if !strings.ContainsRune(sshArgOptions, rune(arg[j])) {
+				continue
+			}
+
+			value := arg[j+1:]
+			if value == "" && i+1 < len(argv) {
+				i++
+				value = argv[i]
+			}
+
+			switch arg[j] {
+			case 'J':
+				if value != "none" {
+					hops = append(hops, strings.Split(value, ",")...)
+				}
+
+			case 'l':
+				user = value
+
+			case 'W':
+				proxy = true
+
+			case 'o':
+				key, val, _ := strings.Cut(value, "=")
+				if strings.EqualFold(strings.TrimSpace(key), "ProxyJump") && val != "none" {
+					hops = append(hops, strings.Split(strings.TrimSpace(val), ",")...)
+				}
+			}
+
+			break
+		}
+	}
+
+	if destination == "" || proxy {
+		return nil
+	}
+
+	destination = strings.TrimPrefix(destination, "ssh://")
+	if user != "" && !strings.Contains(destination, "@") {
+		destination = user + "@" + destination
+	}
+
+	return append(hops, destination)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sshChain reconstructs the onward hops of ssh clients running on a terminal.  Jump hosts given
+// with -J are taken from the command line; ones configured in ssh_config only show up as -W
+// proxy children, which are reported in place of the missing -J list.
+func sshChain(procs map[int]*Process, rdev uint64) []string {
+	var clients []*Process
+
+	for _, p := range procs {
+		if p.TTY != rdev || !isSSHClient(p) {
+			continue
+		}
+
+		if parent, ok := procs[p.PPID]; ok && isSSHClient(parent) {
+			continue
+		}
+
+		clients = append(clients, p)
+	}
+
+	slices.SortFunc(clients, func(a, b *Process) int {
+		return a.PID - b.PID
+	})
+
+	var chain []string
+
+	for _, client := range clients {
+		hops := sshHops(client.Argv)
+		if len(hops) == 0 {
+			continue
+		}
+
+		if len(hops) == 1 {
+			for _, child := range procs {
+				if child.PPID == client.PID && isSSHClient(child) {
+					hops = append([]string{proxyDestination(child.Argv)}, hops...)
+				}
+			}
+		}
+
+		chain = append(chain, hops...)
+	}
+
+	return slices.DeleteFunc(chain, func(hop string) bool {
+		return hop == ""
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// proxyDestination returns the host an "ssh -W target:port jump" proxy child connects through.
+func proxyDestination(argv []string) string {
+	for i := 1; i < len(argv); i++ {
+		arg := argv[i]
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+
+		if len(arg) == 2 && strings.ContainsRune(sshArgOptions, rune(arg[1])) {
+			i++
+		}
+	}
+
+	return ""
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func isSSHClient(p *Process) bool {
+	return p.Comm == "ssh" || (len(p.Argv) > 0 && filepath.Base(p.Argv[0]) == "ssh")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
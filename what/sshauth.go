@@ -0,0 +1,169 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/sshauth.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 9e6eeb23-c7a7-11f1-8d9b-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// AuthLogPaths are where syslog daemons commonly write sshd's messages, relative to "/".
+var AuthLogPaths = []string{"/var/log/auth.log", "/var/log/secure"}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// SSHAuth is an ssh login as sshd logged it: the sshd process that accepted it, the user and
+// the address it came from, and, for a public key, the key's fingerprint, or for a certificate,
+// its key ID and serial.
+type SSHAuth struct {
+	PID    int
+	User   string
+	Method string
+	Addr   string
+	Port   int
+
+	KeyType     string
+	Fingerprint string
+	CertID      string
+	CertSerial  string
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sshAcceptedPattern matches sshd's log message for an accepted login, with the key details
+// that follow "ssh2:" for public keys and certificates.
+var sshAcceptedPattern = regexp.MustCompile(
+	`\bsshd(?:-session)?\[(\d+)\]: Accepted (\S+) for (\S+) from (\S+) port (\d+)` +
+		`(?: ssh2(?:: (\S+) (\S+)(?: ID (.*) \(serial (\d+)\) CA .*)?)?)?`)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ParseSSHAuthLog returns the logins that sshd's messages in r record, in order.  Any syslog line
+// format works, since the message is found by its sshd[PID] tag.
+func ParseSSHAuthLog(r io.Reader) ([]SSHAuth, error) {
+	var auths []SSHAuth
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+
+	for scanner.Scan() {
+		m := sshAcceptedPattern.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+
+		pid, _ := strconv.Atoi(m[1])
+		port, _ := strconv.Atoi(m[5])
+
+		auths = append(auths, SSHAuth{
+			PID: pid, Method: m[2], User: m[3], Addr: m[4], Port: port,
+			KeyType: m[6], Fingerprint: m[7], CertID: m[8], CertSerial: m[9],
+		})
+	}
+
+	return auths, scanner.Err()
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ReadSSHAuthLog reads sshd's logins from path or, if path is "", from the first of AuthLogPaths
+// that exists, falling back to the systemd journal when none does.
+func ReadSSHAuthLog(path string) ([]SSHAuth, error) {
+	if path == "" {
+		for _, p := range AuthLogPaths {
+			if _, err := os.Stat(p); err == nil {
+				path = p
+
+				break
+			}
+		}
+	}
+
+	if path == "" {
+		out, err := exec.Command("journalctl", "--no-pager", "--quiet", "-o", "short",
+			"-t", "sshd", "-t", "sshd-session").Output()
+		if err != nil {
+			return nil, err
+		}
+
+		return ParseSSHAuthLog(strings.NewReader(string(out)))
+	}
+
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return ParseSSHAuthLog(f)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// sshdAncestors returns the PIDs of the sshd processes above p, nearest first: for a login, the
+// session's unprivileged child and the privileged monitor that logged the authentication.
+func sshdAncestors(procs map[int]*Process, p *Process) []int {
+	var pids []int
+
+	for seen := 0; p != nil && p.PID > 1 && seen < 64; seen++ {
+		if strings.HasPrefix(p.Comm, "sshd") {
+			pids = append(pids, p.PID)
+		}
+
+		p = procs[p.PPID]
+	}
+
+	return pids
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// AttributeSSHAuth sets TTY.SSHAuth on every terminal whose session descends from an sshd
+// process that auths records accepting a login.  A PID that sshd logged more than once, having
+// been reused, is taken at its last login.
+func AttributeSSHAuth(snap *Snapshot, auths []SSHAuth) {
+	byPID := make(map[int]*SSHAuth, len(auths))
+
+	for i := range auths {
+		byPID[auths[i].PID] = &auths[i]
+	}
+
+	for _, tty := range snap.TTYs {
+		for _, pid := range tty.sshdPIDs {
+			if auth := byPID[pid]; auth != nil {
+				tty.SSHAuth = auth
+
+				break
+			}
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,146 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/sshauth_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 6387495a-c7b4-11f1-8b7d-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestParseSSHAuthLog(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		line string
+		want []SSHAuth
+	}{
+		{
+			"a password",
+			"Jan  2 03:04:05 host sshd[812]: Accepted password for alice from 198.51.100.7 " +
+				"port 50022 ssh2",
+			[]SSHAuth{{
+				PID: 812, User: "alice", Method: "password", Addr: "198.51.100.7", Port: 50022,
+			}},
+		},
+		{
+			"a public key",
+			"2026-01-02T03:04:05.000000+00:00 host sshd-session[913]: Accepted publickey for " +
+				"bob from 2001:db8::7 port 41234 ssh2: ED25519 SHA256:AbCdEf0123",
+			[]SSHAuth{{
+				PID: 913, User: "bob", Method: "publickey", Addr: "2001:db8::7", Port: 41234,
+				KeyType: "ED25519", Fingerprint: "SHA256:AbCdEf0123",
+			}},
+		},
+		{
+			"a certificate",
+			"Jan  2 03:04:05 host sshd[1014]: Accepted publickey for carol from 192.0.2.9 " +
+				"port 2222 ssh2: ED25519-CERT SHA256:Cert0123 ID carol@example (serial 42) " +
+				"CA ED25519 SHA256:Ca0123",
+			[]SSHAuth{{
+				PID: 1014, User: "carol", Method: "publickey", Addr: "192.0.2.9", Port: 2222,
+				KeyType: "ED25519-CERT", Fingerprint: "SHA256:Cert0123",
+				CertID: "carol@example", CertSerial: "42",
+			}},
+		},
+		{
+			"keyboard-interactive without ssh2",
+			"sshd[77]: Accepted keyboard-interactive/pam for root from 10.0.0.1 port 22",
+			[]SSHAuth{{
+				PID: 77, User: "root", Method: "keyboard-interactive/pam", Addr: "10.0.0.1",
+				Port: 22,
+			}},
+		},
+		{
+			"a failure",
+			"Jan  2 03:04:05 host sshd[812]: Failed password for alice from 198.51.100.7 " +
+				"port 50022 ssh2",
+			nil,
+		},
+		{
+			"another daemon",
+			"Jan  2 03:04:05 host notsshd[812]: Accepted password for alice from 198.51.100.7 " +
+				"port 50022 ssh2",
+			nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseSSHAuthLog(strings.NewReader(tc.line + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParseSSHAuthLog = %+v, want %+v",
+					got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestAttributeSSHAuth(t *testing.T) {
+	procs := map[int]*Process{
+		1:   {PID: 1, Comm: "systemd"},
+		500: {PID: 500, PPID: 1, Comm: "sshd"},
+		812: {PID: 812, PPID: 500, Comm: "sshd-session"},
+		813: {PID: 813, PPID: 812, Comm: "sshd-session"},
+		900: {PID: 900, PPID: 813, Comm: "bash"},
+		901: {PID: 901, PPID: 1, Comm: "agetty"},
+	}
+
+	want := []int{813, 812, 500}
+	if got := sshdAncestors(procs, procs[900]); !slices.Equal(got, want) {
+		t.Errorf("sshdAncestors = %v, want %v",
+			got, want)
+	}
+
+	if got := sshdAncestors(procs, procs[901]); got != nil {
+		t.Errorf("sshdAncestors of a console login = %v",
+			got)
+	}
+
+	auths, err := ParseSSHAuthLog(strings.NewReader(
+		"sshd[812]: Accepted password for alice from 192.0.2.1 port 1 ssh2\n" +
+			"sshd[812]: Accepted password for bob from 192.0.2.2 port 2 ssh2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote := &TTY{Name: "pts/0", sshdPIDs: sshdAncestors(procs, procs[900])}
+	console := &TTY{Name: "tty1", sshdPIDs: sshdAncestors(procs, procs[901])}
+
+	AttributeSSHAuth(&Snapshot{TTYs: []*TTY{remote, console}}, auths)
+
+	if remote.SSHAuth == nil || remote.SSHAuth.User != "bob" {
+		t.Errorf("remote SSHAuth = %+v, want the last login by PID 812",
+			remote.SSHAuth)
+	}
+
+	if console.SSHAuth != nil {
+		t.Errorf("console SSHAuth = %+v, want none",
+			console.SSHAuth)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,177 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/utmp.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: a7b31e0a-c7a0-11f1-b15f-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"net/netip"
+	"os"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// utmp record types, from <utmp.h>.
+const (
+	utBootTime     = 2
+	utLoginProcess = 6
+	utUserProcess  = 7
+	utDeadProcess  = 8
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// utmpRecord is the on-disk struct utmp shared by utmp, wtmp, and btmp on Linux (384 bytes; the
+// 32-bit time fields are the same on every architecture for compatibility).
+type utmpRecord struct {
+	Type    int16
+	_       int16
+	PID     int32
+	Line    [32]byte
+	ID      [4]byte
+	User    [32]byte
+	Host    [256]byte
+	Exit    [2]int16
+	Session int32
+	Sec     int32
+	Usec    int32
+	Addr    [16]byte
+	_       [20]byte
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Utmp is a decoded utmp record.
+type Utmp struct {
+	Type int
+	PID  int
+	Line string
+	User string
+	Host string
+	Addr netip.Addr
+	Time time.Time
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// utmpPaths lists the places the current-logins database is commonly found, relative to "/".
+var utmpPaths = []string{"run/utmp", "var/run/utmp"}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ReadUtmp decodes every record in a utmp-format file (utmp, wtmp, or btmp).
+func ReadUtmp(path string) ([]Utmp, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return decodeUtmp(f)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func decodeUtmp(r io.Reader) ([]Utmp, error) {
+	var entries []Utmp
+
+	for {
+		var rec utmpRecord
+
+		err := binary.Read(r, binary.LittleEndian, &rec)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return entries, nil
+		}
+
+		if err != nil {
+			return entries, err
+		}
+
+		entries = append(entries, rec.decode())
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// readCurrentUtmp reads the first utmp database that exists.
+func readCurrentUtmp(fsys fs.FS) ([]Utmp, error) {
+	var err error
+
+	for _, name := range utmpPaths {
+		var f fs.File
+
+		f, err = fsys.Open(name)
+		if err != nil {
+			continue
+		}
+
+		entries, err := decodeUtmp(f)
+		_ = f.Close()
+
+		return entries, err
+	}
+
+	return nil, err
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (rec *utmpRecord) decode() Utmp {
+	u := Utmp{
+		Type: int(rec.Type),
+		PID:  int(rec.PID),
+		Line: cString(rec.Line[:]),
+		User: cString(rec.User[:]),
+		Host: cString(rec.Host[:]),
+		Time: time.Unix(int64(rec.Sec), int64(rec.Usec)*1000),
+	}
+
+	// IPv4 addresses occupy only the first word of ut_addr_v6.
+	if bytes.Equal(rec.Addr[4:], make([]byte, 12)) {
+		u.Addr = netip.AddrFrom4([4]byte(rec.Addr[:4]))
+	} else {
+		u.Addr = netip.AddrFrom16(rec.Addr)
+	}
+
+	if u.Addr.IsUnspecified() {
+		u.Addr = netip.Addr{}
+	}
+
+	return u
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+
+	return string(b)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
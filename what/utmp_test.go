@@ -0,0 +1,143 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/utmp_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 21122e12-c7b4-11f1-93a1-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// utmpBytes lays out a 384-byte struct utmp by its field offsets in <bits/utmp.h>, independently
+// of utmpRecord.
+func utmpBytes(typ int16, pid int32, line, user, host string, at time.Time, addr []byte) []byte {
+	b := make([]byte, 384)
+
+	binary.LittleEndian.PutUint16(b[0:], uint16(typ))
+	binary.LittleEndian.PutUint32(b[4:], uint32(pid))
+	copy(b[8:40], line)
+	copy(b[40:44], "ts/0")
+	copy(b[44:76], user)
+	copy(b[76:332], host)
+	binary.LittleEndian.PutUint32(b[336:], 4242) // ut_session
+	binary.LittleEndian.PutUint32(b[340:], uint32(at.Unix()))
+	binary.LittleEndian.PutUint32(b[344:], uint32(at.Nanosecond()/1000))
+	copy(b[348:364], addr)
+
+	return b
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestUtmpRecordSize(t *testing.T) {
+	if size := binary.Size(utmpRecord{}); size != 384 {
+		t.Errorf("utmpRecord is %d bytes, want 384",
+			size)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestDecodeUtmp(t *testing.T) {
+	login := time.Date(2026, 10, 14, 9, 30, 0, 123456000, time.UTC)
+	long := "0123456789abcdef0123456789abcdef" // fills ut_line, with no NUL
+
+	var file bytes.Buffer
+
+	file.Write(utmpBytes(utBootTime, 0, "~", "reboot", "6.18.0", login, nil))
+	file.Write(utmpBytes(utUserProcess, 1234, "pts/0", "alice", "198.51.100.7", login,
+		[]byte{198, 51, 100, 7}))
+	file.Write(utmpBytes(utUserProcess, 1235, long, "bob", "2001:db8::7", login,
+		netip.MustParseAddr("2001:db8::7").AsSlice()))
+	file.Write(utmpBytes(utDeadProcess, 1236, "tty1", "", "", login, nil))
+
+	// A record cut short, as by a write under way, is left out.
+	file.Write(utmpBytes(utUserProcess, 1237, "pts/9", "carol", "", login, nil)[:100])
+
+	got, err := decodeUtmp(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local := login.Local()
+	want := []Utmp{
+		{Type: utBootTime, Line: "~", User: "reboot", Host: "6.18.0", Time: local},
+		{
+			Type: utUserProcess, PID: 1234, Line: "pts/0", User: "alice", Host: "198.51.100.7",
+			Addr: netip.MustParseAddr("198.51.100.7"), Time: local,
+		},
+		{
+			Type: utUserProcess, PID: 1235, Line: long, User: "bob", Host: "2001:db8::7",
+			Addr: netip.MustParseAddr("2001:db8::7"), Time: local,
+		},
+		{Type: utDeadProcess, PID: 1236, Line: "tty1", Time: local},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeUtmp =\n%+v\nwant\n%+v",
+			got, want)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestReadUtmp(t *testing.T) {
+	login := time.Unix(1767323045, 0)
+	record := utmpBytes(utUserProcess, 1234, "pts/0", "alice", "", login, nil)
+
+	name := filepath.Join(t.TempDir(), "wtmp")
+	if err := os.WriteFile(name, append(record, record...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := ReadUtmp(name)
+	if err != nil || len(entries) != 2 || entries[1].User != "alice" {
+		t.Errorf("ReadUtmp = %+v, %v, want alice's two records",
+			entries, err)
+	}
+
+	if _, err := ReadUtmp(filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("ReadUtmp of a missing file = %v, want a not-exist error",
+			err)
+	}
+
+	// The current logins are read from /var/run/utmp when there is no /run/utmp.
+	fsys := fstest.MapFS{"var/run/utmp": {Data: record}}
+
+	if entries, err := readCurrentUtmp(fsys); err != nil || len(entries) != 1 ||
+		entries[0].Line != "pts/0" || !entries[0].Time.Equal(login) {
+		t.Errorf("readCurrentUtmp = %+v, %v, want alice's login on pts/0",
+			entries, err)
+	}
+
+	if _, err := readCurrentUtmp(fstest.MapFS{}); err == nil {
+		t.Error("readCurrentUtmp with no utmp succeeded")
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
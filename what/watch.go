@@ -0,0 +1,220 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/watch.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 26144a3f-c7a1-11f1-8471-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// EventType says what happened to a session.
+type EventType int
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Session event types.
+const (
+	SessionAdded EventType = iota + 1
+	SessionUpdated
+	SessionRemoved
+
+	// WatchFailed is the last event of a Watch whose collection failed, with the error.
+	WatchFailed
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (t EventType) String() string {
+	switch t {
+	case SessionAdded:
+		return "added"
+
+	case SessionUpdated:
+		return "updated"
+
+	case SessionRemoved:
+		return "removed"
+
+	case WatchFailed:
+		return "failed"
+	}
+
+	return "unknown"
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// SessionEvent reports a change to a terminal session between two collections.  For removals,
+// TTY is the last state seen; for updates, Previous is the state before the change.  A
+// WatchFailed event has neither, only Err.
+type SessionEvent struct {
+	Type     EventType
+	Time     time.Time
+	TTY      *TTY
+	Previous *TTY
+	Err      error
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// WatchOptions configures Watch.
+type WatchOptions struct {
+	Options
+
+	// Interval is the time between collections; the default is one second.
+	Interval time.Duration
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Watch collects repeatedly and delivers the differences as typed events.  The sessions present
+// at the start are delivered as SessionAdded.  A session is updated when its foreground
+// processes, origin, or label change; idle times alone do not count.  A terminal that was closed
+// and reopened between collections is reported as a removal followed by an addition.
+//
+// Watch polls: the proc connector would need CAP_NET_ADMIN, and still would not report the tty
+// timestamps.  The channel is closed when ctx is done, or after a WatchFailed event if a
+// collection fails, so that the two can be told apart.
+func Watch(ctx context.Context, opts WatchOptions) (<-chan SessionEvent, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+
+	snap, err := Collect(ctx, opts.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan SessionEvent)
+
+	go func() {
+		defer close(events)
+
+		var previous *Snapshot
+
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+
+		for {
+			for _, event := range Diff(previous, snap) {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			next, err := Collect(ctx, opts.Options)
+			if err != nil {
+				if ctx.Err() == nil {
+					select {
+					case events <- SessionEvent{Type: WatchFailed, Time: time.Now(), Err: err}:
+					case <-ctx.Done():
+					}
+				}
+
+				return
+			}
+
+			if opts.Output {
+				next.MeasureOutput(snap)
+			}
+
+			previous, snap = snap, next
+		}
+	}()
+
+	return events, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Diff returns the session events that turn previous into next; a nil previous is treated as
+// empty.
+func Diff(previous, next *Snapshot) []SessionEvent {
+	before := make(map[string]*TTY)
+
+	if previous != nil {
+		for _, tty := range previous.TTYs {
+			if len(tty.Processes) > 0 {
+				before[tty.Name] = tty
+			}
+		}
+	}
+
+	var events []SessionEvent
+
+	seen := make(map[string]bool)
+
+	for _, tty := range next.TTYs {
+		if len(tty.Processes) == 0 {
+			continue
+		}
+
+		seen[tty.Name] = true
+
+		old, ok := before[tty.Name]
+
+		switch {
+		case !ok:
+			events = append(events, SessionEvent{Type: SessionAdded, Time: next.Time, TTY: tty})
+
+		case old.Stat.Ctim != tty.Stat.Ctim || old.Stat.Uid != tty.Stat.Uid:
+			events = append(events,
+				SessionEvent{Type: SessionRemoved, Time: next.Time, TTY: old},
+				SessionEvent{Type: SessionAdded, Time: next.Time, TTY: tty})
+
+		case changed(old, tty):
+			events = append(events,
+				SessionEvent{Type: SessionUpdated, Time: next.Time, TTY: tty, Previous: old})
+		}
+	}
+
+	if previous != nil {
+		for _, tty := range previous.TTYs {
+			if len(tty.Processes) > 0 && !seen[tty.Name] {
+				events = append(events,
+					SessionEvent{Type: SessionRemoved, Time: next.Time, TTY: tty})
+			}
+		}
+	}
+
+	return events
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func changed(a, b *TTY) bool {
+	return a.Origin != b.Origin || a.Label != b.Label ||
+		!slices.EqualFunc(a.Processes, b.Processes, func(p, q *Process) bool {
+			return p.PID == q.PID && p.Cmdline == q.Cmdline
+		})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
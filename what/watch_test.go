@@ -0,0 +1,226 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/watch_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: cd92d3b0-c7b0-11f1-934e-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// session returns a terminal of uid logged in at login, running the commands as PIDs 100 on.
+func session(name string, uid uint32, login int64, cmdlines ...string) *TTY {
+	tty := &TTY{Name: name, Stat: syscall.Stat_t{Uid: uid, Ctim: syscall.Timespec{Sec: login}}}
+
+	for i, cmdline := range cmdlines {
+		tty.Processes = append(tty.Processes, &Process{PID: 100 + i, Cmdline: cmdline})
+	}
+
+	return tty
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// eventList summarizes events as "type tty" strings, in order.
+func eventList(events []SessionEvent) string {
+	list := make([]string, 0, len(events))
+	for _, event := range events {
+		list = append(list, fmt.Sprintf("%s %s",
+			event.Type, event.TTY.Name))
+	}
+
+	return strings.Join(list, ", ")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestDiff(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		previous, next []*TTY
+		want           string
+	}{
+		{
+			"unchanged",
+			[]*TTY{session("pts/0", 1000, 1, "vim")},
+			[]*TTY{session("pts/0", 1000, 1, "vim")},
+			"",
+		},
+		{
+			"login",
+			nil,
+			[]*TTY{session("pts/0", 1000, 1, "vim")},
+			"added pts/0",
+		},
+		{
+			"logout",
+			[]*TTY{session("pts/0", 1000, 1, "vim")},
+			[]*TTY{session("pts/0", 1000, 1)},
+			"removed pts/0",
+		},
+		{
+			"gone from the snapshot",
+			[]*TTY{session("pts/0", 1000, 1, "vim")},
+			nil,
+			"removed pts/0",
+		},
+		{
+			"new foreground command",
+			[]*TTY{session("pts/0", 1000, 1, "vim")},
+			[]*TTY{session("pts/0", 1000, 1, "make")},
+			"updated pts/0",
+		},
+		{
+			"a new login on the same terminal",
+			[]*TTY{session("pts/0", 1000, 1, "vim")},
+			[]*TTY{session("pts/0", 1000, 2, "vim")},
+			"removed pts/0, added pts/0",
+		},
+		{
+			"another user on the same terminal",
+			[]*TTY{session("pts/0", 1000, 1, "vim")},
+			[]*TTY{session("pts/0", 1001, 1, "vim")},
+			"removed pts/0, added pts/0",
+		},
+		{
+			"terminals without processes are not sessions",
+			[]*TTY{session("tty1", 0, 1)},
+			[]*TTY{session("tty1", 0, 1), session("tty2", 0, 1)},
+			"",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			previous := &Snapshot{TTYs: tc.previous}
+			if tc.previous == nil {
+				previous = nil
+			}
+
+			events := Diff(previous, &Snapshot{TTYs: tc.next})
+			if got := eventList(events); got != tc.want {
+				t.Errorf("Diff = %q, want %q",
+					got, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestDiffUpdatePrevious(t *testing.T) {
+	old := session("pts/0", 1000, 1, "vim")
+	next := session("pts/0", 1000, 1, "make", "cc1")
+
+	events := Diff(&Snapshot{TTYs: []*TTY{old}}, &Snapshot{TTYs: []*TTY{next}})
+	if len(events) != 1 || events[0].Previous != old || events[0].TTY != next {
+		t.Fatalf("Diff = %+v, want one update from the old state to the new",
+			events)
+	}
+
+	if !slices.Equal(commands(events[0].TTY), []string{"make", "cc1"}) {
+		t.Errorf("the update runs %q",
+			commands(events[0].TTY))
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestWatch(t *testing.T) {
+	fsys := fixtureFS()
+	addProcess(fsys, 200, 200, 1000, "vim", "vim\x00", fixturePts, 200)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := Watch(ctx, WatchOptions{Options: Options{FS: fsys}, Interval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if event := <-events; event.Type != SessionAdded || event.TTY.Name != "pts/0" {
+		t.Errorf("the first event is %s %s, want the session present at the start",
+			event.Type, event.TTY.Name)
+	}
+
+	cancel()
+
+	for event := range events {
+		if event.Type == WatchFailed {
+			t.Errorf("canceling the watch sent %s: %v",
+				event.Type, event.Err)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// stallingFS is a fixture whose /proc listing, after the first, takes longer than a collection
+// may.
+type stallingFS struct {
+	fstest.MapFS
+
+	listings atomic.Int32
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ReadDir implements fs.ReadDirFS.
+func (s *stallingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == "proc" && s.listings.Add(1) > 1 {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return s.MapFS.ReadDir(name)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestWatchFailed(t *testing.T) {
+	fsys := &stallingFS{MapFS: fixtureFS()}
+	addProcess(fsys.MapFS, 200, 200, 1000, "vim", "vim\x00", fixturePts, 200)
+
+	events, err := Watch(context.Background(), WatchOptions{
+		Options: Options{FS: fsys, Timeout: 10 * time.Millisecond}, Interval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last SessionEvent
+	for event := range events {
+		last = event
+	}
+
+	if last.Type != WatchFailed || !errors.Is(last.Err, context.DeadlineExceeded) {
+		t.Errorf("the last event is %s (%v), want %s with the collection's error",
+			last.Type, last.Err, WatchFailed)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,104 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/wsl.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 7b40f8fc-c7a3-11f1-866e-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// isWSL reports whether the host is a Windows Subsystem for Linux distribution.
+func isWSL(fsys fs.FS) bool {
+	for _, name := range []string{
+		"proc/sys/fs/binfmt_misc/WSLInterop", "proc/sys/fs/binfmt_misc/WSLInterop-late",
+	} {
+		if _, err := fs.Stat(fsys, name); err == nil {
+			return true
+		}
+	}
+
+	release, _ := fs.ReadFile(fsys, "proc/sys/kernel/osrelease")
+
+	return strings.Contains(strings.ToLower(string(release)), "microsoft")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// isWSLRelay reports whether p is one of the copies of WSL's /init that relay a Windows-side
+// terminal (or wsl.exe invocation) to a pty: they rename themselves SessionLeader and
+// Relay(PID), or on older releases keep the name init.
+func isWSLRelay(p *Process) bool {
+	return p.Comm == "SessionLeader" || strings.HasPrefix(p.Comm, "Relay(") ||
+		(p.Comm == "init" && p.PID != 1)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// wslTerminal names the Windows program a WSL session was started from, judging by the
+// environment it passed on: Windows Terminal sets WT_SESSION, and VS Code TERM_PROGRAM.
+func wslTerminal(fsys fs.FS, p *Process) string {
+	environ, err := fs.ReadFile(fsys, fmt.Sprintf("proc/%d/environ",
+		p.PID))
+	if err != nil {
+		return ""
+	}
+
+	for variable := range bytes.SplitSeq(environ, []byte{0}) {
+		switch {
+		case bytes.HasPrefix(variable, []byte("WT_SESSION=")):
+			return "Windows Terminal"
+
+		case bytes.Equal(variable, []byte("TERM_PROGRAM=vscode")):
+			return "VS Code"
+		}
+	}
+
+	return ""
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// labelWSLSession labels a session started through a WSL relay, and replaces its origin (the
+// relay, an opaque init) with the Windows program it came from, or else "wsl.exe".
+func labelWSLSession(fsys fs.FS, procs map[int]*Process, tty *TTY) {
+	if len(tty.Processes) == 0 || tty.Graphical || tty.Container != nil {
+		return
+	}
+
+	terminal := ""
+
+	for ancestor := range ancestors(procs, tty.Processes[0]) {
+		if isWSLRelay(ancestor) {
+			tty.Label = cmp.Or(tty.Label, "wsl")
+			tty.Origin = cmp.Or(terminal, "wsl.exe")
+
+			return
+		}
+
+		terminal = cmp.Or(terminal, wslTerminal(fsys, ancestor))
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,58 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/wtmp.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 8685f514-c7a2-11f1-a309-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import "time"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// WtmpPath is where login programs append the history of logins and logouts.
+const WtmpPath = "/var/log/wtmp"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// PreviousLogin returns the most recent wtmp login of user before the session on line that
+// started at start, ignoring the record of that session itself.  This is what lastlog would
+// have reported when the session began; lastlog itself has since been overwritten by it.
+func PreviousLogin(entries []Utmp, user, line string, start time.Time) (Utmp, bool) {
+	var (
+		previous Utmp
+		found    bool
+	)
+
+	for _, e := range entries {
+		if e.Type != utUserProcess || e.User != user {
+			continue
+		}
+
+		// The session's own record is written around the time its terminal is set up.
+		if e.Line == line && e.Time.Sub(start).Abs() < time.Minute {
+			continue
+		}
+
+		if e.Time.Before(start) && (!found || e.Time.After(previous.Time)) {
+			previous, found = e, true
+		}
+	}
+
+	return previous, found
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
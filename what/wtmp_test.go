@@ -0,0 +1,88 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - what/wtmp_test.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: 2122c808-c7b4-11f1-8ff9-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+package what
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+func TestPreviousLogin(t *testing.T) {
+	start := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	// A wtmp file, as login programs write it, decoded.
+	var file bytes.Buffer
+
+	for _, r := range []struct {
+		typ  int16
+		line string
+		user string
+		host string
+		at   time.Time
+	}{
+		{utUserProcess, "pts/1", "alice", "old.example", at(-72 * time.Hour)},
+		{utDeadProcess, "pts/1", "", "", at(-71 * time.Hour)},
+		{utUserProcess, "pts/2", "alice", "last.example", at(-24 * time.Hour)},
+		{utUserProcess, "pts/3", "bob", "bob.example", at(-time.Hour)},
+		{utLoginProcess, "tty1", "alice", "", at(-30 * time.Minute)},
+		{utUserProcess, "pts/0", "alice", "now.example", at(2 * time.Second)}, // this session
+		{utUserProcess, "pts/4", "alice", "later.example", at(time.Hour)},
+	} {
+		file.Write(utmpBytes(r.typ, 1, r.line, r.user, r.host, r.at, nil))
+	}
+
+	entries, err := decodeUtmp(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		user  string
+		line  string
+		start time.Time
+		want  string // the previous login's host, or "" for none
+	}{
+		{"the login before", "alice", "pts/0", start, "last.example"},
+		{"not the session's own record", "alice", "pts/0", at(time.Second), "last.example"},
+		{
+			// On another terminal, the record a few seconds on is another session.
+			"a session on another terminal", "alice", "pts/9", at(time.Minute), "now.example",
+		},
+		{"another user", "bob", "pts/5", start, "bob.example"},
+		{"an earlier session", "alice", "pts/2", at(-24 * time.Hour), "old.example"},
+		{"the first login", "alice", "pts/1", at(-72 * time.Hour), ""},
+		{"no logins", "carol", "pts/0", start, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			previous, found := PreviousLogin(entries, tc.user, tc.line, tc.start)
+			if found != (tc.want != "") || previous.Host != tc.want {
+				t.Errorf("PreviousLogin = %+v, %v, want the login from %q",
+					previous, found, tc.want)
+			}
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,278 @@
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// go-what - whatjson/whatjson.go
+// Copyright (c) 2016 MIT PDOS
+// Copyright (c) 2025-2026 Jeffrey H. Johnson
+// SPDX-License-Identifier: MIT
+// scspell-id: e552b4af-c7a0-11f1-8ff6-80ee73e9b8e7
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Package whatjson defines the documents written by go-what's JSON output modes, so that Go
+// consumers can decode them without re-declaring the structures.
+//
+// Compatibility rules: within a given SchemaVersion, fields are only ever added.  An existing
+// field is never removed or renamed, and never changes its type or meaning, so consumers must
+// ignore fields they do not recognize.  Fields tagged omitempty may be absent when unknown.
+// Any change that an existing consumer could misread increments SchemaVersion.
+package whatjson
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+import "time"
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// SchemaVersion is the version of the structures in this package.
+const SchemaVersion = 1
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Snapshot is the document written by --format json.
+type Snapshot struct {
+	SchemaVersion int       `json:"schema_version"`
+	Sessions      []Session `json:"sessions"`
+	NoTTY         []NoTTY   `json:"notty"`
+	Warnings      []Warning `json:"warnings"`
+
+	// Uptime is the host's uptime in seconds, Users the number of users with processes, and
+	// Load the 1, 5, and 15 minute load averages, when they could be read.
+	Uptime float64   `json:"uptime,omitempty"`
+	Users  int       `json:"users"`
+	Load   []float64 `json:"load,omitempty"`
+
+	// Running and Processes are the numbers of runnable and of all processes, as the "procs"
+	// of the table's header gives them.
+	Running   int `json:"procs_running,omitempty"`
+	Processes int `json:"procs,omitempty"`
+
+	// Hostname is the host's name, Time when collection began, and Duration how long it took,
+	// in seconds.
+	Hostname string    `json:"hostname,omitempty"`
+	Time     time.Time `json:"time"`
+	Duration float64   `json:"duration"`
+
+	// Node names the host the snapshot was collected on, when the collector was told it (as
+	// go-what serve is, by --node-name).
+	Node string `json:"node,omitempty"`
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Session is one foreground process running on a terminal.
+type Session struct {
+	User      string    `json:"user"`
+	UID       uint32    `json:"uid"`
+	TTY       string    `json:"tty"`
+	Type      string    `json:"type"`
+	Login     time.Time `json:"login"`
+	Input     time.Time `json:"input"`
+	Output    time.Time `json:"output"`
+	Origin    string    `json:"origin,omitempty"`
+	Host      string    `json:"host,omitempty"`
+	Label     string    `json:"label,omitempty"`
+	Automated bool      `json:"automated,omitempty"`
+	PID       int       `json:"pid,omitempty"`
+	PPID      int       `json:"ppid,omitempty"`
+	Command   string    `json:"command"`
+	Argv      []string  `json:"argv,omitempty"`
+
+	// CPUTime is the CPU time used by the process and its waited-for children, in seconds.
+	CPUTime float64 `json:"cpu_time,omitempty"`
+
+	// CPUPercent is the share of one CPU the process used over the --sample interval; it is
+	// reported only with --sample.
+	CPUPercent *float64 `json:"cpu_percent,omitempty"`
+
+	// OutputRate is how many bytes per second were written to the terminal, measured between
+	// two collections; it is reported only with --rate.
+	OutputRate *float64 `json:"output_rate,omitempty"`
+
+	// Ancestry is the comm of each ancestor below init, ending with the process itself; it is
+	// reported only with --ancestry.
+	Ancestry []string `json:"ancestry,omitempty"`
+
+	// Container is the ID (or, for LXC and machined, the name) of the container the terminal
+	// belongs to, and Runtime its runtime; ExecBy is the user whose exec started the session,
+	// when known.
+	Container string `json:"container,omitempty"`
+	Runtime   string `json:"runtime,omitempty"`
+	ExecBy    string `json:"exec_by,omitempty"`
+
+	// Chroot is the process's root directory when it has been chrooted away from the host's.
+	Chroot string `json:"chroot,omitempty"`
+
+	// Namespaces lists the kinds of namespace (mnt, net, pid, user) the process does not share
+	// with init.
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// RealUID is the process's real UID when it differs from its effective UID (the one uid
+	// and user go by for the process), as for a setuid shell.
+	RealUID *uint32 `json:"real_uid,omitempty"`
+
+	// UsernsUID is what UID is in the process's user namespace, when that is not the host's
+	// and maps it to something else.
+	UsernsUID *uint32 `json:"userns_uid,omitempty"`
+
+	// AuditSession is the process's audit session ID, when it has one; AuditUnknown says that
+	// the audit log has no record of that session (only with --audit).
+	AuditSession uint32 `json:"audit_session,omitempty"`
+	AuditUnknown bool   `json:"audit_unknown,omitempty"`
+
+	// SSHKey is the fingerprint of the public key that authenticated an ssh login, and
+	// SSHCertID and SSHCertSerial identify its certificate, from sshd's log.
+	SSHKey        string `json:"ssh_key,omitempty"`
+	SSHCertID     string `json:"ssh_cert_id,omitempty"`
+	SSHCertSerial string `json:"ssh_cert_serial,omitempty"`
+
+	// SSHAuthMethod is how an ssh login authenticated, as sshd names it (publickey, password,
+	// keyboard-interactive/pam, gssapi-with-mic, ...).  These fields are filled in only with
+	// --ssh-key or --auth-method.
+	SSHAuthMethod string `json:"ssh_auth_method,omitempty"`
+
+	// Graphical marks an X11 or Wayland session, whose TTY is its display; Input and Output
+	// are not tracked for these and repeat Login.
+	Graphical bool `json:"graphical,omitempty"`
+
+	// AtPrompt marks a login shell waiting for a command, reported only with --idle-shells.
+	AtPrompt bool `json:"at_prompt,omitempty"`
+
+	// LoginPrompt marks a terminal sitting at a getty, reported only with --all-ttys.
+	LoginPrompt bool `json:"login_prompt,omitempty"`
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// NoTTY counts a user's processes that have no controlling terminal.
+type NoTTY struct {
+	User      string `json:"user"`
+	UID       uint32 `json:"uid"`
+	Processes int    `json:"processes"`
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Warning says that the snapshot is incomplete.  An empty warnings array means that everything
+// was read.
+type Warning struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Warning kinds.  Consumers should treat unknown kinds like WarningRead.
+const (
+	WarningPermission = "permission" // not allowed to read Path
+	WarningParse      = "parse"      // Path had unexpected contents
+	WarningTruncated  = "truncated"  // Path was shorter than expected
+	WarningRead       = "read"       // any other failure reading Path
+	WarningHidepid    = "hidepid"    // /proc hides other users; sessions come from utmp/logind
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Record is one line written by --format ndjson.  Exactly one of Session, NoTTY, or Warning is
+// set, according to Kind.
+type Record struct {
+	SchemaVersion int      `json:"schema_version"`
+	Kind          string   `json:"kind"`
+	Session       *Session `json:"session,omitempty"`
+	NoTTY         *NoTTY   `json:"notty,omitempty"`
+	Warning       *Warning `json:"warning,omitempty"`
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Record kinds.
+const (
+	KindSession = "session"
+	KindNoTTY   = "notty"
+	KindWarning = "warning"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Event is a change to a terminal session, as streamed by go-what serve at /v1/events.  Sessions
+// are the terminal's foreground processes after the change (as last seen, for a removal), and
+// Previous those before an update.
+type Event struct {
+	SchemaVersion int       `json:"schema_version"`
+	Type          string    `json:"type"`
+	Time          time.Time `json:"time"`
+	TTY           string    `json:"tty"`
+	Node          string    `json:"node,omitempty"`
+	Sessions      []Session `json:"sessions"`
+	Previous      []Session `json:"previous,omitempty"`
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Event types.
+const (
+	EventAdded   = "added"
+	EventUpdated = "updated"
+	EventRemoved = "removed"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Alert is posted by go-what --follow to a --webhook when a rule is broken: Rule names it,
+// Message says what happened, and Sessions are the foreground processes of the terminal that
+// broke it.
+type Alert struct {
+	SchemaVersion int       `json:"schema_version"`
+	Rule          string    `json:"rule"`
+	Time          time.Time `json:"time"`
+	Hostname      string    `json:"hostname,omitempty"`
+	Message       string    `json:"message"`
+	Sessions      []Session `json:"sessions"`
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Fleet is the document written by --format json when several hosts are collected at once.
+type Fleet struct {
+	SchemaVersion int    `json:"schema_version"`
+	Hosts         []Host `json:"hosts"`
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Host is one host of a Fleet: its snapshot or, if it could not be collected, why not.
+type Host struct {
+	Host     string    `json:"host"`
+	Error    string    `json:"error,omitempty"`
+	Snapshot *Snapshot `json:"snapshot,omitempty"`
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Failures is the document written by "go-what failed --format json".
+type Failures struct {
+	SchemaVersion int           `json:"schema_version"`
+	Since         time.Time     `json:"since"`
+	Failures      []FailedLogin `json:"failures"`
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+
+// FailedLogin counts the failed login attempts for one user from one source (a remote host or
+// address, or a terminal).
+type FailedLogin struct {
+	User   string    `json:"user"`
+	Source string    `json:"source"`
+	Count  int       `json:"count"`
+	First  time.Time `json:"first"`
+	Last   time.Time `json:"last"`
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// Local Variables:
+// mode: go
+// tab-width: 4
+// eval: (setq-local display-fill-column-indicator-column 100)
+// eval: (display-fill-column-indicator-mode 1)
+// End:
+///////////////////////////////////////////////////////////////////////////////////////////////////
+// vim: set ft=go noexpandtab tabstop=4 cc=100 :
+///////////////////////////////////////////////////////////////////////////////////////////////////